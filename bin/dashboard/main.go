@@ -31,7 +31,14 @@ func main() {
 	dashboardPort := viper.GetInt(configkey.DashboardPort)
 	useRequestLogger := viper.GetBool(configkey.RequestLogger)
 	db, _ := database.CreateDatabase()
-	handler := server.NewDashboardHandler(repositories.NewAccountRepository(db), repositories.NewSnapsRepository(db))
+	accounts := repositories.NewAccountRepository(db)
+	err := accounts.BackfillSSHKeyFingerprints()
+	if err != nil {
+		panic(err)
+	}
+
+	handler := server.NewDashboardHandler(accounts, repositories.NewSnapsRepository(db), repositories.NewDischargeTokensRepository(db),
+		repositories.NewSnapNamesRepository(db))
 
 	s := server.New(useRequestLogger, handler, dashboardPort)
 
@@ -4,22 +4,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/freetocompute/kebe/config"
 	"github.com/freetocompute/kebe/config/configkey"
 	"github.com/freetocompute/kebe/pkg/admind"
 	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
 	resty "github.com/go-resty/resty/v2"
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"golang.org/x/oauth2"
+	"golang.org/x/term"
 )
 
 var username string
 var accountId string
 var email string
 var displayName string
+var validation string
+var keyName string
+var keyPath string
+var keySHA3384 string
 
 const (
 	LoginConfigFilename = ".loginconfig"
@@ -36,6 +47,57 @@ func init() {
 	_ = add.MarkFlagRequired("display-name")
 	_ = add.MarkFlagRequired("account-id")
 	_ = add.MarkFlagRequired("username")
+	add.Flags().StringVarP(&validation, "validation", "v", "", "The validation level of the account: unproven, starred or verified")
+
+	account.AddCommand(listAccounts)
+
+	account.AddCommand(showAccount)
+	showAccount.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	_ = showAccount.MarkFlagRequired("account-id")
+
+	account.AddCommand(updateAccount)
+	updateAccount.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	updateAccount.Flags().StringVarP(&username, "username", "u", "", "The new username of the account")
+	updateAccount.Flags().StringVarP(&email, "email", "e", "", "The new email of the user")
+	updateAccount.Flags().StringVarP(&displayName, "display-name", "d", "", "The new display name for the account")
+	_ = updateAccount.MarkFlagRequired("account-id")
+
+	account.AddCommand(suspendAccount)
+	suspendAccount.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	_ = suspendAccount.MarkFlagRequired("account-id")
+
+	account.AddCommand(reactivateAccount)
+	reactivateAccount.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	_ = reactivateAccount.MarkFlagRequired("account-id")
+
+	account.AddCommand(deleteAccount)
+	deleteAccount.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	_ = deleteAccount.MarkFlagRequired("account-id")
+
+	account.AddCommand(setValidation)
+	setValidation.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	setValidation.Flags().StringVarP(&validation, "validation", "v", "", "The validation level of the account: unproven, starred or verified")
+	_ = setValidation.MarkFlagRequired("account-id")
+	_ = setValidation.MarkFlagRequired("validation")
+
+	account.AddCommand(setPassword)
+	setPassword.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	_ = setPassword.MarkFlagRequired("account-id")
+
+	account.AddCommand(resetTwoFactor)
+	resetTwoFactor.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	_ = resetTwoFactor.MarkFlagRequired("account-id")
+
+	account.AddCommand(addKey)
+	addKey.Flags().StringVarP(&accountId, "account-id", "a", "", "The account id of the user")
+	addKey.Flags().StringVarP(&keyName, "name", "n", "", "The name of the key")
+	addKey.Flags().StringVarP(&keyPath, "key-file", "f", "", "A PEM encoded RSA private key to use, a new key is generated if not given")
+	_ = addKey.MarkFlagRequired("account-id")
+	_ = addKey.MarkFlagRequired("name")
+
+	account.AddCommand(revokeKey)
+	revokeKey.Flags().StringVarP(&keySHA3384, "key", "k", "", "The public-key-sha3-384 of the key to revoke")
+	_ = revokeKey.MarkFlagRequired("key")
 }
 
 var account = &cobra.Command{
@@ -100,56 +162,238 @@ var add = &cobra.Command{
 	Use:   "add",
 	Short: "add",
 	Run: func(cmd *cobra.Command, args []string) {
-		var loginInfo admind.LoginInfo
-		bytes, _ := ioutil.ReadFile(LoginConfigFilename)
-		err := json.Unmarshal(bytes, &loginInfo)
+		addAccountRequest := requests.AddAccount{
+			Username:    username,
+			AcccountId:  accountId,
+			Email:       email,
+			DisplayName: displayName,
+			Validation:  validation,
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/account", &addAccountRequest)
+
+		var added responses.Account
+		err := json.Unmarshal(bytes, &added)
 		if err != nil {
 			panic(err)
 		}
 
-		// we've expired and we need to refresh, so for now
-		// force a login
-		if time.Now().After(loginInfo.Token.Expiry) {
-			fmt.Printf("Token has expired, refreshing.")
-			loginInfoPtr, err := refreshToken(loginInfo.Token.RefreshToken)
-			if err != nil {
-				panic(err)
-			}
+		fmt.Printf("Added account %s\n", added.AccountId)
+	},
+}
+
+var listAccounts = &cobra.Command{
+	Use:   "list",
+	Short: "list",
+	Run: func(cmd *cobra.Command, args []string) {
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/accounts", nil)
+
+		var accounts []responses.Account
+		err := json.Unmarshal(bytes, &accounts)
+		if err != nil {
+			panic(err)
+		}
 
-			if loginInfoPtr != nil {
-				loginInfo = *loginInfoPtr
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Account Id", "Username", "Display Name", "Email", "Validation", "Suspended", "Snaps"})
+		for _, a := range accounts {
+			suspended := ""
+			if a.SuspendedAt != nil {
+				suspended = a.SuspendedAt.Format(time.RFC3339)
 			}
+
+			table.Append([]string{a.AccountId, a.Username, a.DisplayName, a.Email, a.Validation, suspended, strconv.Itoa(len(a.Snaps))})
 		}
+		table.Render()
+	},
+}
 
-		addAccountRequest := requests.AddAccount{
+var showAccount = &cobra.Command{
+	Use:   "show",
+	Short: "show",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		query.Set("account", accountId)
+
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/account?"+query.Encode(), nil)
+
+		var a responses.Account
+		err := json.Unmarshal(bytes, &a)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("account-id:   %s\n", a.AccountId)
+		fmt.Printf("username:     %s\n", a.Username)
+		fmt.Printf("display-name: %s\n", a.DisplayName)
+		fmt.Printf("email:        %s\n", a.Email)
+		fmt.Printf("validation:   %s\n", a.Validation)
+		fmt.Printf("created:      %s\n", a.CreatedAt.Format(time.RFC3339))
+		if a.SuspendedAt != nil {
+			fmt.Printf("suspended:    %s\n", a.SuspendedAt.Format(time.RFC3339))
+		}
+		if a.Admin {
+			fmt.Printf("admin:        true\n")
+		}
+		fmt.Printf("snaps:        %s\n", strings.Join(a.Snaps, ", "))
+	},
+}
+
+var updateAccount = &cobra.Command{
+	Use:   "update",
+	Short: "Changes the username, email or display name of an account",
+	Run: func(cmd *cobra.Command, args []string) {
+		updateAccountReq := requests.UpdateAccount{
+			AccountId:   accountId,
 			Username:    username,
-			AcccountId:  accountId,
 			Email:       email,
 			DisplayName: displayName,
 		}
 
-		// Create a Resty Client
-		client := resty.New()
+		adminDRequest(http.MethodPost, "/v1/admin/account/update", &updateAccountReq)
+	},
+}
+
+var suspendAccount = &cobra.Command{
+	Use:   "suspend",
+	Short: "Stops an account from logging in, pushing and releasing and hides its snaps",
+	Run: func(cmd *cobra.Command, args []string) {
+		suspendAccountReq := requests.SuspendAccount{
+			AccountId: accountId,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/account/suspend", &suspendAccountReq)
+	},
+}
+
+var reactivateAccount = &cobra.Command{
+	Use:   "reactivate",
+	Short: "Lifts the suspension of an account",
+	Run: func(cmd *cobra.Command, args []string) {
+		reactivateAccountReq := requests.ReactivateAccount{
+			AccountId: accountId,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/account/reactivate", &reactivateAccountReq)
+	},
+}
+
+var deleteAccount = &cobra.Command{
+	Use:   "delete",
+	Short: "Deletes an account that doesn't publish any snaps",
+	Run: func(cmd *cobra.Command, args []string) {
+		deleteAccountReq := requests.DeleteAccount{
+			AccountId: accountId,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/account/delete", &deleteAccountReq)
+	},
+}
 
-		admindURL := config.MustGetString(configkey.AdminDURL)
+var setValidation = &cobra.Command{
+	Use:   "set-validation",
+	Short: "set-validation",
+	Run: func(cmd *cobra.Command, args []string) {
+		setValidationRequest := requests.SetAccountValidation{
+			AccountId:  accountId,
+			Validation: validation,
+		}
 
-		accountURL := admindURL + "/v1/admin/account"
+		adminDRequest(http.MethodPost, "/v1/admin/account/validation", &setValidationRequest)
+	},
+}
 
-		bytes, _ = json.Marshal(&addAccountRequest)
-		resp, err := client.R().
-			SetBody(bytes).
-			SetHeader("Authorization", loginInfo.Token.AccessToken).
-			Post(accountURL)
+var setPassword = &cobra.Command{
+	Use:   "set-password",
+	Short: "Sets the password of an account for the login service's local identity backend",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print("Password: ")
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			panic(err)
+		}
 
+		fmt.Print("Repeat password: ")
+		repeated, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
 		if err != nil {
 			panic(err)
 		}
 
-		if resp.StatusCode() != 200 {
-			if resp.Error() != nil {
-				panic(resp.Error())
+		if string(password) != string(repeated) {
+			fmt.Println("The passwords don't match")
+			return
+		}
+
+		setPasswordRequest := requests.SetAccountPassword{
+			AccountId: accountId,
+			Password:  string(password),
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/account/password", &setPasswordRequest)
+	},
+}
+
+var resetTwoFactor = &cobra.Command{
+	Use:   "reset-twofactor",
+	Short: "Removes the 2-factor device of an account that lost it",
+	Run: func(cmd *cobra.Command, args []string) {
+		resetTwoFactorRequest := requests.ResetTwoFactor{
+			AccountId: accountId,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/account/twofactor/reset", &resetTwoFactorRequest)
+	},
+}
+
+var addKey = &cobra.Command{
+	Use:   "add-key",
+	Short: "add-key",
+	Run: func(cmd *cobra.Command, args []string) {
+		addAccountKeyReq := requests.AddAccountKey{
+			AccountId: accountId,
+			Name:      keyName,
+		}
+
+		if keyPath != "" {
+			keyBytes, err := ioutil.ReadFile(keyPath)
+			if err != nil {
+				panic(err)
 			}
-			panic("there was a problem: " + strconv.Itoa(resp.StatusCode()))
+			addAccountKeyReq.PrivateKey = string(keyBytes)
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/account/key", &addAccountKeyReq)
+
+		var accountKey responses.AccountKey
+		err := json.Unmarshal(bytes, &accountKey)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Added key %q: %s\n", accountKey.Name, accountKey.SHA3384)
+	},
+}
+
+var revokeKey = &cobra.Command{
+	Use:   "revoke-key",
+	Short: "revoke-key",
+	Run: func(cmd *cobra.Command, args []string) {
+		revokeAccountKeyReq := requests.RevokeAccountKey{
+			SHA3384: keySHA3384,
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/account/key/revoke", &revokeAccountKeyReq)
+
+		var accountKey responses.AccountKey
+		err := json.Unmarshal(bytes, &accountKey)
+		if err != nil {
+			panic(err)
+		}
+
+		if accountKey.Until != nil {
+			fmt.Printf("Revoked key %q: %s, not trusted after %s\n", accountKey.Name, accountKey.SHA3384, accountKey.Until.Format(time.RFC3339))
 		}
 	},
 }
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var actionsSubject string
+var actionsLimit int
+
+func init() {
+	actions.Flags().StringVarP(&actionsSubject, "subject", "s", "", "Only list the actions of the admin with this OIDC subject")
+	actions.Flags().IntVarP(&actionsLimit, "limit", "l", 0, "The number of actions to list, the most recent first")
+}
+
+var actions = &cobra.Command{
+	Use:   "actions",
+	Short: "Lists the changes made by admins",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		if actionsSubject != "" {
+			query.Set("subject", actionsSubject)
+		}
+		if actionsLimit > 0 {
+			query.Set("limit", strconv.Itoa(actionsLimit))
+		}
+
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/actions?"+query.Encode(), nil)
+
+		var adminActions []responses.AdminAction
+		err := json.Unmarshal(bytes, &adminActions)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"At", "Admin", "Email", "Method", "Path", "Target", "Status"})
+		for _, a := range adminActions {
+			path := a.Path
+			if a.Query != "" {
+				path += "?" + a.Query
+			}
+			table.Append([]string{a.At.Format(time.RFC3339), a.Username, a.Email, a.Method, path, a.Body, strconv.Itoa(a.Status)})
+		}
+		table.Render()
+	},
+}
@@ -19,6 +19,14 @@ func init() {
 	Admin.AddCommand(login)
 	Admin.AddCommand(account)
 	Admin.AddCommand(track)
+	Admin.AddCommand(serial)
+	Admin.AddCommand(model)
+	Admin.AddCommand(validationSet)
+	Admin.AddCommand(actions)
+	Admin.AddCommand(token)
+	Admin.AddCommand(organization)
+	Admin.AddCommand(sshKey)
+	Admin.AddCommand(snapNames)
 }
 
 var Admin = &cobra.Command{
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/freetocompute/kebe/config"
+	"github.com/freetocompute/kebe/config/configkey"
+	"github.com/freetocompute/kebe/pkg/admind"
+	resty "github.com/go-resty/resty/v2"
+)
+
+// mustGetLoginInfo reads the saved login information, refreshing the token if it has expired
+func mustGetLoginInfo() admind.LoginInfo {
+	var loginInfo admind.LoginInfo
+	bytes, _ := ioutil.ReadFile(LoginConfigFilename)
+	err := json.Unmarshal(bytes, &loginInfo)
+	if err != nil {
+		panic(err)
+	}
+
+	// sessions with local credentials can't be refreshed
+	if time.Now().After(loginInfo.Token.Expiry) && loginInfo.Token.RefreshToken == "" {
+		panic("the admind session has expired, log in again")
+	}
+
+	// we've expired and we need to refresh, so for now
+	// force a login
+	if time.Now().After(loginInfo.Token.Expiry) {
+		fmt.Printf("Token has expired, refreshing.\n")
+		loginInfoPtr, err := refreshToken(loginInfo.Token.RefreshToken)
+		if err != nil {
+			panic(err)
+		}
+
+		if loginInfoPtr != nil {
+			loginInfo = *loginInfoPtr
+		}
+	}
+
+	return loginInfo
+}
+
+// adminDRequest sends a request to admind at the given path and returns the response body,
+// panicking if the request fails or returns a non-success status code
+func adminDRequest(method string, path string, body interface{}) []byte {
+	loginInfo := mustGetLoginInfo()
+
+	client := resty.New()
+	url := config.MustGetString(configkey.AdminDURL) + path
+
+	req := client.R().SetHeader("Authorization", "Bearer "+loginInfo.Token.AccessToken)
+	if body != nil {
+		bytes, _ := json.Marshal(body)
+		req = req.SetBody(bytes)
+	}
+
+	resp, err := req.Execute(method, url)
+	if err != nil {
+		panic(err)
+	}
+
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusCreated && resp.StatusCode() != http.StatusNoContent {
+		if resp.Error() != nil {
+			panic(resp.Error())
+		}
+		panic("there was a problem: " + strconv.Itoa(resp.StatusCode()) + " " + string(resp.Body()))
+	}
+
+	return resp.Body()
+}
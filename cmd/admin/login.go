@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
 	"os/exec"
 	"runtime"
 	"strconv"
@@ -16,13 +17,17 @@ import (
 	"github.com/freetocompute/kebe/config"
 	"github.com/freetocompute/kebe/config/configkey"
 	"github.com/freetocompute/kebe/pkg/admind"
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
 	"github.com/freetocompute/kebe/pkg/middleware"
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
+	resty "github.com/go-resty/resty/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
+	"golang.org/x/term"
 )
 
 type Server struct {
@@ -33,10 +38,21 @@ type Server struct {
 	port         int32
 }
 
+var loginEmail string
+
+func init() {
+	login.Flags().StringVarP(&loginEmail, "email", "e", "", "The email of the admin account, when admind.auth.backend is local")
+}
+
 var login = &cobra.Command{
 	Use:   "login",
 	Short: "login",
 	Run: func(cmd *cobra.Command, args []string) {
+		if viper.GetString(configkey.AdminDAuthBackend) == admind.AuthBackendLocal {
+			localLogin()
+			return
+		}
+
 		s := &Server{
 			done: make(chan struct{}),
 		}
@@ -161,6 +177,63 @@ func (s *Server) account(c *gin.Context) {
 	}
 }
 
+// localLogin logs in to admind with the local credential of an admin account, for admind.auth.backend local
+func localLogin() {
+	if loginEmail == "" {
+		fmt.Println("admind uses local credentials, log in with --email")
+		return
+	}
+
+	fmt.Print("Password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		panic(err)
+	}
+
+	loginReq := requests.AdminLogin{
+		Email:    loginEmail,
+		Password: string(password),
+	}
+
+	resp, err := resty.New().R().SetBody(&loginReq).Post(config.MustGetString(configkey.AdminDURL) + "/v1/admin/login")
+	if err != nil {
+		panic(err)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		fmt.Printf("Unable to log in: %d %s\n", resp.StatusCode(), string(resp.Body()))
+		return
+	}
+
+	var loginResp responses.AdminLogin
+	err = json.Unmarshal(resp.Body(), &loginResp)
+	if err != nil {
+		panic(err)
+	}
+
+	loginInfo := admind.LoginInfo{
+		UserInfo: admind.UserInfo{
+			Sub:               loginResp.Subject,
+			PreferredUsername: loginResp.Username,
+			Email:             loginResp.Email,
+		},
+		Token: oauth2.Token{
+			AccessToken: loginResp.Token,
+			TokenType:   "Bearer",
+			Expiry:      loginResp.ExpiresAt,
+		},
+	}
+
+	loginInfoBytes, _ := json.Marshal(&loginInfo)
+	err = ioutil.WriteFile(LoginConfigFilename, loginInfoBytes, 0600)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Logged in as %s until %s\n", loginResp.Username, loginResp.ExpiresAt.Format(time.RFC3339))
+}
+
 // Source: https://gist.github.com/hyg/9c4afcd91fe24316cbf0
 func openbrowser(url string) {
 	var err error
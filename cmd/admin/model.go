@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var definitionPath string
+var outputPath string
+var keyId string
+
+func init() {
+	model.AddCommand(createModel)
+	createModel.Flags().StringVarP(&definitionPath, "definition", "f", "", "A json file with the model headers, in the same form snap sign takes")
+	_ = createModel.MarkFlagRequired("definition")
+
+	model.AddCommand(signModel)
+	signModel.Flags().StringVarP(&brandId, "brand-id", "b", "", "The brand of the model")
+	signModel.Flags().StringVarP(&modelName, "model", "m", "", "The name of the model")
+	signModel.Flags().StringVarP(&keyId, "key", "k", "", "The sha3-384 of the brand key to sign with")
+	signModel.Flags().StringVarP(&outputPath, "output", "o", "", "Write the model assertion to this file instead of stdout")
+	_ = signModel.MarkFlagRequired("brand-id")
+	_ = signModel.MarkFlagRequired("model")
+
+	model.AddCommand(listModels)
+	listModels.Flags().StringVarP(&brandId, "brand-id", "b", "", "Only list models for this brand")
+}
+
+var model = &cobra.Command{
+	Use:   "model",
+	Short: "model",
+}
+
+var createModel = &cobra.Command{
+	Use:   "create",
+	Short: "create",
+	Run: func(cmd *cobra.Command, args []string) {
+		definitionBytes, err := ioutil.ReadFile(definitionPath)
+		if err != nil {
+			panic(err)
+		}
+
+		var definition models.ModelDefinition
+		err = json.Unmarshal(definitionBytes, &definition)
+		if err != nil {
+			panic(err)
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/model", &definition)
+	},
+}
+
+var signModel = &cobra.Command{
+	Use:   "sign",
+	Short: "sign",
+	Run: func(cmd *cobra.Command, args []string) {
+		signModelReq := requests.SignModel{
+			BrandId: brandId,
+			Model:   modelName,
+			KeyId:   keyId,
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/model/sign", &signModelReq)
+		if outputPath != "" {
+			err := ioutil.WriteFile(outputPath, bytes, 0644)
+			if err != nil {
+				panic(err)
+			}
+			return
+		}
+
+		fmt.Print(string(bytes))
+	},
+}
+
+var listModels = &cobra.Command{
+	Use:   "list",
+	Short: "list",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		if brandId != "" {
+			query.Set("brand", brandId)
+		}
+
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/models?"+query.Encode(), nil)
+
+		var modelResponses []responses.Model
+		err := json.Unmarshal(bytes, &modelResponses)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Brand", "Model", "Grade", "Base", "Revision"})
+		for _, m := range modelResponses {
+			revision := "unsigned"
+			if m.Revision > 0 {
+				revision = strconv.Itoa(m.Revision)
+			}
+			table.Append([]string{m.BrandId, m.Model, m.Definition.Grade, m.Definition.Base, revision})
+		}
+		table.Render()
+	},
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var organizationName string
+var organizationAccountId string
+var organizationDisplayName string
+var organizationEmail string
+var memberAccountId string
+var memberRole string
+var transferSnapName string
+
+func init() {
+	organization.AddCommand(addOrganization)
+	addOrganization.Flags().StringVarP(&organizationName, "name", "n", "", "The name of the organization")
+	addOrganization.Flags().StringVarP(&organizationAccountId, "account-id", "a", "", "The account id the organization publishes as")
+	addOrganization.Flags().StringVarP(&organizationDisplayName, "display-name", "d", "", "The display name of the organization, its name if not given")
+	addOrganization.Flags().StringVarP(&organizationEmail, "email", "e", "", "A contact email for the organization")
+	_ = addOrganization.MarkFlagRequired("name")
+	_ = addOrganization.MarkFlagRequired("account-id")
+
+	organization.AddCommand(listOrganizations)
+
+	organization.AddCommand(setOrganizationMember)
+	setOrganizationMember.Flags().StringVarP(&organizationName, "name", "n", "", "The name of the organization")
+	setOrganizationMember.Flags().StringVarP(&memberAccountId, "account-id", "a", "", "The account id of the member")
+	setOrganizationMember.Flags().StringVarP(&memberRole, "role", "r", "", "The role of the member: viewer, developer, releaser or admin")
+	_ = setOrganizationMember.MarkFlagRequired("name")
+	_ = setOrganizationMember.MarkFlagRequired("account-id")
+	_ = setOrganizationMember.MarkFlagRequired("role")
+
+	organization.AddCommand(removeOrganizationMember)
+	removeOrganizationMember.Flags().StringVarP(&organizationName, "name", "n", "", "The name of the organization")
+	removeOrganizationMember.Flags().StringVarP(&memberAccountId, "account-id", "a", "", "The account id of the member")
+	_ = removeOrganizationMember.MarkFlagRequired("name")
+	_ = removeOrganizationMember.MarkFlagRequired("account-id")
+
+	organization.AddCommand(transferSnap)
+	transferSnap.Flags().StringVarP(&transferSnapName, "snap", "s", "", "The name of the snap")
+	transferSnap.Flags().StringVarP(&organizationName, "name", "n", "", "The name of the organization to transfer the snap to")
+	_ = transferSnap.MarkFlagRequired("snap")
+	_ = transferSnap.MarkFlagRequired("name")
+}
+
+var organization = &cobra.Command{
+	Use:   "organization",
+	Short: "Manages the organizations that own snaps and their members",
+}
+
+var addOrganization = &cobra.Command{
+	Use:   "add",
+	Short: "add",
+	Run: func(cmd *cobra.Command, args []string) {
+		addOrganizationReq := requests.AddOrganization{
+			Name:        organizationName,
+			AccountId:   organizationAccountId,
+			DisplayName: organizationDisplayName,
+			Email:       organizationEmail,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/organization", &addOrganizationReq)
+	},
+}
+
+var listOrganizations = &cobra.Command{
+	Use:   "list",
+	Short: "list",
+	Run: func(cmd *cobra.Command, args []string) {
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/organizations", nil)
+
+		var organizations []responses.Organization
+		err := json.Unmarshal(bytes, &organizations)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Organization", "Account", "Member", "Email", "Role"})
+		for _, o := range organizations {
+			if len(o.Members) == 0 {
+				table.Append([]string{o.Name, o.AccountId, "", "", ""})
+			}
+
+			for _, m := range o.Members {
+				table.Append([]string{o.Name, o.AccountId, m.AccountId, m.Email, m.Role})
+			}
+		}
+		table.Render()
+	},
+}
+
+var setOrganizationMember = &cobra.Command{
+	Use:   "set-member",
+	Short: "Adds an account to an organization or changes its role",
+	Run: func(cmd *cobra.Command, args []string) {
+		setMemberReq := requests.SetOrganizationMember{
+			Organization: organizationName,
+			AccountId:    memberAccountId,
+			Role:         memberRole,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/organization/member", &setMemberReq)
+	},
+}
+
+var removeOrganizationMember = &cobra.Command{
+	Use:   "remove-member",
+	Short: "remove-member",
+	Run: func(cmd *cobra.Command, args []string) {
+		removeMemberReq := requests.RemoveOrganizationMember{
+			Organization: organizationName,
+			AccountId:    memberAccountId,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/organization/member/remove", &removeMemberReq)
+	},
+}
+
+var transferSnap = &cobra.Command{
+	Use:   "transfer-snap",
+	Short: "Makes an organization the publisher of a snap",
+	Run: func(cmd *cobra.Command, args []string) {
+		transferSnapReq := requests.TransferSnap{
+			SnapName:     transferSnapName,
+			Organization: organizationName,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/organization/snap/transfer", &transferSnapReq)
+	},
+}
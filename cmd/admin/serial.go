@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var brandId string
+var modelName string
+var serialNumber string
+
+func init() {
+	serial.AddCommand(listSerials)
+	listSerials.Flags().StringVarP(&brandId, "brand-id", "b", "", "Only list serials for this brand")
+	listSerials.Flags().StringVarP(&modelName, "model", "m", "", "Only list serials for this model")
+
+	serial.AddCommand(revokeSerial)
+	revokeSerial.Flags().StringVarP(&brandId, "brand-id", "b", "", "The brand of the device")
+	revokeSerial.Flags().StringVarP(&modelName, "model", "m", "", "The model of the device")
+	revokeSerial.Flags().StringVarP(&serialNumber, "serial", "s", "", "The serial to revoke")
+	_ = revokeSerial.MarkFlagRequired("brand-id")
+	_ = revokeSerial.MarkFlagRequired("model")
+	_ = revokeSerial.MarkFlagRequired("serial")
+}
+
+var serial = &cobra.Command{
+	Use:   "serial",
+	Short: "serial",
+}
+
+var listSerials = &cobra.Command{
+	Use:   "list",
+	Short: "list",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		if brandId != "" {
+			query.Set("brand", brandId)
+		}
+		if modelName != "" {
+			query.Set("model", modelName)
+		}
+
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/serials?"+query.Encode(), nil)
+
+		var serials []responses.Serial
+		err := json.Unmarshal(bytes, &serials)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Brand", "Model", "Serial", "Issued", "Revoked"})
+		for _, s := range serials {
+			revoked := ""
+			if s.RevokedAt != nil {
+				revoked = s.RevokedAt.Format(time.RFC3339)
+			}
+			table.Append([]string{s.BrandId, s.Model, s.Serial, s.IssuedAt.Format(time.RFC3339), revoked})
+		}
+		table.Render()
+	},
+}
+
+var revokeSerial = &cobra.Command{
+	Use:   "revoke",
+	Short: "revoke",
+	Run: func(cmd *cobra.Command, args []string) {
+		revokeSerialReq := requests.RevokeSerial{
+			BrandId: brandId,
+			Model:   modelName,
+			Serial:  serialNumber,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/serial/revoke", &revokeSerialReq)
+	},
+}
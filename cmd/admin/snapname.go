@@ -0,0 +1,183 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var snapNameAccountId string
+var snapNameReason string
+var disputeStatus string
+var disputeId uint
+var acceptDispute bool
+var rejectDispute bool
+
+func init() {
+	snapNames.AddCommand(reserveSnapName)
+	reserveSnapName.Flags().StringVarP(&snapName, "snap-name", "s", "", "The name to reserve")
+	reserveSnapName.Flags().StringVarP(&snapNameAccountId, "account-id", "a", "", "The account that can still register the name, nobody can if not given")
+	reserveSnapName.Flags().StringVarP(&snapNameReason, "reason", "r", "", "Why the name is reserved")
+	_ = reserveSnapName.MarkFlagRequired("snap-name")
+
+	snapNames.AddCommand(unreserveSnapName)
+	unreserveSnapName.Flags().StringVarP(&snapName, "snap-name", "s", "", "The name to unreserve")
+	_ = unreserveSnapName.MarkFlagRequired("snap-name")
+
+	snapNames.AddCommand(listReservedSnapNames)
+
+	snapNames.AddCommand(listSnapNameDisputes)
+	listSnapNameDisputes.Flags().StringVar(&disputeStatus, "status", "open", "Only list the disputes with the status: open, accepted or rejected, all of them if empty")
+
+	snapNames.AddCommand(resolveSnapNameDispute)
+	resolveSnapNameDispute.Flags().UintVarP(&disputeId, "dispute-id", "i", 0, "The id of the dispute, as disputes shows it")
+	resolveSnapNameDispute.Flags().BoolVar(&acceptDispute, "accept", false, "Give the name to the account that disputed it")
+	resolveSnapNameDispute.Flags().BoolVar(&rejectDispute, "reject", false, "Leave the name as it is")
+	_ = resolveSnapNameDispute.MarkFlagRequired("dispute-id")
+
+	snapNames.AddCommand(transferSnapToAccount)
+	transferSnapToAccount.Flags().StringVarP(&snapName, "snap-name", "s", "", "The name of the snap to transfer")
+	transferSnapToAccount.Flags().StringVarP(&snapNameAccountId, "account-id", "a", "", "The account to transfer the snap to")
+	_ = transferSnapToAccount.MarkFlagRequired("snap-name")
+	_ = transferSnapToAccount.MarkFlagRequired("account-id")
+
+	snapNames.AddCommand(unregisterSnap)
+	unregisterSnap.Flags().StringVarP(&snapName, "snap-name", "s", "", "The name of the snap to unregister")
+	_ = unregisterSnap.MarkFlagRequired("snap-name")
+}
+
+var snapNames = &cobra.Command{
+	Use:   "snap-name",
+	Short: "Manages reserved snap names, name disputes and who snaps are registered to",
+}
+
+var reserveSnapName = &cobra.Command{
+	Use:   "reserve",
+	Short: "reserve",
+	Run: func(cmd *cobra.Command, args []string) {
+		reserveReq := requests.ReserveSnapName{
+			Name:      snapName,
+			AccountId: snapNameAccountId,
+			Reason:    snapNameReason,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/snap-name/reserve", &reserveReq)
+	},
+}
+
+var unreserveSnapName = &cobra.Command{
+	Use:   "unreserve",
+	Short: "unreserve",
+	Run: func(cmd *cobra.Command, args []string) {
+		unreserveReq := requests.UnreserveSnapName{
+			Name: snapName,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/snap-name/unreserve", &unreserveReq)
+	},
+}
+
+var listReservedSnapNames = &cobra.Command{
+	Use:   "reserved",
+	Short: "Lists the reserved names",
+	Run: func(cmd *cobra.Command, args []string) {
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/snap-names/reserved", nil)
+
+		var reservedNames []responses.ReservedSnapName
+		err := json.Unmarshal(bytes, &reservedNames)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Name", "Reserved For", "Reason", "Reserved"})
+		for _, r := range reservedNames {
+			table.Append([]string{r.Name, r.AccountId, r.Reason, r.CreatedAt.Format(time.RFC3339)})
+		}
+		table.Render()
+	},
+}
+
+var listSnapNameDisputes = &cobra.Command{
+	Use:   "disputes",
+	Short: "Lists the name disputes publishers filed",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		query.Set("status", disputeStatus)
+
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/snap-name/disputes?"+query.Encode(), nil)
+
+		var disputes []responses.SnapNameDispute
+		err := json.Unmarshal(bytes, &disputes)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Id", "Name", "Account Id", "Status", "Filed", "Comment"})
+		for _, d := range disputes {
+			table.Append([]string{strconv.FormatUint(uint64(d.DisputeId), 10), d.Name, d.AccountId, d.Status, d.CreatedAt.Format(time.RFC3339), d.Comment})
+		}
+		table.Render()
+	},
+}
+
+var resolveSnapNameDispute = &cobra.Command{
+	Use:   "resolve-dispute",
+	Short: "Accepts or rejects a name dispute",
+	Run: func(cmd *cobra.Command, args []string) {
+		if acceptDispute == rejectDispute {
+			fmt.Println("Either --accept or --reject is required")
+			os.Exit(1)
+		}
+
+		resolveReq := requests.ResolveSnapNameDispute{
+			DisputeId: disputeId,
+			Accept:    acceptDispute,
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/snap-name/dispute/resolve", &resolveReq)
+
+		var dispute responses.SnapNameDispute
+		err := json.Unmarshal(bytes, &dispute)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("The dispute of %s for %s was %s\n", dispute.AccountId, dispute.Name, dispute.Status)
+	},
+}
+
+var transferSnapToAccount = &cobra.Command{
+	Use:   "transfer",
+	Short: "Makes an account the publisher of a snap",
+	Run: func(cmd *cobra.Command, args []string) {
+		transferReq := requests.TransferSnapToAccount{
+			SnapName:  snapName,
+			AccountId: snapNameAccountId,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/snap/transfer", &transferReq)
+	},
+}
+
+var unregisterSnap = &cobra.Command{
+	Use:   "unregister",
+	Short: "Frees the name of a snap that never had a revision uploaded",
+	Run: func(cmd *cobra.Command, args []string) {
+		unregisterReq := requests.UnregisterSnap{
+			SnapName: snapName,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/snap/unregister", &unregisterReq)
+	},
+}
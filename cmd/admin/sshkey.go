@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var sshKeyAccountId string
+var sshPublicKey string
+var sshPublicKeyPath string
+var sshKeyFingerprint string
+
+func init() {
+	sshKey.AddCommand(listSSHKeys)
+	listSSHKeys.Flags().StringVarP(&sshKeyAccountId, "account-id", "a", "", "The account to list the keys of")
+	_ = listSSHKeys.MarkFlagRequired("account-id")
+
+	sshKey.AddCommand(addSSHKey)
+	addSSHKey.Flags().StringVarP(&sshKeyAccountId, "account-id", "a", "", "The account to add the key to")
+	addSSHKey.Flags().StringVarP(&sshPublicKey, "key", "k", "", "The public key in the authorized_keys format")
+	addSSHKey.Flags().StringVarP(&sshPublicKeyPath, "key-file", "f", "", "A file with the public key, e.g. ~/.ssh/id_ed25519.pub")
+	_ = addSSHKey.MarkFlagRequired("account-id")
+
+	sshKey.AddCommand(removeSSHKey)
+	removeSSHKey.Flags().StringVarP(&sshKeyAccountId, "account-id", "a", "", "The account to remove the key from")
+	removeSSHKey.Flags().StringVarP(&sshKeyFingerprint, "fingerprint", "p", "", "The SHA256 fingerprint of the key, as ssh-key list shows it")
+	_ = removeSSHKey.MarkFlagRequired("account-id")
+	_ = removeSSHKey.MarkFlagRequired("fingerprint")
+}
+
+var sshKey = &cobra.Command{
+	Use:   "ssh-key",
+	Short: "Manages the ssh keys installed for accounts by snap create-user and console-conf",
+}
+
+var listSSHKeys = &cobra.Command{
+	Use:   "list",
+	Short: "list",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		query.Set("account", sshKeyAccountId)
+
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/account/ssh-keys?"+query.Encode(), nil)
+
+		var sshKeys []responses.SSHKey
+		err := json.Unmarshal(bytes, &sshKeys)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Fingerprint", "Key", "Added"})
+		for _, k := range sshKeys {
+			table.Append([]string{k.Fingerprint, k.PublicKey, k.CreatedAt.Format(time.RFC3339)})
+		}
+		table.Render()
+	},
+}
+
+var addSSHKey = &cobra.Command{
+	Use:   "add",
+	Short: "add",
+	Run: func(cmd *cobra.Command, args []string) {
+		publicKey := sshPublicKey
+		if sshPublicKeyPath != "" {
+			keyBytes, err := ioutil.ReadFile(sshPublicKeyPath)
+			if err != nil {
+				panic(err)
+			}
+
+			publicKey = string(keyBytes)
+		}
+
+		if publicKey == "" {
+			fmt.Println("Either --key or --key-file is required")
+			os.Exit(1)
+		}
+
+		addSSHKeyReq := requests.AddSSHKey{
+			AccountId: sshKeyAccountId,
+			PublicKey: publicKey,
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/account/ssh-key", &addSSHKeyReq)
+
+		var added responses.SSHKey
+		err := json.Unmarshal(bytes, &added)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Added %s\n", added.Fingerprint)
+	},
+}
+
+var removeSSHKey = &cobra.Command{
+	Use:   "remove",
+	Short: "remove",
+	Run: func(cmd *cobra.Command, args []string) {
+		removeSSHKeyReq := requests.RemoveSSHKey{
+			AccountId:   sshKeyAccountId,
+			Fingerprint: sshKeyFingerprint,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/account/ssh-key/remove", &removeSSHKeyReq)
+	},
+}
@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var tokenAccountId string
+var tokenId string
+
+func init() {
+	token.AddCommand(listTokens)
+	listTokens.Flags().StringVarP(&tokenAccountId, "account-id", "a", "", "Only list tokens issued to this account")
+
+	token.AddCommand(revokeToken)
+	revokeToken.Flags().StringVarP(&tokenId, "token-id", "t", "", "The token to revoke")
+	_ = revokeToken.MarkFlagRequired("token-id")
+
+	token.AddCommand(revokeAccountTokens)
+	revokeAccountTokens.Flags().StringVarP(&tokenAccountId, "account-id", "a", "", "The account to revoke every token of")
+	_ = revokeAccountTokens.MarkFlagRequired("account-id")
+}
+
+var token = &cobra.Command{
+	Use:   "token",
+	Short: "Manages the discharge macaroons issued by the login service",
+}
+
+var listTokens = &cobra.Command{
+	Use:   "list",
+	Short: "list",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		if tokenAccountId != "" {
+			query.Set("account", tokenAccountId)
+		}
+
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/tokens?"+query.Encode(), nil)
+
+		var tokens []responses.Token
+		err := json.Unmarshal(bytes, &tokens)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Token", "Account", "Email", "Issued", "Expires", "Revoked"})
+		for _, t := range tokens {
+			revoked := ""
+			if t.RevokedAt != nil {
+				revoked = t.RevokedAt.Format(time.RFC3339)
+			}
+			table.Append([]string{t.TokenId, t.AccountId, t.Email, t.IssuedAt.Format(time.RFC3339), t.ExpiresAt.Format(time.RFC3339), revoked})
+		}
+		table.Render()
+	},
+}
+
+var revokeToken = &cobra.Command{
+	Use:   "revoke",
+	Short: "revoke",
+	Run: func(cmd *cobra.Command, args []string) {
+		revokeTokenReq := requests.RevokeToken{
+			TokenId: tokenId,
+		}
+
+		adminDRequest(http.MethodPost, "/v1/admin/token/revoke", &revokeTokenReq)
+	},
+}
+
+var revokeAccountTokens = &cobra.Command{
+	Use:   "revoke-all",
+	Short: "Revokes every token issued to an account",
+	Run: func(cmd *cobra.Command, args []string) {
+		revokeAccountTokensReq := requests.RevokeAccountTokens{
+			AccountId: tokenAccountId,
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/account/tokens/revoke", &revokeAccountTokensReq)
+
+		var revokedTokens responses.RevokedTokens
+		err := json.Unmarshal(bytes, &revokedTokens)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Revoked %d token(s) of %s\n", revokedTokens.Revoked, revokedTokens.AccountId)
+	},
+}
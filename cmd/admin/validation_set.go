@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var validationSetName string
+var sequence int
+
+func init() {
+	validationSet.AddCommand(createValidationSet)
+	createValidationSet.Flags().StringVarP(&definitionPath, "definition", "f", "", "A json file with the account-id, name and snaps of the validation set")
+	_ = createValidationSet.MarkFlagRequired("definition")
+
+	validationSet.AddCommand(signValidationSet)
+	signValidationSet.Flags().StringVarP(&accountId, "account-id", "a", "", "The account the validation set belongs to")
+	signValidationSet.Flags().StringVarP(&validationSetName, "name", "n", "", "The name of the validation set")
+	signValidationSet.Flags().IntVarP(&sequence, "sequence", "s", 0, "The sequence to sign, defaults to the latest")
+	signValidationSet.Flags().StringVarP(&keyId, "key", "k", "", "The sha3-384 of the account key to sign with")
+	signValidationSet.Flags().StringVarP(&outputPath, "output", "o", "", "Write the validation-set assertion to this file instead of stdout")
+	_ = signValidationSet.MarkFlagRequired("account-id")
+	_ = signValidationSet.MarkFlagRequired("name")
+
+	validationSet.AddCommand(listValidationSets)
+	listValidationSets.Flags().StringVarP(&accountId, "account-id", "a", "", "Only list validation sets of this account")
+	listValidationSets.Flags().StringVarP(&validationSetName, "name", "n", "", "Only list validation sets with this name")
+}
+
+var validationSet = &cobra.Command{
+	Use:   "validation-set",
+	Short: "validation-set",
+}
+
+var createValidationSet = &cobra.Command{
+	Use:   "create",
+	Short: "create",
+	Run: func(cmd *cobra.Command, args []string) {
+		definitionBytes, err := ioutil.ReadFile(definitionPath)
+		if err != nil {
+			panic(err)
+		}
+
+		var definition models.ValidationSetDefinition
+		err = json.Unmarshal(definitionBytes, &definition)
+		if err != nil {
+			panic(err)
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/validation-set", &definition)
+
+		var created responses.ValidationSet
+		err = json.Unmarshal(bytes, &created)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Created %s/%s sequence %d\n", created.AccountId, created.Name, created.Sequence)
+	},
+}
+
+var signValidationSet = &cobra.Command{
+	Use:   "sign",
+	Short: "sign",
+	Run: func(cmd *cobra.Command, args []string) {
+		signReq := requests.SignValidationSet{
+			AccountId: accountId,
+			Name:      validationSetName,
+			Sequence:  sequence,
+			KeyId:     keyId,
+		}
+
+		bytes := adminDRequest(http.MethodPost, "/v1/admin/validation-set/sign", &signReq)
+		if outputPath != "" {
+			err := ioutil.WriteFile(outputPath, bytes, 0644)
+			if err != nil {
+				panic(err)
+			}
+			return
+		}
+
+		fmt.Print(string(bytes))
+	},
+}
+
+var listValidationSets = &cobra.Command{
+	Use:   "list",
+	Short: "list",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		if accountId != "" {
+			query.Set("account", accountId)
+		}
+		if validationSetName != "" {
+			query.Set("name", validationSetName)
+		}
+
+		bytes := adminDRequest(http.MethodGet, "/v1/admin/validation-sets?"+query.Encode(), nil)
+
+		var validationSets []responses.ValidationSet
+		err := json.Unmarshal(bytes, &validationSets)
+		if err != nil {
+			panic(err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Account", "Name", "Sequence", "Snaps", "Revision"})
+		for _, vs := range validationSets {
+			revision := "unsigned"
+			if vs.Revision > 0 {
+				revision = strconv.Itoa(vs.Revision)
+			}
+			table.Append([]string{vs.AccountId, vs.Name, strconv.Itoa(vs.Sequence), strconv.Itoa(len(vs.Definition.Snaps)), revision})
+		}
+		table.Render()
+	},
+}
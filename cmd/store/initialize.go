@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/freetocompute/kebe/config/configkey"
 	"github.com/freetocompute/kebe/pkg/crypto"
@@ -21,6 +22,9 @@ import (
 	"github.com/spf13/viper"
 )
 
+// trustedKeySince is the since of the root and generic account keys
+const trustedKeySince = "2015-11-20T15:04:00Z"
+
 var Destroy = cobra.Command{
 	Use:   "destroy",
 	Short: "Destroys the store",
@@ -109,53 +113,64 @@ var Initialize = cobra.Command{
 
 		fmt.Printf("%+v\n", initConfig)
 
-		makeBucketAndAddKey(minioClient, "root", initConfig.RootKeyPath, "private-key.pem")
-		makeBucketAndAddKey(minioClient, "generic", initConfig.GenericKeyPath, "private-key.pem")
+		makeBucket(minioClient, "root")
+		makeBucket(minioClient, "generic")
+
+		// the private keys go in the signing backend, only assertions are kept in the buckets
+		signingBackend, err := crypto.NewBackendFromConfig()
+		if err != nil {
+			panic(err)
+		}
 
-		// TODO: this is a redundant load
-		rootKey := crypto.GetPrivateKeyFromPEMFile(initConfig.RootKeyPath)
+		rootKey := importKeyFromPEMFile(signingBackend, "root", initConfig.RootKeyPath)
 
 		// create a signing database with the store's root key
-		signingDB := assertstest.NewSigningDB(initConfig.AuthorityId, rootKey)
+		signingDB := crypto.NewSigningDB(signingBackend, initConfig.AuthorityId, rootKey.ID())
 		db, _ := database.CreateDatabase()
 
 		// generate trusted account and account key
-		createTrustedAccountExt(minioClient, rootKey, rootKey.PublicKey().ID(), signingDB, initConfig.RootAccountInit.Id, initConfig.RootAccountInit.Username, "root", "default")
+		createTrustedAccountExt(minioClient, rootKey, rootKey.ID(), signingDB, initConfig.RootAccountInit.Id, initConfig.RootAccountInit.Username, "root", "default")
 		rootAccount := models.Account{
 			AccountId:   initConfig.RootAccountInit.Id,
 			DisplayName: initConfig.RootAccountInit.DisplayName,
 			Username:    initConfig.RootAccountInit.Username,
 			Email:       initConfig.RootAccountInit.Email,
+			Validation:  models.AccountValidationVerified,
 		}
 		db.Save(&rootAccount)
+
+		// matches the since of the trusted account-key assertions
+		keysSince, _ := time.Parse(time.RFC3339, trustedKeySince)
 		rootAccountKey := models.Key{
 			Name: "default",
 			//TODO: get actual sha3384, is it needed?
-			SHA3384:          rootKey.PublicKey().ID(),
-			EncodedPublicKey: rootKey.PublicKey().ID(),
+			SHA3384:          rootKey.ID(),
+			EncodedPublicKey: rootKey.ID(),
 			AccountID:        rootAccount.ID,
+			Since:            keysSince,
 		}
 		db.Save(&rootAccountKey)
 
 		//
 		// generate generic account, account-key and mode
-		// TODO: this is a redundant load
-		genericKey := crypto.GetPrivateKeyFromPEMFile(initConfig.GenericKeyPath)
+		genericKey := importKeyFromPEMFile(signingBackend, "generic", initConfig.GenericKeyPath)
 
-		createTrustedAccountExt(minioClient, genericKey, rootKey.PublicKey().ID(), signingDB, initConfig.GenericAccountInit.Id, initConfig.GenericAccountInit.Username, "generic", "default")
+		createTrustedAccountExt(minioClient, genericKey, rootKey.ID(), signingDB, initConfig.GenericAccountInit.Id, initConfig.GenericAccountInit.Username, "generic", "default")
 		genericAccount := models.Account{
 			AccountId:   initConfig.GenericAccountInit.Id,
 			DisplayName: initConfig.GenericAccountInit.DisplayName,
 			Username:    initConfig.GenericAccountInit.Username,
 			Email:       initConfig.GenericAccountInit.Email,
+			Validation:  models.AccountValidationVerified,
 		}
 		db.Save(&genericAccount)
 		genericAccountKey := models.Key{
 			Name: "default",
 			//TODO: get actual sha3384, is it needed?
-			SHA3384:          genericKey.PublicKey().ID(),
-			EncodedPublicKey: genericKey.PublicKey().ID(),
+			SHA3384:          genericKey.ID(),
+			EncodedPublicKey: genericKey.ID(),
 			AccountID:        genericAccount.ID,
+			Since:            keysSince,
 		}
 		db.Save(&genericAccountKey)
 
@@ -165,32 +180,34 @@ var Initialize = cobra.Command{
 	},
 }
 
-func makeBucketAndAddKey(minioClient *minio.Client, bucketName string, keyPath string, keyName string) {
-	// Make root bucket
+func makeBucket(minioClient *minio.Client, bucketName string) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	defer cancel()
 
-	objectCh := minioClient.ListObjects(ctx, bucketName, minio.ListObjectsOptions{
-		Recursive: true,
-	})
-	for object := range objectCh {
-		logrus.Tracef("object: %s", object.Key)
-	}
-
 	err := minioClient.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{})
 	if err != nil {
 		logrus.Error(err)
 	}
+}
+
+func importKeyFromPEMFile(backend crypto.Backend, name string, keyPath string) asserts.PublicKey {
+	bytes, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		panic(err)
+	}
 
-	bytes, _ := ioutil.ReadFile(keyPath)
-	rootPrivateKey, _ := crypto.ParseRSAPrivateKeyFromPEM(bytes)
-	keyString := crypto.ExportRsaPrivateKeyAsPemStr(rootPrivateKey)
+	privateKey, err := crypto.ParseRSAPrivateKeyFromPEM(bytes)
+	if err != nil {
+		panic(err)
+	}
 
-	_, err = minioClient.PutObject(ctx, bucketName, keyName, strings.NewReader(keyString), int64(len(keyString)), minio.PutObjectOptions{})
+	publicKey, err := backend.ImportKey(name, privateKey)
 	if err != nil {
 		panic(err)
 	}
+
+	return asserts.RSAPublicKey(publicKey)
 }
 
 func getMinioClient() *minio.Client {
@@ -238,32 +255,27 @@ func deleteAllItemsInBucket(minioClient *minio.Client, bucketName string) {
 	}
 }
 
-func createTrustedAccountExt(minioClient *minio.Client, accountKey asserts.PrivateKey, signingKeyId string, signingDB *assertstest.SigningDB,
+func createTrustedAccountExt(minioClient *minio.Client, accountKey asserts.PublicKey, signingKeyId string, signingDB *crypto.SigningDB,
 	accountId string, accountUsername string, bucketName string, accountKeyName string) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	accountAssertion, bytes := createAccountAssertion(signingDB, signingKeyId, accountId, accountUsername)
-	err := signingDB.Add(accountAssertion)
-	if err != nil {
-		panic(err)
-	}
-
-	_, err = minioClient.PutObject(ctx, bucketName, "account.assertion", strings.NewReader(string(bytes)), int64(len(bytes)), minio.PutObjectOptions{})
+	_, err := minioClient.PutObject(ctx, bucketName, "account.assertion", strings.NewReader(string(bytes)), int64(len(bytes)), minio.PutObjectOptions{})
 	if err != nil {
 		logrus.Error(err)
 	}
 
-	_, bytes = createAccountKeyAssertion(signingDB, accountKey.PublicKey(), signingKeyId, accountAssertion, accountKeyName)
+	_, bytes = createAccountKeyAssertion(signingDB, accountKey, signingKeyId, accountAssertion, accountKeyName)
 	_, err = minioClient.PutObject(ctx, bucketName, "account-key.assertion", strings.NewReader(string(bytes)), int64(len(bytes)), minio.PutObjectOptions{})
 	if err != nil {
 		logrus.Error(err)
 	}
 }
 
-func createAccountKeyAssertion(signingDB *assertstest.SigningDB, publicKey asserts.PublicKey, keyId string, trustedAcct *asserts.Account, name string) (*asserts.AccountKey, []byte) {
+func createAccountKeyAssertion(signingDB *crypto.SigningDB, publicKey asserts.PublicKey, keyId string, trustedAcct *asserts.Account, name string) (*asserts.AccountKey, []byte) {
 	trustedAcctKeyHeaders := map[string]interface{}{
-		"since":      "2015-11-20T15:04:00Z",
+		"since":      trustedKeySince,
 		"until":      "2500-11-20T15:04:00Z",
 		"account-id": trustedAcct.AccountID(),
 		"name":       name,
@@ -276,7 +288,7 @@ func createAccountKeyAssertion(signingDB *assertstest.SigningDB, publicKey asser
 	return trustedAccKey, bytes
 }
 
-func createAccountAssertion(signingDB *assertstest.SigningDB, keyId string, accountId string, storeAccountUsername string) (*asserts.Account, []byte) {
+func createAccountAssertion(signingDB *crypto.SigningDB, keyId string, accountId string, storeAccountUsername string) (*asserts.Account, []byte) {
 	trustedAcctHeaders := map[string]interface{}{
 		"validation": "certified",
 		"timestamp":  "2015-11-20T15:04:00Z",
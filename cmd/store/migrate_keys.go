@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/freetocompute/kebe/pkg/crypto"
+	"github.com/minio/minio-go/v7"
+	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/asserts"
+	"github.com/spf13/cobra"
+)
+
+// keyBuckets are where private keys used to be kept, root and generic hold the store keys and brand-keys the
+// keys added for brand accounts
+var keyBuckets = []string{"root", "generic", "brand-keys"}
+
+var MigrateKeys = cobra.Command{
+	Use:   "migrate-keys",
+	Short: "Moves private keys from object storage into the signing backend",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		minioClient := getMinioClient()
+
+		signingBackend, err := crypto.NewBackendFromConfig()
+		if err != nil {
+			panic(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		for _, bucket := range keyBuckets {
+			exists, err2 := minioClient.BucketExists(ctx, bucket)
+			if err2 != nil {
+				panic(err2)
+			}
+
+			if !exists {
+				continue
+			}
+
+			for object := range minioClient.ListObjects(ctx, bucket, minio.ListObjectsOptions{Recursive: true}) {
+				if object.Err != nil {
+					panic(object.Err)
+				}
+
+				if !strings.HasSuffix(object.Key, ".pem") {
+					continue
+				}
+
+				keyId := migrateKey(ctx, minioClient, signingBackend, bucket, object.Key)
+				fmt.Printf("Moved %s/%s to the signing backend: %s\n", bucket, object.Key, keyId)
+			}
+		}
+	},
+}
+
+// migrateKey imports the key into the backend and only removes it from the bucket once the backend can sign
+// with it
+func migrateKey(ctx context.Context, minioClient *minio.Client, backend crypto.Backend, bucket string, objectName string) string {
+	object, err := minioClient.GetObject(ctx, bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		panic(err)
+	}
+
+	bytes, err := ioutil.ReadAll(object)
+	if err != nil {
+		panic(err)
+	}
+
+	privateKey, err := crypto.ParseRSAPrivateKeyFromPEM(bytes)
+	if err != nil {
+		panic(err)
+	}
+
+	keyId := asserts.RSAPublicKey(&privateKey.PublicKey).ID()
+	if _, err = backend.Signer(keyId); err != nil {
+		_, err = backend.ImportKey(bucket+"/"+strings.TrimSuffix(objectName, ".pem"), privateKey)
+		if err != nil {
+			panic(err)
+		}
+
+		if _, err = backend.Signer(keyId); err != nil {
+			panic(err)
+		}
+	} else {
+		logrus.Infof("Key %s is already in the signing backend", keyId)
+	}
+
+	err = minioClient.RemoveObject(ctx, bucket, objectName, minio.RemoveObjectOptions{})
+	if err != nil {
+		panic(err)
+	}
+
+	return keyId
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/crypto"
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/minio/minio-go/v7"
+	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/asserts"
+	"github.com/spf13/cobra"
+)
+
+var rotateKeyAuthorityId string
+var rotateKeyName string
+var rotateKeyPath string
+var rotateKeyRetireAfter time.Duration
+
+// RotateKey adds a new signing key for the store's authority. The store signs new assertions with it straight
+// away, and signs stored ones again as they're requested. The previous key stays valid for the grace period so
+// assertions already handed out can still be checked, its account-key assertion keeps being served with until
+// set. The trusted key is never retired, it signs the account-keys of the other keys.
+var RotateKey = cobra.Command{
+	Use:   "rotate-key",
+	Short: "Adds a new signing key for the store and retires the previous one",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		minioClient := getMinioClient()
+
+		signingBackend, err := crypto.NewBackendFromConfig()
+		if err != nil {
+			panic(err)
+		}
+
+		db, _ := database.CreateDatabase()
+		accounts := repositories.NewAccountRepository(db)
+
+		account, err := accounts.GetAccountById(rotateKeyAuthorityId, false)
+		if err != nil {
+			panic(err)
+		}
+
+		if account == nil {
+			fmt.Printf("Account %s not found, has the store been initialized?\n", rotateKeyAuthorityId)
+			return
+		}
+
+		now := time.Now().UTC()
+		previousKeyId := findActiveKey(accounts, signingBackend, rotateKeyAuthorityId, now)
+
+		var publicKey asserts.PublicKey
+		if rotateKeyPath != "" {
+			publicKey = importKeyFromPEMFile(signingBackend, rotateKeyName, rotateKeyPath)
+		} else {
+			rsaPublicKey, err2 := signingBackend.GenerateKey(rotateKeyName, 4096)
+			if err2 != nil {
+				panic(err2)
+			}
+
+			publicKey = asserts.RSAPublicKey(rsaPublicKey)
+		}
+
+		existing, err := accounts.GetKeyBySHA3384(publicKey.ID())
+		if err != nil {
+			panic(err)
+		}
+
+		if existing != nil {
+			fmt.Printf("Key %s is already registered\n", publicKey.ID())
+			return
+		}
+
+		encodedPublicKey, err := asserts.EncodePublicKey(publicKey)
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = accounts.AddKey(rotateKeyName, publicKey.ID(), base64.StdEncoding.EncodeToString(encodedPublicKey), account.Email, now, nil)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Added key %s, new assertions are signed with it\n", publicKey.ID())
+
+		if previousKeyId == "" {
+			return
+		}
+
+		if isTrustedKey(minioClient, previousKeyId) {
+			fmt.Printf("Key %s is trusted by devices and is kept to sign account-keys\n", previousKeyId)
+			return
+		}
+
+		retired, err := accounts.ExpireKey(previousKeyId, now.Add(rotateKeyRetireAfter))
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Key %s is retired at %s\n", previousKeyId, retired.Until.Format(time.RFC3339))
+	},
+}
+
+func init() {
+	RotateKey.Flags().StringVar(&rotateKeyAuthorityId, "authority-id", "", "The account id of the store's authority")
+	RotateKey.Flags().StringVarP(&rotateKeyName, "name", "n", "", "The name of the new key")
+	RotateKey.Flags().StringVarP(&rotateKeyPath, "key-path", "f", "", "A PEM encoded RSA private key to use rather than generating one")
+	RotateKey.Flags().DurationVar(&rotateKeyRetireAfter, "retire-after", 24*time.Hour, "How long the previous key stays valid for")
+	_ = RotateKey.MarkFlagRequired("authority-id")
+	_ = RotateKey.MarkFlagRequired("name")
+}
+
+// findActiveKey returns the key the store signs with before the rotation, the most recent valid key the signing
+// backend holds
+func findActiveKey(accounts repositories.IAccountRepository, backend crypto.Backend, authorityId string, when time.Time) string {
+	keys, err := accounts.GetSigningKeys(authorityId, when)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, key := range keys {
+		if _, err = backend.Signer(key.SHA3384); err == nil {
+			return key.SHA3384
+		}
+	}
+
+	logrus.Warnf("The signing backend holds no valid key for %s", authorityId)
+	return ""
+}
+
+// isTrustedKey checks if the key is one of the account-keys initialize put in the root or generic buckets, the
+// patched snapd trusts those
+func isTrustedKey(minioClient *minio.Client, keyId string) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, bucket := range []string{"root", "generic"} {
+		object, err := minioClient.GetObject(ctx, bucket, "account-key.assertion", minio.GetObjectOptions{})
+		if err != nil {
+			panic(err)
+		}
+
+		bytes, err := ioutil.ReadAll(object)
+		if err != nil {
+			logrus.Warnf("Unable to read %s/account-key.assertion: %s", bucket, err)
+			continue
+		}
+
+		assertion, err := asserts.Decode(bytes)
+		if err != nil {
+			panic(err)
+		}
+
+		if accountKey, ok := assertion.(*asserts.AccountKey); ok && accountKey.PublicKeyID() == keyId {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/login/identity"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var setAdminAccountId string
+var setAdminRevoke bool
+
+// SetAdmin lets an account log in to admind with its local credential when admind.auth.backend is local. It works
+// on the database directly, so it is how the first admin is added before admind can be used. An account without a
+// local credential is asked for a password.
+var SetAdmin = cobra.Command{
+	Use:   "set-admin",
+	Short: "Lets an account log in to admind with its local credential, or stops it from doing so",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		db, _ := database.CreateDatabase()
+		accounts := repositories.NewAccountRepository(db)
+		credentials := repositories.NewLocalCredentialsRepository(db)
+
+		account, err := accounts.GetAccountById(setAdminAccountId, false)
+		if err != nil {
+			panic(err)
+		}
+
+		if setAdminAccountId == "" || account == nil {
+			fmt.Printf("Account %s not found\n", setAdminAccountId)
+			return
+		}
+
+		if setAdminRevoke {
+			err = accounts.SetAccountAdmin(account, false)
+			if err != nil {
+				panic(err)
+			}
+
+			fmt.Printf("%s is no longer an admin\n", account.AccountId)
+			return
+		}
+
+		credential, err := credentials.GetCredential(account.ID)
+		if err != nil {
+			panic(err)
+		}
+
+		if credential == nil {
+			fmt.Printf("%s has no password yet, set one to log in to admind with\n", account.AccountId)
+			passwordHash, ok := readPasswordHash()
+			if !ok {
+				return
+			}
+
+			err = credentials.SetPasswordHash(account.ID, passwordHash)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		err = accounts.SetAccountAdmin(account, true)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("%s is an admin, log in with kebe-admin login --email %s\n", account.AccountId, account.Email)
+	},
+}
+
+func init() {
+	SetAdmin.Flags().StringVarP(&setAdminAccountId, "account-id", "a", "", "The account id of the admin")
+	SetAdmin.Flags().BoolVar(&setAdminRevoke, "revoke", false, "Stop the account from being an admin")
+	_ = SetAdmin.MarkFlagRequired("account-id")
+}
+
+// readPasswordHash asks for a password twice and returns its hash
+func readPasswordHash() (string, bool) {
+	fmt.Print("Password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Print("Repeat password: ")
+	repeated, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		panic(err)
+	}
+
+	if string(password) != string(repeated) {
+		fmt.Println("The passwords don't match")
+		return "", false
+	}
+
+	passwordHash, err := identity.HashPassword(string(password))
+	if err != nil {
+		fmt.Println(err)
+		return "", false
+	}
+
+	return passwordHash, true
+}
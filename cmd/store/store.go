@@ -63,6 +63,9 @@ func init() {
 	_ = viper.BindPFlag(configkey.StoreInitializationConfigPath, Store.Flags().Lookup("initialization-config-path"))
 
 	Store.AddCommand(&Destroy)
+	Store.AddCommand(&MigrateKeys)
+	Store.AddCommand(&RotateKey)
+	Store.AddCommand(&SetAdmin)
 	// Store.AddCommand(&RegenerateAssertions)
 }
 
@@ -15,23 +15,32 @@ var loadConfigMutex sync.Mutex
 var configLoaded bool
 
 var DefaultValues = map[string]interface{}{
-	configkey.CanonicalSnapStoreURL: "https://api.snapcraft.io",
-	configkey.DebugMode:             true,
-	configkey.LogLevel:              "trace",
-	configkey.RequestLogger:         false,
-	configkey.MinioHost:             "localhost",
-	configkey.MinioSecretKey:        "password",
-	configkey.MinioAccessKey:        "user",
-	configkey.MinioSecure:           false,
-	configkey.DatabaseUsername:      "manager",
-	configkey.DatabaseDatabase:      "store",
-	configkey.DatabaseHost:          "localhost",
-	configkey.DatabasePort:          5432,
-	configkey.DatabaseSSLMode:       "disable",
-	configkey.DatabaseTimezone:      "America/New_York",
-	configkey.DatabasePassword:      "password",
-	configkey.LoginPort:             8890,
-	configkey.DashboardPort:         8891,
+	configkey.CanonicalSnapStoreURL:   "https://api.snapcraft.io",
+	configkey.DebugMode:               true,
+	configkey.LogLevel:                "trace",
+	configkey.RequestLogger:           false,
+	configkey.MinioHost:               "localhost",
+	configkey.MinioSecretKey:          "password",
+	configkey.MinioAccessKey:          "user",
+	configkey.MinioSecure:             false,
+	configkey.DatabaseUsername:        "manager",
+	configkey.DatabaseDatabase:        "store",
+	configkey.DatabaseHost:            "localhost",
+	configkey.DatabasePort:            5432,
+	configkey.DatabaseSSLMode:         "disable",
+	configkey.DatabaseTimezone:        "America/New_York",
+	configkey.DatabasePassword:        "password",
+	configkey.LoginPort:               8890,
+	configkey.DashboardPort:           8891,
+	configkey.SigningBackend:          "keyfile",
+	configkey.SigningKeyfileDirectory: "/opt/kebe-store/keys",
+	configkey.MacaroonStoreId:         "Global",
+	configkey.AdminDGroup:             "kebe-admins",
+	configkey.AdminDAuthBackend:       "oidc",
+	configkey.AdminDSessionExpiry:     "12h",
+	configkey.MacaroonDischargeExpiry: "720h",
+	configkey.LoginTOTPIssuer:         "Kebe",
+	configkey.LoginIdentityBackend:    "oidc",
 }
 
 func LoadConfig() {
@@ -22,10 +22,16 @@ const (
 	StoreURL     = "store.url"
 	LoginURL     = "login.url"
 
-	DashboardPort = "dashboard.port"
-	LoginPort     = "login.port"
-	AdminDPort    = "admind.port"
-	AdminDURL     = "admind.url"
+	DashboardPort            = "dashboard.port"
+	DashboardRegisterNameURL = "dashboard.register-name.url"
+	LoginPort                = "login.port"
+	LoginTOTPIssuer          = "login.totp.issuer"
+	LoginIdentityBackend     = "login.identity.backend"
+	AdminDPort               = "admind.port"
+	AdminDURL                = "admind.url"
+	AdminDGroup              = "admind.admin.group"
+	AdminDAuthBackend        = "admind.auth.backend"
+	AdminDSessionExpiry      = "admind.session.expiry"
 
 	StoreAPIURL                   = "store.api.url"
 	StoreInitializationConfigPath = "store.initialization.config.path"
@@ -36,12 +42,22 @@ const (
 
 	MacaroonDischargeKey       = "macaroon.discharge.key"
 	MacaroonRootKey            = "macaroon.root.key"
+	MacaroonDeviceKey          = "macaroon.device.key"
 	MacaroonRootId             = "macaroon.root.id"
 	MacaroonRootLocation       = "macaroon.root.location"
 	MacaroonThirdPartyCaveatId = "macaroon.thirdparty.caveat.id"
 	MacaroonThirdPartyLocation = "macaroon.thirdparty.location"
+	MacaroonStoreId            = "macaroon.store.id"
+	MacaroonDischargeExpiry    = "macaroon.discharge.expiry"
 
 	RootAuthority = "root.authority"
 
+	SigningBackend           = "signing.backend"
+	SigningKeyfileDirectory  = "signing.keyfile.directory"
+	SigningKeyfilePassphrase = "signing.keyfile.passphrase"
+	SigningPKCS11Module      = "signing.pkcs11.module"
+	SigningPKCS11TokenLabel  = "signing.pkcs11.token.label"
+	SigningPKCS11Pin         = "signing.pkcs11.pin"
+
 	AdminCLILoginPort = "admin.login.port"
 )
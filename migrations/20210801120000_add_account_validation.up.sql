@@ -0,0 +1,2 @@
+alter table accounts
+    add validation text COLLATE pg_catalog."default" DEFAULT 'unproven';
@@ -0,0 +1,3 @@
+drop table if exists snap_media;
+
+drop sequence if exists snap_media_id_seq;
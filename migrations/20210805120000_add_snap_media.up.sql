@@ -0,0 +1,36 @@
+create sequence public.snap_media_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.snap_media
+(
+    id                bigint NOT NULL DEFAULT nextval('snap_media_id_seq'::regclass),
+    created_at        timestamp with time zone,
+    updated_at        timestamp with time zone,
+    deleted_at        timestamp with time zone,
+    type              text COLLATE pg_catalog."default",
+    filename          text COLLATE pg_catalog."default",
+    original_filename text COLLATE pg_catalog."default",
+    sha3_384          text COLLATE pg_catalog."default",
+    content_type      text COLLATE pg_catalog."default",
+    width             bigint,
+    height            bigint,
+    position          bigint,
+    snap_entry_id     bigint,
+    CONSTRAINT snap_media_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_snap_entries_media FOREIGN KEY (snap_entry_id)
+        REFERENCES public.snap_entries (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.snap_media
+    OWNER to manager;
+
+CREATE INDEX idx_snap_media_deleted_at
+    ON public.snap_media USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_snap_media_filename
+    ON public.snap_media USING btree
+        (filename ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,10 @@
+alter table snap_revisions
+    drop column version,
+    drop column title,
+    drop column summary,
+    drop column description,
+    drop column license;
+
+drop table if exists snap_metadata;
+
+drop sequence if exists snap_metadata_id_seq;
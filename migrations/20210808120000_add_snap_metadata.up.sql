@@ -0,0 +1,38 @@
+create sequence public.snap_metadata_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.snap_metadata
+(
+    id            bigint NOT NULL DEFAULT nextval('snap_metadata_id_seq'::regclass),
+    created_at    timestamp with time zone,
+    updated_at    timestamp with time zone,
+    deleted_at    timestamp with time zone,
+    snap_entry_id bigint,
+    title         text COLLATE pg_catalog."default",
+    summary       text COLLATE pg_catalog."default",
+    description   text COLLATE pg_catalog."default",
+    contact       text COLLATE pg_catalog."default",
+    website       text COLLATE pg_catalog."default",
+    license       text COLLATE pg_catalog."default",
+    category      text COLLATE pg_catalog."default",
+    CONSTRAINT snap_metadata_pkey PRIMARY KEY (id),
+    CONSTRAINT snap_metadata_snap_entry_id_key UNIQUE (snap_entry_id),
+    CONSTRAINT fk_snap_entries_metadata FOREIGN KEY (snap_entry_id)
+        REFERENCES public.snap_entries (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.snap_metadata
+    OWNER to manager;
+
+CREATE INDEX idx_snap_metadata_deleted_at
+    ON public.snap_metadata USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+alter table snap_revisions
+    add version text COLLATE pg_catalog."default",
+    add title text COLLATE pg_catalog."default",
+    add summary text COLLATE pg_catalog."default",
+    add description text COLLATE pg_catalog."default",
+    add license text COLLATE pg_catalog."default";
@@ -0,0 +1,3 @@
+drop table if exists assertions;
+
+drop sequence if exists assertions_id_seq;
@@ -0,0 +1,28 @@
+create sequence public.assertions_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.assertions
+(
+    id             bigint NOT NULL DEFAULT nextval('assertions_id_seq'::regclass),
+    created_at     timestamp with time zone,
+    updated_at     timestamp with time zone,
+    deleted_at     timestamp with time zone,
+    type           text COLLATE pg_catalog."default",
+    primary_key    text COLLATE pg_catalog."default",
+    revision       bigint,
+    content_digest text COLLATE pg_catalog."default",
+    encoded        text COLLATE pg_catalog."default",
+    CONSTRAINT assertions_pkey PRIMARY KEY (id)
+) TABLESPACE pg_default;
+
+ALTER TABLE public.assertions
+    OWNER to manager;
+
+CREATE INDEX idx_assertions_deleted_at
+    ON public.assertions USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE UNIQUE INDEX idx_assertions_type_primary_key_revision
+    ON public.assertions USING btree
+        (type ASC NULLS LAST, primary_key ASC NULLS LAST, revision ASC NULLS LAST)
+    TABLESPACE pg_default;
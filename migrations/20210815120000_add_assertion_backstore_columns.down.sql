@@ -0,0 +1,12 @@
+-- the assertions added to the assertion database are the ones the store did not sign
+DELETE FROM public.assertions WHERE content_digest IS NULL OR content_digest = '';
+
+drop index if exists idx_assertions_headers;
+
+drop index if exists idx_assertions_sequence_key;
+
+ALTER TABLE public.assertions
+    DROP COLUMN IF EXISTS headers,
+    DROP COLUMN IF EXISTS sequence,
+    DROP COLUMN IF EXISTS sequence_key,
+    DROP COLUMN IF EXISTS format;
@@ -0,0 +1,17 @@
+-- the assertion database keeps what it is given in the same table the store persists the assertions it signs,
+-- the headers of assertions signed before this are filled in by the store when it starts
+ALTER TABLE public.assertions
+    ADD COLUMN IF NOT EXISTS format       bigint DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS sequence_key text COLLATE pg_catalog."default",
+    ADD COLUMN IF NOT EXISTS sequence     bigint,
+    ADD COLUMN IF NOT EXISTS headers      jsonb;
+
+CREATE INDEX idx_assertions_sequence_key
+    ON public.assertions USING btree
+        (sequence_key ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_assertions_headers
+    ON public.assertions USING gin
+        (headers)
+    TABLESPACE pg_default;
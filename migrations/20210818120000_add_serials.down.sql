@@ -0,0 +1,7 @@
+drop table if exists serials;
+
+drop sequence if exists serials_id_seq;
+
+drop table if exists auth_requests;
+
+drop sequence if exists auth_requests_id_seq;
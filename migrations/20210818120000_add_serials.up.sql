@@ -0,0 +1,56 @@
+create sequence public.auth_requests_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.auth_requests
+(
+    id                  bigint NOT NULL DEFAULT nextval('auth_requests_id_seq'::regclass),
+    created_at          timestamp with time zone,
+    updated_at          timestamp with time zone,
+    deleted_at          timestamp with time zone,
+    used_at             timestamp with time zone,
+    device_key_sha3384  text COLLATE pg_catalog."default",
+    CONSTRAINT auth_requests_pkey PRIMARY KEY (id)
+) TABLESPACE pg_default;
+
+ALTER TABLE public.auth_requests
+    OWNER to manager;
+
+CREATE INDEX idx_auth_requests_deleted_at
+    ON public.auth_requests USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+create sequence public.serials_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.serials
+(
+    id                  bigint NOT NULL DEFAULT nextval('serials_id_seq'::regclass),
+    created_at          timestamp with time zone,
+    updated_at          timestamp with time zone,
+    deleted_at          timestamp with time zone,
+    brand_id            text COLLATE pg_catalog."default",
+    model               text COLLATE pg_catalog."default",
+    serial_uuid         text COLLATE pg_catalog."default",
+    device_key_sha3384  text COLLATE pg_catalog."default",
+    encoded_device_key  text COLLATE pg_catalog."default",
+    encoded             text COLLATE pg_catalog."default",
+    revoked_at          timestamp with time zone,
+    CONSTRAINT serials_pkey PRIMARY KEY (id)
+) TABLESPACE pg_default;
+
+ALTER TABLE public.serials
+    OWNER to manager;
+
+CREATE INDEX idx_serials_deleted_at
+    ON public.serials USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_serials_device_key_sha3384
+    ON public.serials USING btree
+        (device_key_sha3384 ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE UNIQUE INDEX idx_serials_brand_id_model_serial_uuid
+    ON public.serials USING btree
+        (brand_id ASC NULLS LAST, model ASC NULLS LAST, serial_uuid ASC NULLS LAST)
+    TABLESPACE pg_default;
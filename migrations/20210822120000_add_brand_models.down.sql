@@ -0,0 +1,3 @@
+drop table if exists brand_models;
+
+drop sequence if exists brand_models_id_seq;
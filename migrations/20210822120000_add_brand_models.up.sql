@@ -0,0 +1,26 @@
+create sequence public.brand_models_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.brand_models
+(
+    id         bigint NOT NULL DEFAULT nextval('brand_models_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    brand_id   text COLLATE pg_catalog."default",
+    model      text COLLATE pg_catalog."default",
+    definition text COLLATE pg_catalog."default",
+    CONSTRAINT brand_models_pkey PRIMARY KEY (id)
+) TABLESPACE pg_default;
+
+ALTER TABLE public.brand_models
+    OWNER to manager;
+
+CREATE INDEX idx_brand_models_deleted_at
+    ON public.brand_models USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE UNIQUE INDEX idx_brand_models_brand_id_model
+    ON public.brand_models USING btree
+        (brand_id ASC NULLS LAST, model ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,7 @@
+drop table if exists device_sessions;
+
+drop sequence if exists device_sessions_id_seq;
+
+drop table if exists nonces;
+
+drop sequence if exists nonces_id_seq;
@@ -0,0 +1,47 @@
+create sequence public.nonces_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.nonces
+(
+    id         bigint NOT NULL DEFAULT nextval('nonces_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    value      text COLLATE pg_catalog."default",
+    expires_at timestamp with time zone,
+    used_at    timestamp with time zone,
+    CONSTRAINT nonces_pkey PRIMARY KEY (id),
+    CONSTRAINT nonces_value_key UNIQUE (value)
+) TABLESPACE pg_default;
+
+ALTER TABLE public.nonces
+    OWNER to manager;
+
+CREATE INDEX idx_nonces_deleted_at
+    ON public.nonces USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+create sequence public.device_sessions_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.device_sessions
+(
+    id         bigint NOT NULL DEFAULT nextval('device_sessions_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    serial_id  bigint,
+    expires_at timestamp with time zone,
+    CONSTRAINT device_sessions_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_device_sessions_serial FOREIGN KEY (serial_id)
+        REFERENCES public.serials (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.device_sessions
+    OWNER to manager;
+
+CREATE INDEX idx_device_sessions_deleted_at
+    ON public.device_sessions USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
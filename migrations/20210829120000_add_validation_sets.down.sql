@@ -0,0 +1,3 @@
+drop table if exists validation_sets;
+
+drop sequence if exists validation_sets_id_seq;
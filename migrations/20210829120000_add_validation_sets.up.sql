@@ -0,0 +1,27 @@
+create sequence public.validation_sets_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.validation_sets
+(
+    id         bigint NOT NULL DEFAULT nextval('validation_sets_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    account_id text COLLATE pg_catalog."default",
+    name       text COLLATE pg_catalog."default",
+    sequence   bigint,
+    definition text COLLATE pg_catalog."default",
+    CONSTRAINT validation_sets_pkey PRIMARY KEY (id)
+) TABLESPACE pg_default;
+
+ALTER TABLE public.validation_sets
+    OWNER to manager;
+
+CREATE INDEX idx_validation_sets_deleted_at
+    ON public.validation_sets USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE UNIQUE INDEX idx_validation_sets_account_id_name_sequence
+    ON public.validation_sets USING btree
+        (account_id ASC NULLS LAST, name ASC NULLS LAST, sequence ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,2 @@
+alter table keys drop column until;
+alter table keys drop column since;
@@ -0,0 +1,7 @@
+alter table keys
+    add since timestamp with time zone;
+alter table keys
+    add until timestamp with time zone;
+
+-- keys registered before this were served with a fixed since
+update keys set since = '2015-11-20 15:04:00+00' where since is null;
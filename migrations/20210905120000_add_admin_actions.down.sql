@@ -0,0 +1,3 @@
+drop table if exists admin_actions;
+
+drop sequence if exists admin_actions_id_seq;
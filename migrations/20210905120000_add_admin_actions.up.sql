@@ -0,0 +1,29 @@
+create sequence public.admin_actions_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.admin_actions
+(
+    id         bigint NOT NULL DEFAULT nextval('admin_actions_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    subject    text COLLATE pg_catalog."default",
+    username   text COLLATE pg_catalog."default",
+    email      text COLLATE pg_catalog."default",
+    method     text COLLATE pg_catalog."default",
+    path       text COLLATE pg_catalog."default",
+    status     bigint,
+    CONSTRAINT admin_actions_pkey PRIMARY KEY (id)
+) TABLESPACE pg_default;
+
+ALTER TABLE public.admin_actions
+    OWNER to manager;
+
+CREATE INDEX idx_admin_actions_deleted_at
+    ON public.admin_actions USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_admin_actions_subject
+    ON public.admin_actions USING btree
+        (subject ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,3 @@
+drop table if exists discharge_tokens;
+
+drop sequence if exists discharge_tokens_id_seq;
@@ -0,0 +1,32 @@
+create sequence public.discharge_tokens_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.discharge_tokens
+(
+    id         bigint NOT NULL DEFAULT nextval('discharge_tokens_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    token_id   text COLLATE pg_catalog."default",
+    account_id bigint,
+    expires_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    CONSTRAINT discharge_tokens_pkey PRIMARY KEY (id),
+    CONSTRAINT discharge_tokens_token_id_key UNIQUE (token_id),
+    CONSTRAINT fk_discharge_tokens_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.discharge_tokens
+    OWNER to manager;
+
+CREATE INDEX idx_discharge_tokens_deleted_at
+    ON public.discharge_tokens USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_discharge_tokens_account_id
+    ON public.discharge_tokens USING btree
+        (account_id ASC NULLS LAST)
+    TABLESPACE pg_default;
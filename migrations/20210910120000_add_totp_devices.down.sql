@@ -0,0 +1,3 @@
+drop table if exists totp_devices;
+
+drop sequence if exists totp_devices_id_seq;
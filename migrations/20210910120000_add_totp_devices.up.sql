@@ -0,0 +1,31 @@
+create sequence public.totp_devices_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.totp_devices
+(
+    id             bigint NOT NULL DEFAULT nextval('totp_devices_id_seq'::regclass),
+    created_at     timestamp with time zone,
+    updated_at     timestamp with time zone,
+    deleted_at     timestamp with time zone,
+    account_id     bigint,
+    secret         text COLLATE pg_catalog."default",
+    confirmed_at   timestamp with time zone,
+    last_used_step bigint,
+    CONSTRAINT totp_devices_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_totp_devices_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.totp_devices
+    OWNER to manager;
+
+CREATE INDEX idx_totp_devices_deleted_at
+    ON public.totp_devices USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE UNIQUE INDEX idx_totp_devices_account_id
+    ON public.totp_devices USING btree
+        (account_id ASC NULLS LAST)
+    TABLESPACE pg_default;
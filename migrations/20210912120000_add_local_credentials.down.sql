@@ -0,0 +1,3 @@
+drop table if exists local_credentials;
+
+drop sequence if exists local_credentials_id_seq;
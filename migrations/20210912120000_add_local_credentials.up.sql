@@ -0,0 +1,29 @@
+create sequence public.local_credentials_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.local_credentials
+(
+    id             bigint NOT NULL DEFAULT nextval('local_credentials_id_seq'::regclass),
+    created_at     timestamp with time zone,
+    updated_at     timestamp with time zone,
+    deleted_at     timestamp with time zone,
+    account_id     bigint,
+    password_hash  text COLLATE pg_catalog."default",
+    CONSTRAINT local_credentials_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_local_credentials_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.local_credentials
+    OWNER to manager;
+
+CREATE INDEX idx_local_credentials_deleted_at
+    ON public.local_credentials USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE UNIQUE INDEX idx_local_credentials_account_id
+    ON public.local_credentials USING btree
+        (account_id ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,6 @@
+drop table if exists snap_collaborators;
+
+drop sequence if exists snap_collaborators_id_seq;
+
+alter table snap_revisions drop column uploader_id;
+alter table snap_uploads drop column account_id;
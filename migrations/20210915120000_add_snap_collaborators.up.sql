@@ -0,0 +1,47 @@
+alter table snap_uploads
+    add account_id bigint;
+alter table snap_revisions
+    add uploader_id bigint;
+
+drop sequence if exists snap_collaborators_id_seq;
+create sequence public.snap_collaborators_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.snap_collaborators
+(
+    id            bigint NOT NULL DEFAULT nextval('snap_collaborators_id_seq'::regclass),
+    created_at    timestamp with time zone,
+    updated_at    timestamp with time zone,
+    deleted_at    timestamp with time zone,
+    snap_entry_id bigint,
+    account_id    bigint,
+    permissions   text COLLATE pg_catalog."default",
+    since         timestamp with time zone,
+    until         timestamp with time zone,
+    CONSTRAINT snap_collaborators_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_snap_collaborators_snap_entry FOREIGN KEY (snap_entry_id)
+        REFERENCES public.snap_entries (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION,
+    CONSTRAINT fk_snap_collaborators_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.snap_collaborators
+    OWNER to manager;
+
+CREATE INDEX idx_snap_collaborators_deleted_at
+    ON public.snap_collaborators USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_snap_collaborators_snap_entry_id
+    ON public.snap_collaborators USING btree
+        (snap_entry_id ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_snap_collaborators_account_id
+    ON public.snap_collaborators USING btree
+        (account_id ASC NULLS LAST)
+    TABLESPACE pg_default;
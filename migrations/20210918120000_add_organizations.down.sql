@@ -0,0 +1,7 @@
+drop table if exists organization_members;
+
+drop sequence if exists organization_members_id_seq;
+
+drop table if exists organizations;
+
+drop sequence if exists organizations_id_seq;
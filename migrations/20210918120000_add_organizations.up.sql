@@ -0,0 +1,63 @@
+drop sequence if exists organizations_id_seq;
+create sequence public.organizations_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.organizations
+(
+    id         bigint NOT NULL DEFAULT nextval('organizations_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    name       text COLLATE pg_catalog."default",
+    account_id bigint,
+    CONSTRAINT organizations_pkey PRIMARY KEY (id),
+    CONSTRAINT organizations_name_key UNIQUE (name),
+    CONSTRAINT organizations_account_id_key UNIQUE (account_id),
+    CONSTRAINT fk_organizations_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.organizations
+    OWNER to manager;
+
+CREATE INDEX idx_organizations_deleted_at
+    ON public.organizations USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+drop sequence if exists organization_members_id_seq;
+create sequence public.organization_members_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.organization_members
+(
+    id              bigint NOT NULL DEFAULT nextval('organization_members_id_seq'::regclass),
+    created_at      timestamp with time zone,
+    updated_at      timestamp with time zone,
+    deleted_at      timestamp with time zone,
+    organization_id bigint,
+    account_id      bigint,
+    role            text COLLATE pg_catalog."default",
+    CONSTRAINT organization_members_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_organizations_members FOREIGN KEY (organization_id)
+        REFERENCES public.organizations (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION,
+    CONSTRAINT fk_organization_members_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.organization_members
+    OWNER to manager;
+
+CREATE INDEX idx_organization_members_deleted_at
+    ON public.organization_members USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE UNIQUE INDEX idx_organization_members_organization_id_account_id
+    ON public.organization_members USING btree
+        (organization_id ASC NULLS LAST, account_id ASC NULLS LAST)
+    TABLESPACE pg_default;
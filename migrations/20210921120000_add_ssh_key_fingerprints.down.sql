@@ -0,0 +1,3 @@
+drop index if exists idx_ssh_keys_fingerprint;
+
+alter table ssh_keys drop column fingerprint;
@@ -0,0 +1,8 @@
+alter table ssh_keys
+    add fingerprint text COLLATE pg_catalog."default";
+
+-- keys inserted by hand before this have no fingerprint, the dashboard backfills them when it starts
+CREATE UNIQUE INDEX idx_ssh_keys_fingerprint
+    ON public.ssh_keys USING btree
+        (fingerprint ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1,2 @@
+alter table accounts
+    add suspended_at timestamp with time zone;
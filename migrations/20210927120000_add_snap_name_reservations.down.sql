@@ -0,0 +1,7 @@
+drop table if exists snap_name_disputes;
+
+drop sequence if exists snap_name_disputes_id_seq;
+
+drop table if exists reserved_snap_names;
+
+drop sequence if exists reserved_snap_names_id_seq;
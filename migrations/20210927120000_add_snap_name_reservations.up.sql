@@ -0,0 +1,61 @@
+drop sequence if exists reserved_snap_names_id_seq;
+create sequence public.reserved_snap_names_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.reserved_snap_names
+(
+    id         bigint NOT NULL DEFAULT nextval('reserved_snap_names_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    name       text COLLATE pg_catalog."default",
+    account_id bigint,
+    reason     text COLLATE pg_catalog."default",
+    CONSTRAINT reserved_snap_names_pkey PRIMARY KEY (id),
+    CONSTRAINT reserved_snap_names_name_key UNIQUE (name),
+    CONSTRAINT fk_reserved_snap_names_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.reserved_snap_names
+    OWNER to manager;
+
+CREATE INDEX idx_reserved_snap_names_deleted_at
+    ON public.reserved_snap_names USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+drop sequence if exists snap_name_disputes_id_seq;
+create sequence public.snap_name_disputes_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.snap_name_disputes
+(
+    id          bigint NOT NULL DEFAULT nextval('snap_name_disputes_id_seq'::regclass),
+    created_at  timestamp with time zone,
+    updated_at  timestamp with time zone,
+    deleted_at  timestamp with time zone,
+    name        text COLLATE pg_catalog."default",
+    account_id  bigint,
+    comment     text COLLATE pg_catalog."default",
+    status      text COLLATE pg_catalog."default" DEFAULT 'open'::text,
+    resolved_at timestamp with time zone,
+    CONSTRAINT snap_name_disputes_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_snap_name_disputes_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.snap_name_disputes
+    OWNER to manager;
+
+CREATE INDEX idx_snap_name_disputes_deleted_at
+    ON public.snap_name_disputes USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_snap_name_disputes_name
+    ON public.snap_name_disputes USING btree
+        (name ASC NULLS LAST)
+    TABLESPACE pg_default;
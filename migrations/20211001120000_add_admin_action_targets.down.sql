@@ -0,0 +1,2 @@
+alter table admin_actions drop column body;
+alter table admin_actions drop column query;
@@ -0,0 +1,4 @@
+alter table admin_actions
+    add query text COLLATE pg_catalog."default";
+alter table admin_actions
+    add body text COLLATE pg_catalog."default";
@@ -0,0 +1,5 @@
+drop table if exists admin_sessions;
+
+drop sequence if exists admin_sessions_id_seq;
+
+alter table accounts drop column admin;
@@ -0,0 +1,34 @@
+alter table accounts
+    add admin boolean default false;
+
+create sequence public.admin_sessions_id_seq;
+
+CREATE TABLE IF NOT EXISTS public.admin_sessions
+(
+    id         bigint NOT NULL DEFAULT nextval('admin_sessions_id_seq'::regclass),
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    token_hash text COLLATE pg_catalog."default",
+    account_id bigint,
+    expires_at timestamp with time zone,
+    CONSTRAINT admin_sessions_pkey PRIMARY KEY (id),
+    CONSTRAINT admin_sessions_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT fk_admin_sessions_account FOREIGN KEY (account_id)
+        REFERENCES public.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+) TABLESPACE pg_default;
+
+ALTER TABLE public.admin_sessions
+    OWNER to manager;
+
+CREATE INDEX idx_admin_sessions_deleted_at
+    ON public.admin_sessions USING btree
+        (deleted_at ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX idx_admin_sessions_account_id
+    ON public.admin_sessions USING btree
+        (account_id ASC NULLS LAST)
+    TABLESPACE pg_default;
@@ -0,0 +1 @@
+alter table snap_uploads drop column revision_id;
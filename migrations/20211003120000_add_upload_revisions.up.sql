@@ -0,0 +1,2 @@
+alter table snap_uploads
+    add revision_id bigint;
@@ -0,0 +1,2 @@
+alter table accounts
+    add constraint accounts_display_name_key unique (display_name);
@@ -0,0 +1,2 @@
+alter table accounts
+    drop constraint if exists accounts_display_name_key;
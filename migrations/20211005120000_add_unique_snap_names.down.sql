@@ -0,0 +1 @@
+drop index if exists idx_snap_entries_name;
@@ -0,0 +1,6 @@
+-- a name can only be registered once, deleted snaps don't hold on to theirs
+CREATE UNIQUE INDEX idx_snap_entries_name
+    ON public.snap_entries USING btree
+        (name ASC NULLS LAST)
+    TABLESPACE pg_default
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,204 @@
+package admind
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func accountResponse(account *models.Account) responses.Account {
+	accountResp := responses.Account{
+		AccountId:   account.AccountId,
+		Username:    account.Username,
+		DisplayName: account.DisplayName,
+		Email:       account.Email,
+		Validation:  account.GetValidation(),
+		SuspendedAt: account.SuspendedAt,
+		Admin:       account.Admin,
+		CreatedAt:   account.CreatedAt,
+		Snaps:       []string{},
+	}
+
+	for _, snapEntry := range account.SnapEntries {
+		accountResp.Snaps = append(accountResp.Snaps, snapEntry.Name)
+	}
+
+	return accountResp
+}
+
+func (s *Server) getAccounts(c *gin.Context) {
+	accounts, err := s.accounts.GetAccounts()
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	accountResponses := []responses.Account{}
+	for i := range *accounts {
+		accountResponses = append(accountResponses, accountResponse(&(*accounts)[i]))
+	}
+
+	c.JSON(http.StatusOK, &accountResponses)
+}
+
+func (s *Server) getAccount(c *gin.Context) {
+	account, ok := s.getAccountById(c, c.Query("account"), true)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, accountResponse(account))
+}
+
+// updateAccount changes the username, email or display name of an account, the ones left empty are kept
+func (s *Server) updateAccount(c *gin.Context) {
+	var updateAccountReq requests.UpdateAccount
+	err := json.NewDecoder(c.Request.Body).Decode(&updateAccountReq)
+	if err == nil {
+		account, ok := s.getAccountById(c, updateAccountReq.AccountId, false)
+		if !ok {
+			return
+		}
+
+		if updateAccountReq.Username != "" {
+			account.Username = updateAccountReq.Username
+		}
+
+		if updateAccountReq.Email != "" {
+			account.Email = updateAccountReq.Email
+		}
+
+		if updateAccountReq.DisplayName != "" {
+			account.DisplayName = updateAccountReq.DisplayName
+		}
+
+		if !s.checkAccount(c, account) {
+			return
+		}
+
+		err = s.accounts.SaveAccount(account)
+		if err == nil {
+			logrus.Infof("Updated account %s", account.AccountId)
+			c.JSON(http.StatusOK, accountResponse(account))
+			return
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// suspendAccount stops the account from logging in, pushing and releasing and hides its snaps from find. Its
+// discharges are kept so they work again if the account is reactivated.
+func (s *Server) suspendAccount(c *gin.Context) {
+	var suspendAccountReq requests.SuspendAccount
+	err := json.NewDecoder(c.Request.Body).Decode(&suspendAccountReq)
+	if err == nil {
+		s.setAccountSuspended(c, suspendAccountReq.AccountId, true)
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+func (s *Server) reactivateAccount(c *gin.Context) {
+	var reactivateAccountReq requests.ReactivateAccount
+	err := json.NewDecoder(c.Request.Body).Decode(&reactivateAccountReq)
+	if err == nil {
+		s.setAccountSuspended(c, reactivateAccountReq.AccountId, false)
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+func (s *Server) setAccountSuspended(c *gin.Context, accountId string, suspended bool) {
+	account, ok := s.getAccountById(c, accountId, false)
+	if !ok {
+		return
+	}
+
+	if account.IsSuspended() == suspended {
+		c.JSON(http.StatusOK, accountResponse(account))
+		return
+	}
+
+	err := s.accounts.SetAccountSuspended(account, suspended)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if suspended {
+		logrus.Infof("Suspended account %s", account.AccountId)
+	} else {
+		logrus.Infof("Reactivated account %s", account.AccountId)
+	}
+
+	c.JSON(http.StatusOK, accountResponse(account))
+}
+
+// deleteAccount removes an account that doesn't publish any snaps, they have to be transferred first. Its account
+// id, username and display name can't be reused.
+func (s *Server) deleteAccount(c *gin.Context) {
+	var deleteAccountReq requests.DeleteAccount
+	err := json.NewDecoder(c.Request.Body).Decode(&deleteAccountReq)
+	if err == nil {
+		account, ok := s.getAccountById(c, deleteAccountReq.AccountId, true)
+		if !ok {
+			return
+		}
+
+		if len(account.SnapEntries) > 0 {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": account.AccountId + " publishes snaps, transfer them first"})
+			return
+		}
+
+		organization, err2 := s.organizations.GetOrganizationByAccount(account.ID)
+		if err2 == nil && organization != nil {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": account.AccountId + " is the account of organization " + organization.Name})
+			return
+		} else if err2 == nil {
+			err2 = s.accounts.DeleteAccount(account)
+			if err2 == nil {
+				logrus.Infof("Deleted account %s", account.AccountId)
+				c.Status(http.StatusOK)
+				return
+			}
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// getAccountById aborts the request if there is no account with the id
+func (s *Server) getAccountById(c *gin.Context, accountId string, preload bool) (*models.Account, bool) {
+	if accountId == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "an account id is required"})
+		return nil, false
+	}
+
+	account, err := s.accounts.GetAccountById(accountId, preload)
+	if err == nil && account == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + accountId})
+		return nil, false
+	} else if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return account, true
+}
@@ -0,0 +1,331 @@
+package admind
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid access token")
+	ErrNotAdmin     = errors.New("not a member of the admin group")
+)
+
+// Admin authentication backends that can be set with admind.auth.backend
+const (
+	AuthBackendOIDC  = "oidc"
+	AuthBackendLocal = "local"
+)
+
+// AdminAuthenticator checks the bearer tokens kebe-admin sends belong to admins
+type AdminAuthenticator interface {
+	// Authenticate returns ErrInvalidToken if the token isn't valid and ErrNotAdmin if it isn't an admin's
+	Authenticate(ctx context.Context, accessToken string) (*UserInfo, error)
+}
+
+// Authenticator checks the OIDC access tokens kebe-admin sends belong to admins
+type Authenticator struct {
+	provider   *oidc.Provider
+	verifier   *oidc.IDTokenVerifier
+	clientId   string
+	adminGroup string
+
+	mutex sync.Mutex
+	// admins caches the user info of verified tokens until they expire, by the sha256 of the token
+	admins map[[sha256.Size]byte]*cachedAdmin
+}
+
+type cachedAdmin struct {
+	userInfo UserInfo
+	expiry   time.Time
+}
+
+func NewAuthenticator(ctx context.Context, providerURL string, clientId string, adminGroup string) (*Authenticator, error) {
+	provider, err := oidc.NewProvider(ctx, providerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Authenticator{
+		provider: provider,
+		// access tokens are usually for another audience, the client is checked against azp instead
+		verifier:   provider.Verifier(&oidc.Config{SkipClientIDCheck: true}),
+		clientId:   clientId,
+		adminGroup: adminGroup,
+		admins:     map[[sha256.Size]byte]*cachedAdmin{},
+	}, nil
+}
+
+// Authenticate verifies the access token was signed by the provider, using its JWKS, and that its user is in the
+// admin group
+func (a *Authenticator) Authenticate(ctx context.Context, accessToken string) (*UserInfo, error) {
+	if accessToken == "" {
+		return nil, fmt.Errorf("%w: no access token", ErrInvalidToken)
+	}
+
+	tokenHash := sha256.Sum256([]byte(accessToken))
+	a.mutex.Lock()
+	cached, ok := a.admins[tokenHash]
+	a.mutex.Unlock()
+	if ok && time.Now().Before(cached.expiry) {
+		return &cached.userInfo, nil
+	}
+
+	token, err := a.verifier.Verify(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+
+	var claims struct {
+		AuthorizedParty string `json:"azp"`
+	}
+	err = token.Claims(&claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+
+	if claims.AuthorizedParty != a.clientId && !contains(token.Audience, a.clientId) {
+		return nil, fmt.Errorf("%w: token was not issued to %s", ErrInvalidToken, a.clientId)
+	}
+
+	providerUserInfo, err := a.provider.UserInfo(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken}))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+
+	var userInfo UserInfo
+	err = providerUserInfo.Claims(&userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if userInfo.Sub != token.Subject {
+		return nil, fmt.Errorf("%w: user info is for another subject", ErrInvalidToken)
+	}
+
+	if !contains(userInfo.Groups, a.adminGroup) && !contains(userInfo.Groups, "/"+a.adminGroup) {
+		return nil, fmt.Errorf("%w: %s", ErrNotAdmin, userInfo.PreferredUsername)
+	}
+
+	a.mutex.Lock()
+	for hash, admin := range a.admins {
+		if time.Now().After(admin.expiry) {
+			delete(a.admins, hash)
+		}
+	}
+	a.admins[tokenHash] = &cachedAdmin{userInfo: userInfo, expiry: token.Expiry}
+	a.mutex.Unlock()
+
+	return &userInfo, nil
+}
+
+// RequireAdmin only lets requests from admins through, the admin's user info is set in the context
+func RequireAdmin(a AdminAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken := strings.TrimSpace(c.GetHeader("Authorization"))
+		accessToken = strings.TrimSpace(strings.TrimPrefix(accessToken, "Bearer"))
+
+		userInfo, err := a.Authenticate(c.Request.Context(), accessToken)
+		if errors.Is(err, ErrNotAdmin) {
+			logrus.Warn(err)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": err.Error()})
+			return
+		} else if err != nil {
+			logrus.Warn(err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.Set("admin", userInfo)
+		c.Next()
+	}
+}
+
+// recordAdminActions stores every change made by an admin, requests that only read are not recorded
+func (s *Server) recordAdminActions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		// the body is read for the record and put back for the handler
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = ioutil.ReadAll(c.Request.Body)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+				return
+			}
+			c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		c.Next()
+
+		admin := getAdmin(c)
+		if admin == nil {
+			return
+		}
+
+		action := models.AdminAction{
+			Subject:  admin.Sub,
+			Username: admin.PreferredUsername,
+			Email:    admin.Email,
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Query:    redactQuery(c.Request.URL.Query()),
+			Body:     redactBody(body),
+			Status:   c.Writer.Status(),
+		}
+
+		logrus.Infof("Admin %s (%s): %s %s %s %d", action.Username, action.Subject, action.Method, action.Path, action.Body, action.Status)
+		err := s.adminActions.AddAction(&action)
+		if err != nil {
+			logrus.Errorf("unable to record admin action: %s", err)
+		}
+	}
+}
+
+// redacted replaces the values of secrets in recorded admin actions
+const redacted = "REDACTED"
+
+// isSecret returns true for the names of request fields and query parameters that hold passwords, private keys,
+// tokens and other secrets, they are compared ignoring case, dashes and underscores
+func isSecret(name string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+	for _, suffix := range []string{"password", "passphrase", "privatekey", "secret", "token", "macaroon", "discharge", "pin"} {
+		if strings.HasSuffix(normalized, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactQuery(query url.Values) string {
+	for name := range query {
+		if isSecret(name) {
+			query[name] = []string{redacted}
+		}
+	}
+
+	return query.Encode()
+}
+
+// redactBody returns the JSON body with the values of secrets redacted, bodies that aren't JSON are not recorded
+func redactBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+
+	var value interface{}
+	err := json.Unmarshal(body, &value)
+	if err != nil {
+		return fmt.Sprintf("(%d bytes that aren't JSON)", len(body))
+	}
+
+	redactedBody, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return ""
+	}
+
+	return string(redactedBody)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, fieldValue := range v {
+			if isSecret(name) {
+				v[name] = redacted
+			} else {
+				v[name] = redactValue(fieldValue)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+
+	return value
+}
+
+// getAdmin returns the user info set by RequireAdmin
+func getAdmin(c *gin.Context) *UserInfo {
+	value, ok := c.Get("admin")
+	if !ok {
+		return nil
+	}
+
+	userInfo, _ := value.(*UserInfo)
+	return userInfo
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultAdminActionsLimit is how many actions are listed when no limit is asked for
+const defaultAdminActionsLimit = 100
+
+func (s *Server) getAdminActions(c *gin.Context) {
+	limit := defaultAdminActionsLimit
+	if limitQuery := c.Query("limit"); limitQuery != "" {
+		parsed, err := strconv.Atoi(limitQuery)
+		if err != nil || parsed <= 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid limit: " + limitQuery})
+			return
+		}
+
+		limit = parsed
+	}
+
+	actions, err := s.adminActions.GetActions(c.Query("subject"), limit)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	actionResponses := []responses.AdminAction{}
+	for _, action := range *actions {
+		actionResponses = append(actionResponses, responses.AdminAction{
+			Subject:  action.Subject,
+			Username: action.Username,
+			Email:    action.Email,
+			Method:   action.Method,
+			Path:     action.Path,
+			Query:    action.Query,
+			Body:     action.Body,
+			Status:   action.Status,
+			At:       action.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, &actionResponses)
+}
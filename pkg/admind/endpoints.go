@@ -5,6 +5,51 @@ import "github.com/gin-gonic/gin"
 func (s *Server) SetupEndpoints(r *gin.Engine) {
 	s.engine = r
 
+	// admins log in with their local credential when admind doesn't use OIDC
+	if s.localAuthenticator != nil {
+		r.POST("/v1/admin/login", s.login)
+	}
+
+	r.Use(RequireAdmin(s.authenticator), s.recordAdminActions())
+
 	r.POST("/v1/admin/account", s.addAccount)
+	r.GET("/v1/admin/accounts", s.getAccounts)
+	r.GET("/v1/admin/account", s.getAccount)
+	r.POST("/v1/admin/account/update", s.updateAccount)
+	r.POST("/v1/admin/account/suspend", s.suspendAccount)
+	r.POST("/v1/admin/account/reactivate", s.reactivateAccount)
+	r.POST("/v1/admin/account/delete", s.deleteAccount)
+	r.POST("/v1/admin/account/validation", s.setAccountValidation)
+	r.POST("/v1/admin/account/twofactor/reset", s.resetTwoFactor)
+	r.POST("/v1/admin/account/password", s.setAccountPassword)
+	r.POST("/v1/admin/account/key", s.addAccountKey)
+	r.POST("/v1/admin/account/key/revoke", s.revokeAccountKey)
+	r.GET("/v1/admin/account/ssh-keys", s.getSSHKeys)
+	r.POST("/v1/admin/account/ssh-key", s.addSSHKey)
+	r.POST("/v1/admin/account/ssh-key/remove", s.removeSSHKey)
 	r.POST("/v1/admin/track", s.addTrack)
+	r.GET("/v1/admin/serials", s.getSerials)
+	r.POST("/v1/admin/model", s.saveModel)
+	r.POST("/v1/admin/model/sign", s.signModel)
+	r.GET("/v1/admin/models", s.getModels)
+	r.POST("/v1/admin/validation-set", s.addValidationSet)
+	r.POST("/v1/admin/validation-set/sign", s.signValidationSet)
+	r.GET("/v1/admin/validation-sets", s.getValidationSets)
+	r.POST("/v1/admin/serial/revoke", s.revokeSerial)
+	r.GET("/v1/admin/actions", s.getAdminActions)
+	r.GET("/v1/admin/tokens", s.getTokens)
+	r.POST("/v1/admin/token/revoke", s.revokeToken)
+	r.POST("/v1/admin/account/tokens/revoke", s.revokeAccountTokens)
+	r.POST("/v1/admin/organization", s.addOrganization)
+	r.GET("/v1/admin/organizations", s.getOrganizations)
+	r.POST("/v1/admin/organization/member", s.setOrganizationMember)
+	r.POST("/v1/admin/organization/member/remove", s.removeOrganizationMember)
+	r.POST("/v1/admin/organization/snap/transfer", s.transferSnap)
+	r.POST("/v1/admin/snap/transfer", s.transferSnapToAccount)
+	r.POST("/v1/admin/snap/unregister", s.unregisterSnap)
+	r.GET("/v1/admin/snap-names/reserved", s.getReservedSnapNames)
+	r.POST("/v1/admin/snap-name/reserve", s.reserveSnapName)
+	r.POST("/v1/admin/snap-name/unreserve", s.unreserveSnapName)
+	r.GET("/v1/admin/snap-name/disputes", s.getSnapNameDisputes)
+	r.POST("/v1/admin/snap-name/dispute/resolve", s.resolveSnapNameDispute)
 }
@@ -0,0 +1,172 @@
+package admind
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/login/identity"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// localSubjectPrefix is put in front of the account id of local admins, it keeps their subject in admin actions
+// apart from OIDC ones
+const localSubjectPrefix = "local:"
+
+// LocalAuthenticator lets accounts flagged as admins log in to admind with the same local credential the login
+// service's local identity backend checks, no identity provider is needed. The sessions it issues are bearer tokens
+// kept in the database.
+type LocalAuthenticator struct {
+	accounts *repositories.AccountRepository
+	sessions *repositories.AdminSessionsRepository
+	identity *identity.LocalBackend
+	expiry   time.Duration
+}
+
+func NewLocalAuthenticator(accounts *repositories.AccountRepository, sessions *repositories.AdminSessionsRepository, credentials *repositories.LocalCredentialsRepository, expiry time.Duration) *LocalAuthenticator {
+	return &LocalAuthenticator{
+		accounts: accounts,
+		sessions: sessions,
+		identity: identity.NewLocalBackend(credentials),
+		expiry:   expiry,
+	}
+}
+
+// Login checks the password of the admin account with the email and returns a new session token
+func (l *LocalAuthenticator) Login(ctx context.Context, email string, password string) (string, *models.AdminSession, error) {
+	account, err := l.accounts.GetAccountByEmail(email, false)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if email == "" || account == nil {
+		return "", nil, fmt.Errorf("%w: invalid email or password", ErrInvalidToken)
+	}
+
+	err = l.identity.Authenticate(ctx, account, password)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: invalid email or password", ErrInvalidToken)
+	}
+
+	err = checkLocalAdmin(account)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tokenBytes := make([]byte, 32)
+	_, err = rand.Read(tokenBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	session := models.AdminSession{
+		TokenHash: hashToken(token),
+		AccountID: account.ID,
+		Account:   *account,
+		ExpiresAt: time.Now().Add(l.expiry),
+	}
+
+	err = l.sessions.AddSession(&session)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &session, nil
+}
+
+// Authenticate checks the session token was issued by Login, has not expired and its account is still an admin
+func (l *LocalAuthenticator) Authenticate(ctx context.Context, accessToken string) (*UserInfo, error) {
+	if accessToken == "" {
+		return nil, fmt.Errorf("%w: no access token", ErrInvalidToken)
+	}
+
+	session, err := l.sessions.GetSession(hashToken(accessToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil || !time.Now().Before(session.ExpiresAt) {
+		return nil, fmt.Errorf("%w: unknown or expired session", ErrInvalidToken)
+	}
+
+	// the account isn't loaded if it has been deleted
+	if session.Account.ID == 0 {
+		return nil, fmt.Errorf("%w: the account of the session has been deleted", ErrInvalidToken)
+	}
+
+	err = checkLocalAdmin(&session.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	return localUserInfo(&session.Account), nil
+}
+
+func checkLocalAdmin(account *models.Account) error {
+	if !account.Admin || account.IsSuspended() {
+		return fmt.Errorf("%w: %s", ErrNotAdmin, account.Username)
+	}
+
+	return nil
+}
+
+func localUserInfo(account *models.Account) *UserInfo {
+	return &UserInfo{
+		Sub:               localSubjectPrefix + account.AccountId,
+		Name:              account.DisplayName,
+		PreferredUsername: account.Username,
+		Email:             account.Email,
+	}
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *Server) login(c *gin.Context) {
+	var loginReq requests.AdminLogin
+	err := json.NewDecoder(c.Request.Body).Decode(&loginReq)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	token, session, err := s.localAuthenticator.Login(c.Request.Context(), loginReq.Email, loginReq.Password)
+	if errors.Is(err, ErrNotAdmin) {
+		logrus.Warn(err)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		return
+	} else if errors.Is(err, ErrInvalidToken) {
+		logrus.Warnf("Invalid admin credentials for %s", loginReq.Email)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	} else if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	userInfo := localUserInfo(&session.Account)
+	logrus.Infof("Admin %s (%s) logged in", userInfo.PreferredUsername, userInfo.Sub)
+	c.JSON(http.StatusOK, &responses.AdminLogin{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		Subject:   userInfo.Sub,
+		Username:  userInfo.PreferredUsername,
+		Email:     userInfo.Email,
+	})
+}
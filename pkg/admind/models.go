@@ -0,0 +1,154 @@
+package admind
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/crypto"
+	"github.com/freetocompute/kebe/pkg/models"
+	asserts2 "github.com/freetocompute/kebe/pkg/store/asserts"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/asserts"
+)
+
+func (s *Server) saveModel(c *gin.Context) {
+	var definition models.ModelDefinition
+	err := json.NewDecoder(c.Request.Body).Decode(&definition)
+	if err == nil {
+		account, err2 := s.accounts.GetAccountById(definition.BrandID, false)
+		if err2 != nil || account == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "brand account not found: " + definition.BrandID})
+			return
+		}
+
+		brandModel, err2 := s.models.SaveBrandModel(&definition)
+		if err2 == nil && brandModel != nil {
+			c.Status(http.StatusCreated)
+			return
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+}
+
+// signModel signs the model definition with a key of the brand, a new revision is only made if the definition
+// has changed since it was last signed
+func (s *Server) signModel(c *gin.Context) {
+	var signModelReq requests.SignModel
+	err := json.NewDecoder(c.Request.Body).Decode(&signModelReq)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	brandModel, err := s.models.GetBrandModel(signModelReq.BrandId, signModelReq.Model)
+	if err == nil && brandModel == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "model not found: " + signModelReq.BrandId + "/" + signModelReq.Model})
+		return
+	}
+
+	if err == nil {
+		var definition *models.ModelDefinition
+		definition, err = brandModel.GetDefinition()
+		if err == nil {
+			var signingDB *crypto.SigningDB
+			signingDB, err = s.getSigningDB(signModelReq.BrandId, signModelReq.KeyId)
+			if err == nil {
+				var modelAssertion asserts.Assertion
+				modelAssertion, err = asserts2.SignAndPersist(asserts.ModelType, definition.Headers(), nil, signingDB.KeyID, signingDB, s.assertions)
+				if err == nil {
+					c.Data(http.StatusOK, asserts.MediaType, asserts.Encode(modelAssertion))
+					return
+				}
+			}
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+}
+
+func (s *Server) getModels(c *gin.Context) {
+	brandModels, err := s.models.GetBrandModels(c.Query("brand"))
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	modelResponses := []responses.Model{}
+	for _, brandModel := range brandModels {
+		definition, err2 := brandModel.GetDefinition()
+		if err2 != nil {
+			logrus.Error(err2)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		modelResponse := responses.Model{
+			BrandId:    brandModel.BrandID,
+			Model:      brandModel.ModelName,
+			Definition: *definition,
+		}
+
+		primaryKey, err2 := asserts2.PrimaryKey(asserts.ModelType, definition.Headers())
+		if err2 == nil {
+			latest, err3 := s.assertions.GetLatestAssertion(asserts.ModelType.Name, primaryKey)
+			if err3 == nil && latest != nil {
+				modelResponse.Revision = latest.Revision
+			}
+		}
+
+		modelResponses = append(modelResponses, modelResponse)
+	}
+
+	c.JSON(http.StatusOK, &modelResponses)
+}
+
+// getSigningDB signs as the account with the named key, or its most recent key the signing backend holds if no
+// key is named
+func (s *Server) getSigningDB(accountId string, keyId string) (*crypto.SigningDB, error) {
+	account, err := s.accounts.GetAccountById(accountId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, errors.New("account not found: " + accountId)
+	}
+
+	// the most recent key is preferred, so a brand rotates keys by adding a new one
+	keys, err := s.accounts.GetSigningKeys(accountId, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if keyId != "" && key.SHA3384 != keyId {
+			continue
+		}
+
+		_, err2 := s.signingBackend.Signer(key.SHA3384)
+		if err2 == nil {
+			return crypto.NewSigningDB(s.signingBackend, accountId, key.SHA3384), nil
+		}
+
+		logrus.Warnf("Key %s for %s is not available from the signing backend: %s", key.SHA3384, accountId, err2)
+	}
+
+	if keyId != "" {
+		return nil, fmt.Errorf("key %s of %s is revoked, expired or not available to sign with", keyId, accountId)
+	}
+
+	return nil, errors.New("no key available to sign for account: " + accountId)
+}
@@ -0,0 +1,215 @@
+package admind
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func organizationResponse(organization *models.Organization) responses.Organization {
+	organizationResp := responses.Organization{
+		Name:        organization.Name,
+		AccountId:   organization.Account.AccountId,
+		DisplayName: organization.Account.DisplayName,
+		Members:     []responses.OrganizationMember{},
+	}
+
+	for _, member := range organization.Members {
+		organizationResp.Members = append(organizationResp.Members, responses.OrganizationMember{
+			AccountId: member.Account.AccountId,
+			Username:  member.Account.Username,
+			Email:     member.Account.Email,
+			Role:      member.Role,
+		})
+	}
+
+	return organizationResp
+}
+
+// addOrganization creates an organization along with the account its snaps are published as
+func (s *Server) addOrganization(c *gin.Context) {
+	var addOrganizationReq requests.AddOrganization
+	err := json.NewDecoder(c.Request.Body).Decode(&addOrganizationReq)
+	if err == nil {
+		if addOrganizationReq.Name == "" || addOrganizationReq.AccountId == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "a name and an account id are required"})
+			return
+		}
+
+		existing, err2 := s.organizations.GetOrganization(addOrganizationReq.Name)
+		if err2 != nil {
+			logrus.Error(err2)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		} else if existing != nil {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": "organization already exists: " + addOrganizationReq.Name})
+			return
+		}
+
+		displayName := addOrganizationReq.DisplayName
+		if displayName == "" {
+			displayName = addOrganizationReq.Name
+		}
+
+		account := models.Account{
+			AccountId:   addOrganizationReq.AccountId,
+			DisplayName: displayName,
+			Username:    addOrganizationReq.Name,
+			Email:       addOrganizationReq.Email,
+			Validation:  models.AccountValidationUnproven,
+		}
+
+		if !s.checkAccount(c, &account) {
+			return
+		}
+
+		organization, err2 := s.organizations.CreateOrganization(addOrganizationReq.Name, &account)
+		if err2 == nil && organization != nil {
+			logrus.Infof("Created organization %s publishing as %s", organization.Name, account.AccountId)
+			c.JSON(http.StatusCreated, organizationResponse(organization))
+			return
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+func (s *Server) getOrganizations(c *gin.Context) {
+	organizations, err := s.organizations.GetOrganizations()
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	organizationResponses := []responses.Organization{}
+	for i := range *organizations {
+		organizationResponses = append(organizationResponses, organizationResponse(&(*organizations)[i]))
+	}
+
+	c.JSON(http.StatusOK, &organizationResponses)
+}
+
+// setOrganizationMember adds an account to an organization or changes its role
+func (s *Server) setOrganizationMember(c *gin.Context) {
+	var setMemberReq requests.SetOrganizationMember
+	err := json.NewDecoder(c.Request.Body).Decode(&setMemberReq)
+	if err == nil {
+		if !models.IsValidOrganizationRole(setMemberReq.Role) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid role: " + setMemberReq.Role})
+			return
+		}
+
+		organization, account, ok := s.getOrganizationAndAccount(c, setMemberReq.Organization, setMemberReq.AccountId)
+		if !ok {
+			return
+		}
+
+		if account.ID == organization.AccountID {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "an organization can't be a member of itself"})
+			return
+		}
+
+		_, err = s.organizations.SetMember(organization.ID, account.ID, setMemberReq.Role)
+		if err == nil {
+			logrus.Infof("%s is a %s of organization %s", account.AccountId, setMemberReq.Role, organization.Name)
+			c.Status(http.StatusOK)
+			return
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+func (s *Server) removeOrganizationMember(c *gin.Context) {
+	var removeMemberReq requests.RemoveOrganizationMember
+	err := json.NewDecoder(c.Request.Body).Decode(&removeMemberReq)
+	if err == nil {
+		organization, account, ok := s.getOrganizationAndAccount(c, removeMemberReq.Organization, removeMemberReq.AccountId)
+		if !ok {
+			return
+		}
+
+		removed, err2 := s.organizations.RemoveMember(organization.ID, account.ID)
+		if err2 == nil && !removed {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": account.AccountId + " is not a member of " + organization.Name})
+			return
+		} else if err2 == nil {
+			logrus.Infof("Removed %s from organization %s", account.AccountId, organization.Name)
+			c.Status(http.StatusOK)
+			return
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// transferSnap makes an organization the publisher of a snap, it can be owned by an account or another
+// organization. Its assertions are signed again with the organization as the publisher when next requested.
+func (s *Server) transferSnap(c *gin.Context) {
+	var transferSnapReq requests.TransferSnap
+	err := json.NewDecoder(c.Request.Body).Decode(&transferSnapReq)
+	if err == nil {
+		organization, err2 := s.organizations.GetOrganization(transferSnapReq.Organization)
+		if err2 == nil && organization == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "organization not found: " + transferSnapReq.Organization})
+			return
+		} else if err2 != nil {
+			logrus.Error(err2)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		snapEntry, ok := s.getSnapByName(c, transferSnapReq.SnapName, false)
+		if !ok {
+			return
+		}
+
+		err = s.snaps.TransferSnap(snapEntry.ID, organization.AccountID)
+		if err == nil {
+			logrus.Infof("Transferred %s to organization %s", snapEntry.Name, organization.Name)
+			c.Status(http.StatusOK)
+			return
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// getOrganizationAndAccount aborts the request if either isn't found
+func (s *Server) getOrganizationAndAccount(c *gin.Context, name string, accountId string) (*models.Organization, *models.Account, bool) {
+	organization, err := s.organizations.GetOrganization(name)
+	if err == nil && organization == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "organization not found: " + name})
+		return nil, nil, false
+	} else if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	account, err := s.accounts.GetAccountById(accountId, false)
+	if err == nil && account == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + accountId})
+		return nil, nil, false
+	} else if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	return organization, account, true
+}
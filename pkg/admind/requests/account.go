@@ -5,9 +5,66 @@ type AddAccount struct {
 	AcccountId  string
 	Email       string
 	DisplayName string
+	Validation  string
+}
+
+type SetAccountValidation struct {
+	AccountId  string
+	Validation string
+}
+
+type SetAccountPassword struct {
+	AccountId string
+	Password  string
+}
+
+type ResetTwoFactor struct {
+	AccountId string
 }
 
 type AddTrack struct {
 	SnapName  string
 	TrackName string
 }
+
+type AddAccountKey struct {
+	AccountId string
+	Name      string
+	// PrivateKey is a PEM encoded RSA private key, a new key is generated when it's empty
+	PrivateKey string
+}
+
+type RevokeAccountKey struct {
+	SHA3384 string
+}
+
+type AddSSHKey struct {
+	AccountId string
+	// PublicKey is in the authorized_keys format
+	PublicKey string
+}
+
+type RemoveSSHKey struct {
+	AccountId   string
+	Fingerprint string
+}
+
+// UpdateAccount changes the fields that are set, the account id can't be changed
+type UpdateAccount struct {
+	AccountId   string
+	Username    string
+	Email       string
+	DisplayName string
+}
+
+type SuspendAccount struct {
+	AccountId string
+}
+
+type ReactivateAccount struct {
+	AccountId string
+}
+
+type DeleteAccount struct {
+	AccountId string
+}
@@ -0,0 +1,7 @@
+package requests
+
+// AdminLogin logs an admin in with their local credential, it is only accepted when admind.auth.backend is local
+type AdminLogin struct {
+	Email    string
+	Password string
+}
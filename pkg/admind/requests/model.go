@@ -0,0 +1,16 @@
+package requests
+
+type SignModel struct {
+	BrandId string
+	Model   string
+	// KeyId is the brand key to sign with, the first key Kebe holds for the brand is used when it's empty
+	KeyId string
+}
+
+type SignValidationSet struct {
+	AccountId string
+	Name      string
+	// Sequence is the sequence to sign, the latest one is used when it's zero
+	Sequence int
+	KeyId    string
+}
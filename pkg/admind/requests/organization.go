@@ -0,0 +1,25 @@
+package requests
+
+// AddOrganization creates an organization and the account it publishes as
+type AddOrganization struct {
+	Name        string
+	AccountId   string
+	DisplayName string
+	Email       string
+}
+
+type SetOrganizationMember struct {
+	Organization string
+	AccountId    string
+	Role         string
+}
+
+type RemoveOrganizationMember struct {
+	Organization string
+	AccountId    string
+}
+
+type TransferSnap struct {
+	SnapName     string
+	Organization string
+}
@@ -0,0 +1,7 @@
+package requests
+
+type RevokeSerial struct {
+	BrandId string
+	Model   string
+	Serial  string
+}
@@ -0,0 +1,27 @@
+package requests
+
+// ReserveSnapName reserves a name for the account, or blocks it for everyone when AccountId is empty
+type ReserveSnapName struct {
+	Name      string
+	AccountId string
+	Reason    string
+}
+
+type UnreserveSnapName struct {
+	Name string
+}
+
+// ResolveSnapNameDispute accepts or rejects a dispute, accepting gives the name to the account that disputed it
+type ResolveSnapNameDispute struct {
+	DisputeId uint
+	Accept    bool
+}
+
+type TransferSnapToAccount struct {
+	SnapName  string
+	AccountId string
+}
+
+type UnregisterSnap struct {
+	SnapName string
+}
@@ -0,0 +1,9 @@
+package requests
+
+type RevokeToken struct {
+	TokenId string
+}
+
+type RevokeAccountTokens struct {
+	AccountId string
+}
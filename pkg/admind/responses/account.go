@@ -0,0 +1,29 @@
+package responses
+
+import "time"
+
+type AccountKey struct {
+	Name    string
+	SHA3384 string
+	Since   time.Time
+	Until   *time.Time
+}
+
+type SSHKey struct {
+	PublicKey   string
+	Fingerprint string
+	CreatedAt   time.Time
+}
+
+type Account struct {
+	AccountId   string
+	Username    string
+	DisplayName string
+	Email       string
+	Validation  string
+	SuspendedAt *time.Time
+	// Admin is set for accounts that can log in to admind with their local credential
+	Admin     bool
+	CreatedAt time.Time
+	Snaps     []string
+}
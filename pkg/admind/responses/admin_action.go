@@ -0,0 +1,15 @@
+package responses
+
+import "time"
+
+type AdminAction struct {
+	Subject  string
+	Username string
+	Email    string
+	Method   string
+	Path     string
+	Query    string
+	Body     string
+	Status   int
+	At       time.Time
+}
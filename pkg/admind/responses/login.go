@@ -0,0 +1,12 @@
+package responses
+
+import "time"
+
+// AdminLogin is the session admind issued, the token is sent as a bearer token until it expires
+type AdminLogin struct {
+	Token     string
+	ExpiresAt time.Time
+	Subject   string
+	Username  string
+	Email     string
+}
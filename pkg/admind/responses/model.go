@@ -0,0 +1,20 @@
+package responses
+
+import "github.com/freetocompute/kebe/pkg/models"
+
+type Model struct {
+	BrandId string
+	Model   string
+	// Revision is the latest signed revision, zero if the model has not been signed
+	Revision   int
+	Definition models.ModelDefinition
+}
+
+type ValidationSet struct {
+	AccountId string
+	Name      string
+	Sequence  int
+	// Revision is the latest signed revision of the sequence, zero if it has not been signed
+	Revision   int
+	Definition models.ValidationSetDefinition
+}
@@ -0,0 +1,15 @@
+package responses
+
+type OrganizationMember struct {
+	AccountId string
+	Username  string
+	Email     string
+	Role      string
+}
+
+type Organization struct {
+	Name        string
+	AccountId   string
+	DisplayName string
+	Members     []OrganizationMember
+}
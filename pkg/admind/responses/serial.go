@@ -0,0 +1,12 @@
+package responses
+
+import "time"
+
+type Serial struct {
+	BrandId          string
+	Model            string
+	Serial           string
+	DeviceKeySHA3384 string
+	IssuedAt         time.Time
+	RevokedAt        *time.Time
+}
@@ -0,0 +1,21 @@
+package responses
+
+import "time"
+
+type ReservedSnapName struct {
+	Name string
+	// AccountId is the account that can register the name, empty if nobody can
+	AccountId string
+	Reason    string
+	CreatedAt time.Time
+}
+
+type SnapNameDispute struct {
+	DisputeId  uint
+	Name       string
+	AccountId  string
+	Comment    string
+	Status     string
+	CreatedAt  time.Time
+	ResolvedAt *time.Time
+}
@@ -0,0 +1,17 @@
+package responses
+
+import "time"
+
+type Token struct {
+	TokenId   string
+	AccountId string
+	Email     string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+type RevokedTokens struct {
+	AccountId string
+	Revoked   int64
+}
@@ -1,28 +1,61 @@
 package admind
 
 import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/freetocompute/kebe/pkg/repositories"
 
 	"github.com/freetocompute/kebe/config"
 	"github.com/freetocompute/kebe/config/configkey"
 	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/crypto"
 	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/login/identity"
 	"github.com/freetocompute/kebe/pkg/middleware"
 	"github.com/freetocompute/kebe/pkg/models"
+	asserts2 "github.com/freetocompute/kebe/pkg/store/asserts"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/asserts"
 	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
 type Server struct {
-	db     *gorm.DB
-	engine *gin.Engine
-	snaps  *repositories.SnapsRepository
+	db         *gorm.DB
+	engine     *gin.Engine
+	snaps      *repositories.SnapsRepository
+	accounts   *repositories.AccountRepository
+	serials    *repositories.SerialsRepository
+	models     *repositories.BrandModelsRepository
+	validation *repositories.ValidationSetsRepository
+	assertions *repositories.AssertionsRepository
+	// adminActions records the changes made by admins
+	adminActions *repositories.AdminActionsRepository
+	tokens       *repositories.DischargeTokensRepository
+	totpDevices  *repositories.TOTPDevicesRepository
+	credentials  *repositories.LocalCredentialsRepository
+	// organizations own snaps on behalf of their members
+	organizations *repositories.OrganizationsRepository
+	snapNames     *repositories.SnapNamesRepository
+
+	signingBackend crypto.Backend
+	authenticator  AdminAuthenticator
+	// localAuthenticator is set when admins log in with their local credential rather than OIDC
+	localAuthenticator *LocalAuthenticator
+	// rootAuthorityId is the account devices trust, its keys can't be revoked
+	rootAuthorityId string
 }
 
 func (s *Server) Init() {
@@ -53,6 +86,36 @@ func (s *Server) Init() {
 	db, _ := database.CreateDatabase()
 	s.db = db
 	s.snaps = repositories.NewSnapsRepository(db)
+	s.accounts = repositories.NewAccountRepository(db)
+	s.serials = repositories.NewSerialsRepository(db)
+	s.models = repositories.NewBrandModelsRepository(db)
+	s.validation = repositories.NewValidationSetsRepository(db)
+	s.assertions = repositories.NewAssertionsRepository(db)
+	s.adminActions = repositories.NewAdminActionsRepository(db)
+	s.tokens = repositories.NewDischargeTokensRepository(db)
+	s.totpDevices = repositories.NewTOTPDevicesRepository(db)
+	s.credentials = repositories.NewLocalCredentialsRepository(db)
+	s.organizations = repositories.NewOrganizationsRepository(db)
+	s.snapNames = repositories.NewSnapNamesRepository(db)
+	s.signingBackend = crypto.MustGetBackend()
+	s.rootAuthorityId = config.MustGetString(configkey.RootAuthority)
+
+	switch backend := viper.GetString(configkey.AdminDAuthBackend); backend {
+	case AuthBackendOIDC:
+		authenticator, err := NewAuthenticator(context.Background(), config.MustGetString(configkey.OIDCProviderURL),
+			config.MustGetString(configkey.OIDCClientId), config.MustGetString(configkey.AdminDGroup))
+		if err != nil {
+			logrus.Error(err)
+			panic(err)
+		}
+		s.authenticator = authenticator
+	case AuthBackendLocal:
+		s.localAuthenticator = NewLocalAuthenticator(s.accounts, repositories.NewAdminSessionsRepository(db), s.credentials,
+			viper.GetDuration(configkey.AdminDSessionExpiry))
+		s.authenticator = s.localAuthenticator
+	default:
+		panic(fmt.Errorf("unknown admind auth backend: %q", backend))
+	}
 
 	s.SetupEndpoints(r)
 }
@@ -66,25 +129,298 @@ func (s *Server) addAccount(c *gin.Context) {
 	var addAccountReq requests.AddAccount
 	err := json.NewDecoder(c.Request.Body).Decode(&addAccountReq)
 	if err == nil {
+		validation := addAccountReq.Validation
+		if validation == "" {
+			validation = models.AccountValidationUnproven
+		} else if !models.IsValidAccountValidation(validation) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid validation level: " + validation})
+			return
+		}
 
-		// TODO:: add validation
 		account := models.Account{
 			AccountId:   addAccountReq.AcccountId,
 			DisplayName: addAccountReq.DisplayName,
 			Username:    addAccountReq.Username,
 			Email:       addAccountReq.Email,
+			Validation:  validation,
 		}
 
-		s.db.Save(&account)
+		if !s.checkAccount(c, &account) {
+			return
+		}
 
-		c.Status(http.StatusCreated)
-		return
+		err = s.accounts.SaveAccount(&account)
+		if err == nil {
+			logrus.Infof("Added account %s", account.AccountId)
+			c.JSON(http.StatusCreated, accountResponse(&account))
+			return
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// checkAccount validates the fields of an account that is going to be saved and that no other account uses the
+// unique ones, the request is aborted if it can't be saved
+func (s *Server) checkAccount(c *gin.Context, account *models.Account) bool {
+	if !asserts.IsValidAccountID(account.AccountId) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid account id: " + account.AccountId})
+		return false
+	}
+
+	if account.Username == "" || strings.ContainsAny(account.Username, " \t\r\n") {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid username: " + account.Username})
+		return false
+	}
+
+	if strings.TrimSpace(account.DisplayName) == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "a display name is required"})
+		return false
+	}
+
+	// organizations don't have to have an email, accounts that log in do
+	if account.Email != "" {
+		address, err := mail.ParseAddress(account.Email)
+		if err != nil || address.Address != account.Email {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid email: " + account.Email})
+			return false
+		}
+	}
+
+	others, err := s.accounts.GetConflictingAccounts(account)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return false
+	}
+
+	var conflicts []string
+	for _, other := range *others {
+		for field, used := range map[string]bool{
+			"account id": other.AccountId == account.AccountId,
+			"username":   other.Username == account.Username,
+			"email":      account.Email != "" && other.Email == account.Email,
+		} {
+			if used {
+				conflicts = append(conflicts, fmt.Sprintf("%s is used by %s", field, other.AccountId))
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": strings.Join(conflicts, ", ")})
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) setAccountValidation(c *gin.Context) {
+	var setValidationReq requests.SetAccountValidation
+	err := json.NewDecoder(c.Request.Body).Decode(&setValidationReq)
+	if err == nil {
+		if !models.IsValidAccountValidation(setValidationReq.Validation) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid validation level: " + setValidationReq.Validation})
+			return
+		}
+
+		account, err2 := s.accounts.SetAccountValidation(setValidationReq.AccountId, setValidationReq.Validation)
+		if err2 == nil && account == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + setValidationReq.AccountId})
+			return
+		} else if err2 == nil {
+			c.Status(http.StatusOK)
+			return
+		}
+
+		err = err2
 	}
 
 	logrus.Error(err)
 	c.AbortWithStatus(http.StatusInternalServerError)
 }
 
+// resetTwoFactor removes the account's 2-factor device, for when the user has lost it. Their discharges stay valid,
+// revoke them too if the account may be compromised.
+func (s *Server) resetTwoFactor(c *gin.Context) {
+	var resetTwoFactorReq requests.ResetTwoFactor
+	err := json.NewDecoder(c.Request.Body).Decode(&resetTwoFactorReq)
+	if err == nil {
+		account, err2 := s.accounts.GetAccountById(resetTwoFactorReq.AccountId, false)
+		if err2 == nil && account == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + resetTwoFactorReq.AccountId})
+			return
+		} else if err2 == nil {
+			err2 = s.totpDevices.DeleteDevice(account.ID)
+			if err2 == nil {
+				logrus.Infof("Reset 2-factor authentication of %s", account.AccountId)
+				c.Status(http.StatusOK)
+				return
+			}
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// setAccountPassword sets the password the account logs in with when the login service uses the local identity
+// backend
+func (s *Server) setAccountPassword(c *gin.Context) {
+	var setPasswordReq requests.SetAccountPassword
+	err := json.NewDecoder(c.Request.Body).Decode(&setPasswordReq)
+	if err == nil {
+		passwordHash, err2 := identity.HashPassword(setPasswordReq.Password)
+		if errors.Is(err2, identity.ErrPasswordTooShort) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("the password must be at least %d characters", identity.MinPasswordLength)})
+			return
+		}
+
+		account, err2 := s.accounts.GetAccountById(setPasswordReq.AccountId, false)
+		if err2 == nil && account == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + setPasswordReq.AccountId})
+			return
+		} else if err2 == nil {
+			err2 = s.credentials.SetPasswordHash(account.ID, passwordHash)
+			if err2 == nil {
+				logrus.Infof("Set the password of %s", account.AccountId)
+				c.Status(http.StatusOK)
+				return
+			}
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// addAccountKey puts a key for the account in the signing backend so Kebe can sign assertions, like models and
+// serials, on behalf of a brand
+func (s *Server) addAccountKey(c *gin.Context) {
+	var addAccountKeyReq requests.AddAccountKey
+	err := json.NewDecoder(c.Request.Body).Decode(&addAccountKeyReq)
+	if err == nil {
+		account, err2 := s.accounts.GetAccountById(addAccountKeyReq.AccountId, false)
+		if err2 != nil || account == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + addAccountKeyReq.AccountId})
+			return
+		}
+
+		var publicKey *rsa.PublicKey
+		if addAccountKeyReq.PrivateKey == "" {
+			publicKey, err2 = s.signingBackend.GenerateKey(addAccountKeyReq.Name, 4096)
+		} else {
+			rsaKey, err3 := crypto.ParseRSAPrivateKeyFromPEM([]byte(addAccountKeyReq.PrivateKey))
+			if err3 != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err3.Error()})
+				return
+			}
+
+			publicKey, err2 = s.signingBackend.ImportKey(addAccountKeyReq.Name, rsaKey)
+		}
+
+		if err2 == nil {
+			assertsPublicKey := asserts.RSAPublicKey(publicKey)
+			encodedPublicKey, err3 := asserts.EncodePublicKey(assertsPublicKey)
+			if err3 == nil {
+				key := models.Key{
+					Name:             addAccountKeyReq.Name,
+					SHA3384:          assertsPublicKey.ID(),
+					EncodedPublicKey: base64.StdEncoding.EncodeToString(encodedPublicKey),
+					AccountID:        account.ID,
+					Since:            time.Now().UTC(),
+				}
+
+				// devices send models signed with the key, the account-key has to be in the assertion database for
+				// their sessions to be accepted
+				err2 = s.persistAccountKey(account.AccountId, &key)
+				if err2 != nil {
+					logrus.Error(err2)
+					c.AbortWithStatus(http.StatusInternalServerError)
+					return
+				}
+
+				db := s.db.Save(&key)
+				if db.Error == nil {
+					c.JSON(http.StatusCreated, &responses.AccountKey{Name: key.Name, SHA3384: key.SHA3384, Since: key.Since})
+					return
+				}
+
+				err2 = db.Error
+			} else {
+				err2 = err3
+			}
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// persistAccountKey signs the account-key of the key with the root authority's key and stores it
+func (s *Server) persistAccountKey(accountId string, key *models.Key) error {
+	signingDB, err := s.getSigningDB(s.rootAuthorityId, "")
+	if err != nil {
+		return err
+	}
+
+	_, err = asserts2.MakeAccountKeyAssertion(s.rootAuthorityId, accountId, key, signingDB.KeyID, signingDB, s.assertions)
+	return err
+}
+
+// revokeAccountKey stops the key from being trusted, the store serves its account-key with an until of now. Keys
+// of the root authority can't be revoked, devices trust them, they are retired with rotate-key instead.
+func (s *Server) revokeAccountKey(c *gin.Context) {
+	var revokeAccountKeyReq requests.RevokeAccountKey
+	err := json.NewDecoder(c.Request.Body).Decode(&revokeAccountKeyReq)
+	if err != nil || revokeAccountKeyReq.SHA3384 == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "the sha3-384 of the key to revoke is required"})
+		return
+	}
+
+	key, err := s.accounts.GetKeyBySHA3384(revokeAccountKeyReq.SHA3384)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if key == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "key not found: " + revokeAccountKeyReq.SHA3384})
+		return
+	}
+
+	if key.Account.AccountId == s.rootAuthorityId {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "keys of the root authority can't be revoked, use rotate-key to retire them"})
+		return
+	}
+
+	key, err = s.accounts.RevokeKey(key.SHA3384)
+	if err != nil || key == nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	err = s.persistAccountKey(key.Account.AccountId, key)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	logrus.Infof("Revoked key %s of %s", key.SHA3384, key.Account.AccountId)
+	c.JSON(http.StatusOK, &responses.AccountKey{Name: key.Name, SHA3384: key.SHA3384, Since: key.Since, Until: key.Until})
+}
+
 func (s *Server) addTrack(c *gin.Context) {
 	var addTrackReq requests.AddTrack
 	err := json.NewDecoder(c.Request.Body).Decode(&addTrackReq)
@@ -111,3 +447,50 @@ func (s *Server) addTrack(c *gin.Context) {
 	logrus.Error(err)
 	c.AbortWithStatus(http.StatusInternalServerError)
 }
+
+func (s *Server) getSerials(c *gin.Context) {
+	serials, err := s.serials.GetSerials(c.Query("brand"), c.Query("model"))
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	serialResponses := []responses.Serial{}
+	for _, serial := range serials {
+		serialResponses = append(serialResponses, responses.Serial{
+			BrandId:          serial.BrandID,
+			Model:            serial.ModelName,
+			Serial:           serial.SerialUUID,
+			DeviceKeySHA3384: serial.DeviceKeySHA3384,
+			IssuedAt:         serial.CreatedAt,
+			RevokedAt:        serial.RevokedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, &serialResponses)
+}
+
+func (s *Server) revokeSerial(c *gin.Context) {
+	var revokeSerialReq requests.RevokeSerial
+	err := json.NewDecoder(c.Request.Body).Decode(&revokeSerialReq)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	serial, err := s.serials.RevokeSerial(revokeSerialReq.BrandId, revokeSerialReq.Model, revokeSerialReq.Serial)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if serial == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "serial not found: " + revokeSerialReq.BrandId + "/" + revokeSerialReq.Model + "/" + revokeSerialReq.Serial})
+		return
+	}
+
+	logrus.Infof("Revoked serial %s/%s/%s", serial.BrandID, serial.ModelName, serial.SerialUUID)
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,252 @@
+package admind
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/snap/naming"
+)
+
+func reservedSnapNameResponse(reservedName *models.ReservedSnapName) responses.ReservedSnapName {
+	reservedNameResp := responses.ReservedSnapName{
+		Name:      reservedName.Name,
+		Reason:    reservedName.Reason,
+		CreatedAt: reservedName.CreatedAt,
+	}
+
+	if reservedName.Account != nil {
+		reservedNameResp.AccountId = reservedName.Account.AccountId
+	}
+
+	return reservedNameResp
+}
+
+func snapNameDisputeResponse(dispute *models.SnapNameDispute) responses.SnapNameDispute {
+	return responses.SnapNameDispute{
+		DisputeId:  dispute.ID,
+		Name:       dispute.Name,
+		AccountId:  dispute.Account.AccountId,
+		Comment:    dispute.Comment,
+		Status:     dispute.Status,
+		CreatedAt:  dispute.CreatedAt,
+		ResolvedAt: dispute.ResolvedAt,
+	}
+}
+
+func (s *Server) getReservedSnapNames(c *gin.Context) {
+	reservedNames, err := s.snapNames.GetReservedNames()
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	reservedNameResponses := []responses.ReservedSnapName{}
+	for i := range *reservedNames {
+		reservedNameResponses = append(reservedNameResponses, reservedSnapNameResponse(&(*reservedNames)[i]))
+	}
+
+	c.JSON(http.StatusOK, &reservedNameResponses)
+}
+
+// reserveSnapName stops a name from being registered first-come-first-served, a snap already registered with the
+// name keeps it
+func (s *Server) reserveSnapName(c *gin.Context) {
+	var reserveReq requests.ReserveSnapName
+	err := json.NewDecoder(c.Request.Body).Decode(&reserveReq)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if naming.ValidateSnap(reserveReq.Name) != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid snap name: " + reserveReq.Name})
+		return
+	}
+
+	var accountId *uint
+	var account *models.Account
+	if reserveReq.AccountId != "" {
+		var ok bool
+		account, ok = s.getAccountById(c, reserveReq.AccountId, false)
+		if !ok {
+			return
+		}
+
+		accountId = &account.ID
+	}
+
+	reservedName, err := s.snapNames.ReserveName(reserveReq.Name, accountId, reserveReq.Reason)
+	if err == nil {
+		reservedName.Account = account
+		logrus.Infof("Reserved the snap name %s", reservedName.Name)
+		c.JSON(http.StatusOK, reservedSnapNameResponse(reservedName))
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+func (s *Server) unreserveSnapName(c *gin.Context) {
+	var unreserveReq requests.UnreserveSnapName
+	err := json.NewDecoder(c.Request.Body).Decode(&unreserveReq)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	removed, err := s.snapNames.UnreserveName(unreserveReq.Name)
+	if err == nil && !removed {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "snap name is not reserved: " + unreserveReq.Name})
+		return
+	} else if err == nil {
+		logrus.Infof("Unreserved the snap name %s", unreserveReq.Name)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// getSnapNameDisputes lists the disputes with the status given in the query, all of them if there is none
+func (s *Server) getSnapNameDisputes(c *gin.Context) {
+	disputes, err := s.snapNames.GetDisputes(c.Query("status"))
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	disputeResponses := []responses.SnapNameDispute{}
+	for i := range *disputes {
+		disputeResponses = append(disputeResponses, snapNameDisputeResponse(&(*disputes)[i]))
+	}
+
+	c.JSON(http.StatusOK, &disputeResponses)
+}
+
+// resolveSnapNameDispute closes an open dispute. Accepting it transfers the snap registered with the name to the
+// account that disputed it, or reserves the name for that account if it isn't registered, and rejects the other open
+// disputes of the name.
+func (s *Server) resolveSnapNameDispute(c *gin.Context) {
+	var resolveReq requests.ResolveSnapNameDispute
+	err := json.NewDecoder(c.Request.Body).Decode(&resolveReq)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	dispute, err := s.snapNames.GetDispute(resolveReq.DisputeId)
+	if err == nil && dispute == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "dispute not found: " + strconv.FormatUint(uint64(resolveReq.DisputeId), 10)})
+		return
+	} else if err == nil && dispute.Status != models.SnapNameDisputeOpen {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": "dispute is already " + dispute.Status})
+		return
+	} else if err == nil {
+		status := models.SnapNameDisputeRejected
+		if resolveReq.Accept {
+			status = models.SnapNameDisputeAccepted
+		}
+
+		err = s.snapNames.ResolveDispute(dispute, status)
+		if err == nil {
+			logrus.Infof("Dispute %d of %s for %s was %s", dispute.ID, dispute.Account.AccountId, dispute.Name, status)
+			c.JSON(http.StatusOK, snapNameDisputeResponse(dispute))
+			return
+		} else if errors.Is(err, repositories.ErrDisputeNotOpen) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+func (s *Server) transferSnapToAccount(c *gin.Context) {
+	var transferReq requests.TransferSnapToAccount
+	err := json.NewDecoder(c.Request.Body).Decode(&transferReq)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	account, ok := s.getAccountById(c, transferReq.AccountId, false)
+	if !ok {
+		return
+	}
+
+	snapEntry, ok := s.getSnapByName(c, transferReq.SnapName, false)
+	if !ok {
+		return
+	}
+
+	err = s.snaps.TransferSnap(snapEntry.ID, account.ID)
+	if err == nil {
+		logrus.Infof("Transferred %s to %s", snapEntry.Name, account.AccountId)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// unregisterSnap frees the name of a snap that never had a revision uploaded
+func (s *Server) unregisterSnap(c *gin.Context) {
+	var unregisterReq requests.UnregisterSnap
+	err := json.NewDecoder(c.Request.Body).Decode(&unregisterReq)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	snapEntry, ok := s.getSnapByName(c, unregisterReq.SnapName, false)
+	if !ok {
+		return
+	}
+
+	err = s.snaps.UnregisterSnap(snapEntry.ID)
+	if err == nil {
+		logrus.Infof("Unregistered %s", snapEntry.Name)
+		c.Status(http.StatusOK)
+		return
+	} else if errors.Is(err, repositories.ErrSnapHasRevisions) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": snapEntry.Name + " has revisions and can't be unregistered"})
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// getSnapByName aborts the request if there is no snap with the name
+func (s *Server) getSnapByName(c *gin.Context, name string, preload bool) (*models.SnapEntry, bool) {
+	if name == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "a snap name is required"})
+		return nil, false
+	}
+
+	snapEntry, err := s.snaps.GetSnap(name, preload)
+	if err == nil && snapEntry == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "snap not found: " + name})
+		return nil, false
+	} else if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return snapEntry, true
+}
@@ -0,0 +1,117 @@
+package admind
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/auth"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func sshKeyResponse(sshKey *models.SSHKey) responses.SSHKey {
+	return responses.SSHKey{
+		PublicKey:   sshKey.PublicKeyString,
+		Fingerprint: sshKey.Fingerprint,
+		CreatedAt:   sshKey.CreatedAt,
+	}
+}
+
+func (s *Server) getSSHKeys(c *gin.Context) {
+	accountId := c.Query("account")
+	account, err := s.accounts.GetAccountById(accountId, false)
+	if err == nil && account == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + accountId})
+		return
+	} else if err == nil {
+		sshKeys, err2 := s.accounts.GetSSHKeys(account.ID)
+		if err2 == nil {
+			sshKeyResponses := []responses.SSHKey{}
+			for i := range *sshKeys {
+				sshKeyResponses = append(sshKeyResponses, sshKeyResponse(&(*sshKeys)[i]))
+			}
+
+			c.JSON(http.StatusOK, &sshKeyResponses)
+			return
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// addSSHKey adds a key to any account, the same key can't be added to two accounts
+func (s *Server) addSSHKey(c *gin.Context) {
+	var addSSHKeyReq requests.AddSSHKey
+	err := json.NewDecoder(c.Request.Body).Decode(&addSSHKeyReq)
+	if err == nil {
+		normalized, fingerprint, err2 := auth.ParseSSHPublicKey(addSSHKeyReq.PublicKey)
+		if errors.Is(err2, auth.ErrInvalidSSHKey) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err2.Error()})
+			return
+		}
+
+		account, err2 := s.accounts.GetAccountById(addSSHKeyReq.AccountId, false)
+		if err2 == nil && account == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + addSSHKeyReq.AccountId})
+			return
+		} else if err2 == nil {
+			existing, err3 := s.accounts.GetSSHKeyByFingerprint(fingerprint)
+			if err3 == nil && existing != nil {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": fingerprint + " already belongs to " + existing.Account.AccountId})
+				return
+			} else if err3 == nil {
+				sshKey, err4 := s.accounts.AddSSHKey(account.ID, normalized, fingerprint)
+				if err4 == nil {
+					logrus.Infof("Added ssh key %s to %s", fingerprint, account.AccountId)
+					c.JSON(http.StatusCreated, sshKeyResponse(sshKey))
+					return
+				}
+
+				err3 = err4
+			}
+
+			err2 = err3
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+func (s *Server) removeSSHKey(c *gin.Context) {
+	var removeSSHKeyReq requests.RemoveSSHKey
+	err := json.NewDecoder(c.Request.Body).Decode(&removeSSHKeyReq)
+	if err == nil {
+		account, err2 := s.accounts.GetAccountById(removeSSHKeyReq.AccountId, false)
+		if err2 == nil && account == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + removeSSHKeyReq.AccountId})
+			return
+		} else if err2 == nil {
+			deleted, err3 := s.accounts.DeleteSSHKey(account.ID, removeSSHKeyReq.Fingerprint)
+			if err3 == nil && !deleted {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "ssh key not found: " + removeSSHKeyReq.Fingerprint})
+				return
+			} else if err3 == nil {
+				logrus.Infof("Removed ssh key %s from %s", removeSSHKeyReq.Fingerprint, account.AccountId)
+				c.Status(http.StatusOK)
+				return
+			}
+
+			err2 = err3
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
@@ -0,0 +1,89 @@
+package admind
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func tokenResponse(token *models.DischargeToken) responses.Token {
+	return responses.Token{
+		TokenId:   token.TokenID,
+		AccountId: token.Account.AccountId,
+		Email:     token.Account.Email,
+		IssuedAt:  token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+		RevokedAt: token.RevokedAt,
+	}
+}
+
+// getTokens lists the discharges the login service has issued, to one account when the account query is set
+func (s *Server) getTokens(c *gin.Context) {
+	tokens, err := s.tokens.GetTokens(c.Query("account"))
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	tokenResponses := []responses.Token{}
+	for i := range *tokens {
+		tokenResponses = append(tokenResponses, tokenResponse(&(*tokens)[i]))
+	}
+
+	c.JSON(http.StatusOK, &tokenResponses)
+}
+
+// revokeToken stops a discharge from being accepted, the user has to log in again
+func (s *Server) revokeToken(c *gin.Context) {
+	var revokeTokenReq requests.RevokeToken
+	err := json.NewDecoder(c.Request.Body).Decode(&revokeTokenReq)
+	if err == nil {
+		token, err2 := s.tokens.RevokeToken(revokeTokenReq.TokenId)
+		if err2 == nil && token != nil {
+			logrus.Infof("Revoked token %s of %s", token.TokenID, token.Account.AccountId)
+			c.JSON(http.StatusOK, tokenResponse(token))
+			return
+		} else if err2 == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "token not found: " + revokeTokenReq.TokenId})
+			return
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// revokeAccountTokens revokes every discharge issued to the account, e.g. when its credentials were leaked
+func (s *Server) revokeAccountTokens(c *gin.Context) {
+	var revokeAccountTokensReq requests.RevokeAccountTokens
+	err := json.NewDecoder(c.Request.Body).Decode(&revokeAccountTokensReq)
+	if err == nil {
+		account, err2 := s.accounts.GetAccountById(revokeAccountTokensReq.AccountId, false)
+		if err2 == nil && account == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "account not found: " + revokeAccountTokensReq.AccountId})
+			return
+		} else if err2 == nil {
+			revoked, err3 := s.tokens.RevokeAccountTokens(account.AccountId)
+			if err3 == nil {
+				logrus.Infof("Revoked %d token(s) of %s", revoked, account.AccountId)
+				c.JSON(http.StatusOK, &responses.RevokedTokens{AccountId: account.AccountId, Revoked: revoked})
+				return
+			}
+
+			err2 = err3
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
@@ -14,7 +14,7 @@ type Token struct {
 
 type UserInfo struct {
 	Sub               string   `json:"sub"`
-	EmailVerified     string   `json:"email_verified"`
+	EmailVerified     bool     `json:"email_verified"`
 	Name              string   `json:"name"`
 	Groups            []string `json:"groups"`
 	PreferredUsername string   `json:"preferred_username"`
@@ -0,0 +1,146 @@
+package admind
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/freetocompute/kebe/pkg/admind/requests"
+	"github.com/freetocompute/kebe/pkg/admind/responses"
+	"github.com/freetocompute/kebe/pkg/assertions"
+	"github.com/freetocompute/kebe/pkg/crypto"
+	"github.com/freetocompute/kebe/pkg/models"
+	asserts2 "github.com/freetocompute/kebe/pkg/store/asserts"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/asserts"
+)
+
+// addValidationSet stores the definition as the next sequence of the validation set, snap ids that are left
+// out are filled in for snaps this store knows about
+func (s *Server) addValidationSet(c *gin.Context) {
+	var definition models.ValidationSetDefinition
+	err := json.NewDecoder(c.Request.Body).Decode(&definition)
+	if err == nil {
+		for i, snap := range definition.Snaps {
+			if snap.ID != "" {
+				continue
+			}
+
+			snapEntry, err2 := s.snaps.GetSnap(snap.Name, false)
+			if err2 != nil || snapEntry == nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "no snap id given and snap is unknown: " + snap.Name})
+				return
+			}
+
+			definition.Snaps[i].ID = snapEntry.SnapStoreID
+		}
+
+		validationSet, err2 := s.validation.AddValidationSet(&definition)
+		if err2 == nil && validationSet != nil {
+			c.JSON(http.StatusCreated, &responses.ValidationSet{
+				AccountId:  validationSet.AccountID,
+				Name:       validationSet.Name,
+				Sequence:   validationSet.Sequence,
+				Definition: definition,
+			})
+			return
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+}
+
+func (s *Server) signValidationSet(c *gin.Context) {
+	var signReq requests.SignValidationSet
+	err := json.NewDecoder(c.Request.Body).Decode(&signReq)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	validationSet, err := s.findValidationSet(signReq.AccountId, signReq.Name, signReq.Sequence)
+	if err == nil && validationSet == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "validation set not found: " + signReq.AccountId + "/" + signReq.Name})
+		return
+	}
+
+	if err == nil {
+		var headers map[string]interface{}
+		headers, err = validationSet.Headers()
+		if err == nil {
+			var signingDB *crypto.SigningDB
+			signingDB, err = s.getSigningDB(signReq.AccountId, signReq.KeyId)
+			if err == nil {
+				var validationSetAssertion asserts.Assertion
+				validationSetAssertion, err = asserts2.SignAndPersist(asserts.ValidationSetType, headers, nil, signingDB.KeyID, signingDB, s.assertions)
+				if err == nil {
+					c.Data(http.StatusOK, asserts.MediaType, asserts.Encode(validationSetAssertion))
+					return
+				}
+			}
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+}
+
+func (s *Server) getValidationSets(c *gin.Context) {
+	validationSets, err := s.validation.GetValidationSets(c.Query("account"), c.Query("name"))
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	validationSetResponses := []responses.ValidationSet{}
+	for _, validationSet := range validationSets {
+		definition, err2 := validationSet.GetDefinition()
+		if err2 != nil {
+			logrus.Error(err2)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		validationSetResponse := responses.ValidationSet{
+			AccountId:  validationSet.AccountID,
+			Name:       validationSet.Name,
+			Sequence:   validationSet.Sequence,
+			Definition: *definition,
+		}
+
+		primaryKey := assertions.JoinKey([]string{"16", validationSet.AccountID, validationSet.Name, strconv.Itoa(validationSet.Sequence)})
+		latest, err2 := s.assertions.GetLatestAssertion(asserts.ValidationSetType.Name, primaryKey)
+		if err2 == nil && latest != nil {
+			validationSetResponse.Revision = latest.Revision
+		}
+
+		validationSetResponses = append(validationSetResponses, validationSetResponse)
+	}
+
+	c.JSON(http.StatusOK, &validationSetResponses)
+}
+
+// findValidationSet returns the sequence of the validation set, or the latest one if sequence is zero
+func (s *Server) findValidationSet(accountId string, name string, sequence int) (*models.ValidationSet, error) {
+	if accountId == "" || name == "" {
+		return nil, errors.New("an account id and name are needed to find a validation set")
+	}
+
+	if sequence > 0 {
+		return s.validation.GetValidationSet(accountId, name, sequence)
+	}
+
+	validationSets, err := s.validation.GetValidationSets(accountId, name)
+	if err != nil || len(validationSets) == 0 {
+		return nil, err
+	}
+
+	return &validationSets[0], nil
+}
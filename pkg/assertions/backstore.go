@@ -0,0 +1,243 @@
+package assertions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/jackc/pgconn"
+	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/asserts"
+	"gorm.io/gorm"
+)
+
+// uniqueViolation is the Postgres error code for a unique index violation
+const uniqueViolation = "23505"
+
+// PostgresBackstore is an asserts.Backstore that keeps assertions in the database so they survive
+// restarts and are shared between every store instance. It uses the same table the store persists
+// the assertions it signs in, so those are visible to the assertion database too.
+type PostgresBackstore struct {
+	db *gorm.DB
+}
+
+func NewPostgresBackstore(db *gorm.DB) *PostgresBackstore {
+	return &PostgresBackstore{db: db}
+}
+
+// NewRecord returns the row an assertion is stored as, the content digest is left for the caller to set
+func NewRecord(assert asserts.Assertion) (*models.Assertion, error) {
+	headers, err := json.Marshal(assert.Headers())
+	if err != nil {
+		return nil, err
+	}
+
+	assertType := assert.Type()
+	record := models.Assertion{
+		Type:       assertType.Name,
+		PrimaryKey: JoinKey(assert.Ref().PrimaryKey),
+		Revision:   assert.Revision(),
+		Format:     assert.Format(),
+		Headers:    string(headers),
+		Encoded:    string(asserts.Encode(assert)),
+	}
+
+	if seqMember, ok := assert.(asserts.SequenceMember); ok && assertType.SequenceForming() {
+		pk := assert.Ref().PrimaryKey
+		record.SequenceKey = JoinKey(pk[:len(pk)-1])
+		record.Sequence = seqMember.Sequence()
+	}
+
+	return &record, nil
+}
+
+func (pbs *PostgresBackstore) Put(assertType *asserts.AssertionType, assert asserts.Assertion) error {
+	record, err := NewRecord(assert)
+	if err != nil {
+		return err
+	}
+
+	return pbs.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Assertion
+		db := tx.Where(&models.Assertion{Type: assertType.Name, PrimaryKey: record.PrimaryKey}).Order("revision desc").Limit(1).Find(&current)
+		if db.Error != nil {
+			return db.Error
+		}
+
+		if db.RowsAffected > 0 && current.Revision >= assert.Revision() {
+			return &asserts.RevisionError{Current: current.Revision, Used: assert.Revision()}
+		}
+
+		db = tx.Create(record)
+		if db.Error != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(db.Error, &pgErr) && pgErr.Code == uniqueViolation {
+				// another instance put the same revision concurrently
+				return &asserts.RevisionError{Current: assert.Revision(), Used: assert.Revision()}
+			}
+
+			logrus.Error(db.Error)
+			return db.Error
+		}
+
+		return nil
+	})
+}
+
+func (pbs *PostgresBackstore) Get(assertType *asserts.AssertionType, key []string, maxFormat int) (asserts.Assertion, error) {
+	var records []models.Assertion
+	db := pbs.db.Where("type = ? AND primary_key = ? AND format <= ?", assertType.Name, JoinKey(key), maxFormat).Order("revision desc").Limit(1).Find(&records)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	if len(records) == 0 {
+		return nil, &asserts.NotFoundError{Type: assertType}
+	}
+
+	return decodeRecord(assertType, &records[0])
+}
+
+// Search finds the latest revision of every assertion of the type whose headers include the ones given, the
+// headers are matched by Postgres so only the matching assertions are loaded
+func (pbs *PostgresBackstore) Search(assertType *asserts.AssertionType, headers map[string]string, foundCb func(asserts.Assertion), maxFormat int) error {
+	latest := pbs.db.Model(&models.Assertion{}).Select("DISTINCT ON (primary_key) *").
+		Where("type = ? AND format <= ?", assertType.Name, maxFormat).Order("primary_key, revision desc")
+
+	// when the whole primary key is known the search can be narrowed to it
+	var keyValues []string
+	for _, k := range assertType.PrimaryKey {
+		if headers[k] == "" {
+			keyValues = nil
+			break
+		}
+		keyValues = append(keyValues, headers[k])
+	}
+	if keyValues != nil {
+		latest = latest.Where("primary_key = ?", JoinKey(keyValues))
+	}
+
+	query := pbs.db.Table("(?) AS latest", latest)
+	if len(headers) > 0 {
+		expected, err := json.Marshal(headers)
+		if err != nil {
+			return err
+		}
+
+		query = query.Where("headers @> ?", string(expected))
+	}
+
+	var records []models.Assertion
+	db := query.Find(&records)
+	if db.Error != nil {
+		return db.Error
+	}
+
+	for i := range records {
+		a, err := decodeRecord(assertType, &records[i])
+		if err != nil {
+			return err
+		}
+
+		foundCb(a)
+	}
+
+	return nil
+}
+
+func (pbs *PostgresBackstore) SequenceMemberAfter(assertType *asserts.AssertionType, sequenceKey []string, after, maxFormat int) (asserts.SequenceMember, error) {
+	if !assertType.SequenceForming() {
+		panic(fmt.Sprintf("internal error: SequenceMemberAfter on non sequence-forming assertion type %s", assertType.Name))
+	}
+	if len(sequenceKey) != len(assertType.PrimaryKey)-1 {
+		return nil, errors.New("internal error: SequenceMemberAfter's sequence key argument length must be exactly 1 less than the assertion type primary key")
+	}
+
+	query := pbs.db.Where("type = ? AND sequence_key = ? AND format <= ?", assertType.Name, JoinKey(sequenceKey), maxFormat)
+	if after == -1 {
+		query = query.Order("sequence desc, revision desc")
+	} else {
+		query = query.Where("sequence > ?", after).Order("sequence asc, revision desc")
+	}
+
+	var records []models.Assertion
+	db := query.Limit(1).Find(&records)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	if len(records) == 0 {
+		return nil, &asserts.NotFoundError{Type: assertType}
+	}
+
+	a, err := decodeRecord(assertType, &records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return a.(asserts.SequenceMember), nil
+}
+
+// Backfill sets the columns the backstore needs on assertions stored before the store persisted them, they only
+// had their encoded form
+func (pbs *PostgresBackstore) Backfill() error {
+	var records []models.Assertion
+	db := pbs.db.Where("headers IS NULL").Find(&records)
+	if db.Error != nil {
+		return db.Error
+	}
+
+	for i := range records {
+		a, err := asserts.Decode([]byte(records[i].Encoded))
+		if err != nil {
+			return fmt.Errorf("broken assertion storage, cannot decode assertion %d: %v", records[i].ID, err)
+		}
+
+		filled, err := NewRecord(a)
+		if err != nil {
+			return err
+		}
+
+		db = pbs.db.Model(&records[i]).Updates(map[string]interface{}{
+			"format":       filled.Format,
+			"sequence_key": filled.SequenceKey,
+			"sequence":     filled.Sequence,
+			"headers":      filled.Headers,
+		})
+		if db.Error != nil {
+			return db.Error
+		}
+	}
+
+	if len(records) > 0 {
+		logrus.Infof("Backfilled %d assertions for the assertion database", len(records))
+	}
+
+	return nil
+}
+
+func decodeRecord(assertType *asserts.AssertionType, record *models.Assertion) (asserts.Assertion, error) {
+	a, err := asserts.Decode([]byte(record.Encoded))
+	if err != nil {
+		return nil, fmt.Errorf("broken assertion storage, cannot decode assertion: %v", err)
+	}
+
+	if a.Type() != assertType {
+		return nil, fmt.Errorf("assertion that is not of type %q stored as one", assertType.Name)
+	}
+
+	return a, nil
+}
+
+// JoinKey joins primary key values with "/", the values are escaped so keys with a "/" in a value can't collide
+func JoinKey(key []string) string {
+	escaped := make([]string, len(key))
+	for i, k := range key {
+		escaped[i] = url.QueryEscape(k)
+	}
+
+	return strings.Join(escaped, "/")
+}
@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	macaroonv2 "gopkg.in/macaroon.v2"
+)
+
+// Permissions that can be granted by the macaroons the dashboard issues, they are the ones snapcraft asks for
+const (
+	PermissionPackageAccess    = "package_access"
+	PermissionPackageManage    = "package_manage"
+	PermissionPackageMetrics   = "package_metrics"
+	PermissionPackagePush      = "package_push"
+	PermissionPackageRegister  = "package_register"
+	PermissionPackageRelease   = "package_release"
+	PermissionPackageUpdate    = "package_update"
+	PermissionModifyAccountKey = "modify_account_key"
+)
+
+var Permissions = []string{
+	PermissionPackageAccess,
+	PermissionPackageManage,
+	PermissionPackageMetrics,
+	PermissionPackagePush,
+	PermissionPackageRegister,
+	PermissionPackageRelease,
+	PermissionPackageUpdate,
+	PermissionModifyAccountKey,
+}
+
+const (
+	emailCaveatPrefix       = "email="
+	permissionsCaveatPrefix = "permissions="
+	snapIdsCaveatPrefix     = "snap-ids="
+	channelsCaveatPrefix    = "channels="
+	expiresCaveatPrefix     = "expires="
+	storeIdCaveatPrefix     = "store-id="
+	tokenIdCaveatPrefix     = "token-id="
+	timeBeforeCaveatPrefix  = "time-before="
+)
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ACL is what a root macaroon and its discharge grant. A nil SnapIDs or Channels means any snap or channel.
+type ACL struct {
+	Email string
+	// TokenID is the id of the discharge, it's used to revoke it
+	TokenID     string
+	Permissions []string
+	SnapIDs     []string
+	Channels    []string
+	Expires     *time.Time
+	StoreID     string
+}
+
+func IsValidPermission(permission string) bool {
+	return contains(Permissions, permission)
+}
+
+// Caveats returns the first party caveats that restrict a root macaroon to the ACL
+func (a *ACL) Caveats() []string {
+	caveats := []string{
+		storeIdCaveatPrefix + a.StoreID,
+		permissionsCaveatPrefix + strings.Join(a.Permissions, ","),
+	}
+
+	if a.SnapIDs != nil {
+		caveats = append(caveats, snapIdsCaveatPrefix+strings.Join(a.SnapIDs, ","))
+	}
+
+	if a.Channels != nil {
+		caveats = append(caveats, channelsCaveatPrefix+strings.Join(a.Channels, ","))
+	}
+
+	if a.Expires != nil {
+		caveats = append(caveats, expiresCaveatPrefix+a.Expires.UTC().Format(time.RFC3339))
+	}
+
+	return caveats
+}
+
+// DischargeCaveats returns the first party caveats of a discharge macaroon issued to the email, it can't be used
+// after expires
+func DischargeCaveats(email string, tokenId string, expires time.Time) []string {
+	return []string{
+		emailCaveatPrefix + email,
+		tokenIdCaveatPrefix + tokenId,
+		timeBeforeCaveatPrefix + expires.UTC().Format(time.RFC3339),
+	}
+}
+
+func (a *ACL) HasPermission(permission string) bool {
+	return contains(a.Permissions, permission)
+}
+
+func (a *ACL) AllowsSnap(snapId string) bool {
+	return a.SnapIDs == nil || contains(a.SnapIDs, snapId)
+}
+
+// AllowsChannels checks every channel is allowed, a channel without a track is on the latest track so "stable"
+// and "latest/stable" are the same channel
+func (a *ACL) AllowsChannels(channels []string) bool {
+	if a.Channels == nil {
+		return true
+	}
+
+	allowed := make([]string, 0, len(a.Channels))
+	for _, channel := range a.Channels {
+		allowed = append(allowed, normalizeChannel(channel))
+	}
+
+	for _, channel := range channels {
+		if !contains(allowed, normalizeChannel(channel)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// VerifyMacaroons checks the root macaroon was issued with rootKey and is discharged, every caveat has to be
+// satisfied. The ACL the caveats grant is returned, a caveat repeated by attenuation can only narrow it.
+func VerifyMacaroons(rootKey string, storeId string, root *macaroonv2.Macaroon, discharge *macaroonv2.Macaroon) (*ACL, error) {
+	if root == nil || discharge == nil {
+		return nil, fmt.Errorf("%w: root and discharge macaroons are required", ErrUnauthorized)
+	}
+
+	return verify(rootKey, storeId, root, []*macaroonv2.Macaroon{discharge})
+}
+
+// VerifyStoreMacaroon checks a macaroon the dashboard issued in exchange for a discharged root macaroon, it
+// carries the caveats of both so it needs no discharge
+func VerifyStoreMacaroon(rootKey string, storeId string, m *macaroonv2.Macaroon) (*ACL, error) {
+	if m == nil {
+		return nil, fmt.Errorf("%w: a macaroon is required", ErrUnauthorized)
+	}
+
+	return verify(rootKey, storeId, m, nil)
+}
+
+func verify(rootKey string, storeId string, root *macaroonv2.Macaroon, discharges []*macaroonv2.Macaroon) (*ACL, error) {
+	acl := ACL{StoreID: storeId}
+	hasPermissions := false
+	err := root.Verify([]byte(rootKey), func(caveat string) error {
+		switch {
+		case strings.HasPrefix(caveat, emailCaveatPrefix):
+			email := strings.TrimPrefix(caveat, emailCaveatPrefix)
+			if email == "" || (acl.Email != "" && acl.Email != email) {
+				return errors.New("email caveat malformed")
+			}
+
+			acl.Email = email
+		case strings.HasPrefix(caveat, permissionsCaveatPrefix):
+			permissions := splitCaveatList(strings.TrimPrefix(caveat, permissionsCaveatPrefix))
+			if hasPermissions {
+				permissions = intersect(acl.Permissions, permissions)
+			}
+
+			acl.Permissions = permissions
+			hasPermissions = true
+		case strings.HasPrefix(caveat, snapIdsCaveatPrefix):
+			snapIds := splitCaveatList(strings.TrimPrefix(caveat, snapIdsCaveatPrefix))
+			if acl.SnapIDs != nil {
+				snapIds = intersect(acl.SnapIDs, snapIds)
+			}
+
+			acl.SnapIDs = snapIds
+		case strings.HasPrefix(caveat, channelsCaveatPrefix):
+			channels := splitCaveatList(strings.TrimPrefix(caveat, channelsCaveatPrefix))
+			if acl.Channels != nil {
+				channels = intersect(acl.Channels, channels)
+			}
+
+			acl.Channels = channels
+		case strings.HasPrefix(caveat, expiresCaveatPrefix):
+			expires, err2 := time.Parse(time.RFC3339, strings.TrimPrefix(caveat, expiresCaveatPrefix))
+			if err2 != nil {
+				return err2
+			}
+
+			if time.Now().After(expires) {
+				return errors.New("macaroon has expired")
+			}
+
+			if acl.Expires == nil || expires.Before(*acl.Expires) {
+				acl.Expires = &expires
+			}
+		case strings.HasPrefix(caveat, tokenIdCaveatPrefix):
+			tokenId := strings.TrimPrefix(caveat, tokenIdCaveatPrefix)
+			if tokenId == "" || (acl.TokenID != "" && acl.TokenID != tokenId) {
+				return errors.New("token-id caveat malformed")
+			}
+
+			acl.TokenID = tokenId
+		case strings.HasPrefix(caveat, timeBeforeCaveatPrefix):
+			timeBefore, err2 := time.Parse(time.RFC3339, strings.TrimPrefix(caveat, timeBeforeCaveatPrefix))
+			if err2 != nil {
+				return err2
+			}
+
+			if !time.Now().Before(timeBefore) {
+				return errors.New("discharge has expired")
+			}
+		case strings.HasPrefix(caveat, storeIdCaveatPrefix):
+			if strings.TrimPrefix(caveat, storeIdCaveatPrefix) != storeId {
+				return errors.New("macaroon is for another store")
+			}
+		default:
+			return fmt.Errorf("unknown caveat %q", caveat)
+		}
+
+		return nil
+	}, discharges)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnauthorized, err.Error())
+	}
+
+	if acl.Email == "" {
+		return nil, fmt.Errorf("%w: the discharge has no email", ErrUnauthorized)
+	}
+
+	// discharges issued before tokens were recorded can't be revoked, they have to be replaced by logging in again
+	if acl.TokenID == "" {
+		return nil, fmt.Errorf("%w: the discharge has no token id", ErrUnauthorized)
+	}
+
+	if acl.Permissions == nil {
+		acl.Permissions = []string{}
+	}
+
+	return &acl, nil
+}
+
+func splitCaveatList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func intersect(a []string, b []string) []string {
+	items := []string{}
+	for _, item := range b {
+		if contains(a, item) {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeChannel(channel string) string {
+	if !strings.Contains(channel, "/") {
+		return "latest/" + channel
+	}
+
+	return channel
+}
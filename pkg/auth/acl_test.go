@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	macaroonv2 "gopkg.in/macaroon.v2"
+)
+
+const (
+	testRootKey      = "test-root-key"
+	testDischargeKey = "test-discharge-key"
+	testStoreId      = "test-store"
+	testCaveatId     = "test-caveat"
+)
+
+// newTestMacaroons returns a root macaroon with the caveats and its discharge with the discharge caveats, the
+// discharge is bound to the root
+func newTestMacaroons(t *testing.T, rootKey string, caveats []string, dischargeCaveats []string) (*macaroonv2.Macaroon, *macaroonv2.Macaroon) {
+	root := MustNewMacaroon([]byte(rootKey), []byte("root-id"), "dashboard", macaroonv2.V2)
+	for _, caveat := range caveats {
+		if err := root.AddFirstPartyCaveat([]byte(caveat)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := root.AddThirdPartyCaveat([]byte(testDischargeKey), []byte(testCaveatId), "login")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discharge := MustNewMacaroon([]byte(testDischargeKey), []byte(testCaveatId), "login", macaroonv2.V2)
+	for _, caveat := range dischargeCaveats {
+		if err = discharge.AddFirstPartyCaveat([]byte(caveat)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	discharge.Bind(root.Signature())
+	return root, discharge
+}
+
+func TestVerifyMacaroons(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	acl := ACL{
+		StoreID:     testStoreId,
+		Permissions: []string{PermissionPackageAccess, PermissionPackagePush},
+		SnapIDs:     []string{"snap-1"},
+		Channels:    []string{"edge"},
+	}
+	expiredAcl := acl
+	expiredAcl.Expires = &past
+
+	tests := []struct {
+		name             string
+		rootKey          string
+		caveats          []string
+		dischargeCaveats []string
+		expected         *ACL
+	}{
+		{
+			name:             "valid",
+			rootKey:          testRootKey,
+			caveats:          acl.Caveats(),
+			dischargeCaveats: DischargeCaveats("dev@example.com", "token-1", future),
+			expected: &ACL{
+				Email:       "dev@example.com",
+				TokenID:     "token-1",
+				StoreID:     testStoreId,
+				Permissions: []string{PermissionPackageAccess, PermissionPackagePush},
+				SnapIDs:     []string{"snap-1"},
+				Channels:    []string{"edge"},
+			},
+		},
+		{
+			name:             "attenuated",
+			rootKey:          testRootKey,
+			caveats:          append(acl.Caveats(), permissionsCaveatPrefix+PermissionPackagePush+","+PermissionPackageRelease),
+			dischargeCaveats: DischargeCaveats("dev@example.com", "token-1", future),
+			expected: &ACL{
+				Email:       "dev@example.com",
+				TokenID:     "token-1",
+				StoreID:     testStoreId,
+				Permissions: []string{PermissionPackagePush},
+				SnapIDs:     []string{"snap-1"},
+				Channels:    []string{"edge"},
+			},
+		},
+		{
+			name:             "expired",
+			rootKey:          testRootKey,
+			caveats:          expiredAcl.Caveats(),
+			dischargeCaveats: DischargeCaveats("dev@example.com", "token-1", future),
+		},
+		{
+			name:             "expired discharge",
+			rootKey:          testRootKey,
+			caveats:          acl.Caveats(),
+			dischargeCaveats: DischargeCaveats("dev@example.com", "token-1", past),
+		},
+		{
+			name:             "wrong signer",
+			rootKey:          "another-root-key",
+			caveats:          acl.Caveats(),
+			dischargeCaveats: DischargeCaveats("dev@example.com", "token-1", future),
+		},
+		{
+			name:             "another store",
+			rootKey:          testRootKey,
+			caveats:          (&ACL{StoreID: "another-store", Permissions: acl.Permissions}).Caveats(),
+			dischargeCaveats: DischargeCaveats("dev@example.com", "token-1", future),
+		},
+		{
+			name:             "unknown caveat",
+			rootKey:          testRootKey,
+			caveats:          append(acl.Caveats(), "account-id=someone"),
+			dischargeCaveats: DischargeCaveats("dev@example.com", "token-1", future),
+		},
+		{
+			name:             "malformed expiry",
+			rootKey:          testRootKey,
+			caveats:          append(acl.Caveats(), expiresCaveatPrefix+"tomorrow"),
+			dischargeCaveats: DischargeCaveats("dev@example.com", "token-1", future),
+		},
+		{
+			name:             "conflicting emails",
+			rootKey:          testRootKey,
+			caveats:          acl.Caveats(),
+			dischargeCaveats: append(DischargeCaveats("dev@example.com", "token-1", future), emailCaveatPrefix+"other@example.com"),
+		},
+		{
+			name:             "no email",
+			rootKey:          testRootKey,
+			caveats:          acl.Caveats(),
+			dischargeCaveats: []string{tokenIdCaveatPrefix + "token-1"},
+		},
+		{
+			name:             "no token id",
+			rootKey:          testRootKey,
+			caveats:          acl.Caveats(),
+			dischargeCaveats: []string{emailCaveatPrefix + "dev@example.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, discharge := newTestMacaroons(t, test.rootKey, test.caveats, test.dischargeCaveats)
+			verified, err := VerifyMacaroons(testRootKey, testStoreId, root, discharge)
+			if test.expected == nil {
+				if !errors.Is(err, ErrUnauthorized) {
+					t.Fatalf("expected ErrUnauthorized, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("VerifyMacaroons failed: %s", err)
+			}
+
+			if !reflect.DeepEqual(verified, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, verified)
+			}
+		})
+	}
+}
+
+func TestVerifyMacaroonsRequiresDischarge(t *testing.T) {
+	root, discharge := newTestMacaroons(t, testRootKey, (&ACL{StoreID: testStoreId}).Caveats(),
+		DischargeCaveats("dev@example.com", "token-1", time.Now().Add(time.Hour)))
+
+	_, err := VerifyMacaroons(testRootKey, testStoreId, root, nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized without a discharge, got %v", err)
+	}
+
+	// a discharge that isn't bound to the root macaroon doesn't discharge it
+	unbound := MustNewMacaroon([]byte(testDischargeKey), []byte(testCaveatId), "login", macaroonv2.V2)
+	for _, caveat := range DischargeCaveats("dev@example.com", "token-1", time.Now().Add(time.Hour)) {
+		if err = unbound.AddFirstPartyCaveat([]byte(caveat)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = VerifyMacaroons(testRootKey, testStoreId, root, unbound)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized with an unbound discharge, got %v", err)
+	}
+
+	_, err = VerifyMacaroons(testRootKey, testStoreId, root, discharge)
+	if err != nil {
+		t.Errorf("expected the bound discharge to verify, got %s", err)
+	}
+}
+
+func TestACLAllowsChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels []string
+		request  []string
+		expected bool
+	}{
+		{name: "any channel", channels: nil, request: []string{"latest/stable", "beta"}, expected: true},
+		{name: "risk is on the latest track", channels: []string{"stable"}, request: []string{"latest/stable"}, expected: true},
+		{name: "track is kept", channels: []string{"2.0/stable"}, request: []string{"stable"}, expected: false},
+		{name: "every channel has to be allowed", channels: []string{"edge"}, request: []string{"edge", "beta"}, expected: false},
+		{name: "no channels", channels: []string{}, request: []string{"edge"}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			acl := ACL{Channels: test.channels}
+			if allowed := acl.AllowsChannels(test.request); allowed != test.expected {
+				t.Errorf("expected %t for %v, got %t", test.expected, test.request, allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var ErrInvalidSSHKey = errors.New("invalid ssh public key")
+
+// ParseSSHPublicKey checks the key is a single public key in the authorized_keys format. The key is returned in a
+// normalized form, keeping its comment, with its SHA256 fingerprint.
+func ParseSSHPublicKey(publicKey string) (string, string, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	if publicKey == "" || strings.ContainsAny(publicKey, "\r\n") {
+		return "", "", fmt.Errorf("%w: expected a single key", ErrInvalidSSHKey)
+	}
+
+	key, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", ErrInvalidSSHKey, err.Error())
+	}
+
+	// options would be applied by sshd on the device, they aren't ours to set
+	if len(options) > 0 {
+		return "", "", fmt.Errorf("%w: options are not allowed", ErrInvalidSSHKey)
+	}
+
+	normalized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	if comment != "" {
+		normalized += " " + comment
+	}
+
+	return normalized, ssh.FingerprintSHA256(key), nil
+}
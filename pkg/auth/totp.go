@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod = 30
+	// totpSkew is how many time steps either side of now a code is accepted for, clocks drift
+	totpSkew = 1
+)
+
+// GenerateTOTPKey generates a shared secret for an authenticator app, the key's URL can be shown as a QR code
+func GenerateTOTPKey(issuer string, accountName string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+}
+
+// ValidateTOTP checks the code was generated from the secret around when, the time step it was generated for is
+// returned so the code can't be used again
+func ValidateTOTP(secret string, code string, when time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != int(otp.DigitsSix) {
+		return 0, false
+	}
+
+	for skew := -totpSkew; skew <= totpSkew; skew++ {
+		at := when.Add(time.Duration(skew*totpPeriod) * time.Second)
+		expected, err := totp.GenerateCodeCustom(secret, at, totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return at.Unix() / totpPeriod, true
+		}
+	}
+
+	return 0, false
+}
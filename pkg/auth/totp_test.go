@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+func TestValidateTOTP(t *testing.T) {
+	key, err := GenerateTOTPKey("kebe", "dev@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a fixed secret whose code at now differs from the generated one's would make the test flaky, so check it
+	other := "JBSWY3DPEHPK3PXP"
+
+	now := time.Unix(1634567890, 0)
+	codeAt := func(secret string, when time.Time) string {
+		code, err := totp.GenerateCodeCustom(secret, when, totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return code
+	}
+
+	if codeAt(other, now) == codeAt(key.Secret(), now) {
+		t.Skip("the generated secret collides with the fixed one")
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		valid    bool
+		expected int64
+	}{
+		{name: "valid", secret: key.Secret(), code: codeAt(key.Secret(), now), valid: true, expected: now.Unix() / totpPeriod},
+		{name: "surrounding whitespace", secret: key.Secret(), code: " " + codeAt(key.Secret(), now) + "\n", valid: true, expected: now.Unix() / totpPeriod},
+		{name: "previous step", secret: key.Secret(), code: codeAt(key.Secret(), now.Add(-totpPeriod*time.Second)), valid: true, expected: now.Unix()/totpPeriod - 1},
+		{name: "next step", secret: key.Secret(), code: codeAt(key.Secret(), now.Add(totpPeriod*time.Second)), valid: true, expected: now.Unix()/totpPeriod + 1},
+		{name: "expired", secret: key.Secret(), code: codeAt(key.Secret(), now.Add(-2*totpPeriod*time.Second))},
+		{name: "too early", secret: key.Secret(), code: codeAt(key.Secret(), now.Add(2*totpPeriod*time.Second))},
+		{name: "another secret", secret: key.Secret(), code: codeAt(other, now)},
+		{name: "too short", secret: key.Secret(), code: "12345"},
+		{name: "too long", secret: key.Secret(), code: codeAt(key.Secret(), now) + "0"},
+		{name: "not digits", secret: key.Secret(), code: "abcdef"},
+		{name: "empty", secret: key.Secret(), code: ""},
+		{name: "malformed secret", secret: "not base32!", code: "123456"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step, valid := ValidateTOTP(test.secret, test.code, now)
+			if valid != test.valid {
+				t.Fatalf("expected valid to be %t, got %t", test.valid, valid)
+			}
+
+			if valid && step != test.expected {
+				t.Errorf("expected time step %d, got %d", test.expected, step)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/freetocompute/kebe/config"
+	"github.com/freetocompute/kebe/config/configkey"
+	"github.com/spf13/viper"
+)
+
+// Signing backends that can be set with signing.backend
+const (
+	BackendKeyfile = "keyfile"
+	BackendPKCS11  = "pkcs11"
+)
+
+var ErrKeyNotFound = errors.New("key not found in signing backend")
+
+// Backend holds the private keys used to sign assertions, the keys never leave it. Keys are referred to by the
+// public-key-sha3-384 of their public key, the id snapd uses for them.
+type Backend interface {
+	// GenerateKey creates a new RSA key in the backend and returns its public key
+	GenerateKey(name string, bits int) (*rsa.PublicKey, error)
+	// ImportKey adds an existing RSA key to the backend and returns its public key
+	ImportKey(name string, privateKey *rsa.PrivateKey) (*rsa.PublicKey, error)
+	// Signer returns a signer for the key, ErrKeyNotFound is returned if the backend doesn't hold it
+	Signer(keyId string) (crypto.Signer, error)
+}
+
+// NewBackendFromConfig opens the signing backend set in the configuration
+func NewBackendFromConfig() (Backend, error) {
+	switch backend := viper.GetString(configkey.SigningBackend); backend {
+	case BackendKeyfile:
+		return NewKeyfileBackend(config.MustGetString(configkey.SigningKeyfileDirectory), config.MustGetString(configkey.SigningKeyfilePassphrase))
+	case BackendPKCS11:
+		return NewPKCS11Backend(config.MustGetString(configkey.SigningPKCS11Module), config.MustGetString(configkey.SigningPKCS11TokenLabel), config.MustGetString(configkey.SigningPKCS11Pin))
+	default:
+		return nil, fmt.Errorf("unknown signing backend: %q", backend)
+	}
+}
+
+// MustGetBackend is NewBackendFromConfig for services that can't run without being able to sign
+func MustGetBackend() Backend {
+	backend, err := NewBackendFromConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	return backend
+}
@@ -0,0 +1,181 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/snapcore/snapd/asserts"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keyfilePEMType   = "KEBE ENCRYPTED PRIVATE KEY"
+	keyfileExtension = ".key"
+	keyfileSaltSize  = 32
+)
+
+// KeyfileBackend keeps each private key in its own file, encrypted with AES-256-GCM using a key derived from a
+// passphrase with scrypt
+type KeyfileBackend struct {
+	directory  string
+	passphrase []byte
+
+	mutex sync.Mutex
+	keys  map[string]*rsa.PrivateKey
+}
+
+func NewKeyfileBackend(directory string, passphrase string) (*KeyfileBackend, error) {
+	if passphrase == "" {
+		return nil, errors.New("a passphrase is required to encrypt key files")
+	}
+
+	err := os.MkdirAll(directory, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyfileBackend{
+		directory:  directory,
+		passphrase: []byte(passphrase),
+		keys:       map[string]*rsa.PrivateKey{},
+	}, nil
+}
+
+func (k *KeyfileBackend) GenerateKey(name string, bits int) (*rsa.PublicKey, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.ImportKey(name, privateKey)
+}
+
+func (k *KeyfileBackend) ImportKey(name string, privateKey *rsa.PrivateKey) (*rsa.PublicKey, error) {
+	keyId := asserts.RSAPublicKey(&privateKey.PublicKey).ID()
+
+	salt := make([]byte, keyfileSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := k.newAEAD(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	// the key id is authenticated so a key file can't be passed off as another key
+	sealed := aead.Seal(nonce, nonce, x509.MarshalPKCS1PrivateKey(privateKey), []byte(keyId))
+	block := &pem.Block{
+		Type: keyfilePEMType,
+		Headers: map[string]string{
+			"Name": name,
+			"Salt": base64.StdEncoding.EncodeToString(salt),
+		},
+		Bytes: sealed,
+	}
+
+	err = ioutil.WriteFile(k.keyPath(keyId), pem.EncodeToMemory(block), 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mutex.Lock()
+	k.keys[keyId] = privateKey
+	k.mutex.Unlock()
+
+	return &privateKey.PublicKey, nil
+}
+
+func (k *KeyfileBackend) Signer(keyId string) (crypto.Signer, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if privateKey, ok := k.keys[keyId]; ok {
+		return privateKey, nil
+	}
+
+	privateKey, err := k.load(keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	k.keys[keyId] = privateKey
+	return privateKey, nil
+}
+
+func (k *KeyfileBackend) load(keyId string) (*rsa.PrivateKey, error) {
+	if keyId == "" || strings.ContainsAny(keyId, `/\.`) {
+		return nil, ErrKeyNotFound
+	}
+
+	bytes, err := ioutil.ReadFile(k.keyPath(keyId))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(bytes)
+	if block == nil || block.Type != keyfilePEMType {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(block.Headers["Salt"])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := k.newAEAD(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(block.Bytes) < aead.NonceSize() {
+		return nil, errors.New("key file is too short")
+	}
+
+	nonce, sealed := block.Bytes[:aead.NonceSize()], block.Bytes[aead.NonceSize():]
+	der, err := aead.Open(nil, nonce, sealed, []byte(keyId))
+	if err != nil {
+		return nil, errors.New("unable to decrypt key file, is the passphrase right?")
+	}
+
+	return x509.ParsePKCS1PrivateKey(der)
+}
+
+func (k *KeyfileBackend) newAEAD(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(k.passphrase, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (k *KeyfileBackend) keyPath(keyId string) string {
+	// key ids are url safe base64 so they can be used as file names as is
+	return filepath.Join(k.directory, keyId+keyfileExtension)
+}
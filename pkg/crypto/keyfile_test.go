@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/snapcore/snapd/asserts"
+)
+
+func TestKeyfileRoundTrip(t *testing.T) {
+	directory := t.TempDir()
+	backend, err := NewKeyfileBackend(directory, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := backend.ImportKey("test", privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyId := asserts.RSAPublicKey(publicKey).ID()
+
+	// a new backend has nothing cached so the key is read back from its file
+	reopened, err := NewKeyfileBackend(directory, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := reopened.Signer(keyId)
+	if err != nil {
+		t.Fatalf("unable to load the key: %s", err)
+	}
+
+	loaded, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		t.Fatalf("expected an RSA private key, got %T", signer)
+	}
+
+	if !loaded.Equal(privateKey) {
+		t.Error("the loaded key is not the imported one")
+	}
+}
+
+func TestKeyfileWrongPassphrase(t *testing.T) {
+	directory := t.TempDir()
+	backend, err := NewKeyfileBackend(directory, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := backend.GenerateKey("test", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewKeyfileBackend(directory, "wrong passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = reopened.Signer(asserts.RSAPublicKey(publicKey).ID())
+	if err == nil {
+		t.Error("expected the key to not decrypt with the wrong passphrase")
+	}
+}
+
+func TestKeyfileUnknownKey(t *testing.T) {
+	backend, err := NewKeyfileBackend(t.TempDir(), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keyId := range []string{"", "unknown", "../unknown"} {
+		_, err = backend.Signer(keyId)
+		if err != ErrKeyNotFound {
+			t.Errorf("expected ErrKeyNotFound for %q, got %v", keyId, err)
+		}
+	}
+}
+
+func TestKeyfileRequiresPassphrase(t *testing.T) {
+	_, err := NewKeyfileBackend(t.TempDir(), "")
+	if err == nil {
+		t.Error("expected an error without a passphrase")
+	}
+}
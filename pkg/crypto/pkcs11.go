@@ -0,0 +1,310 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/snapcore/snapd/asserts"
+)
+
+// pkcs1DigestPrefixes are the DER encoded DigestInfo prefixes CKM_RSA_PKCS expects in front of a digest
+var pkcs1DigestPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// PKCS11Backend keeps private keys on a PKCS#11 token, like an HSM or SoftHSM, where they are generated or
+// imported as non-extractable keys. A single logged in session is shared, PKCS#11 sessions can't be used
+// concurrently so every call to the token is serialized.
+type PKCS11Backend struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+
+	mutex sync.Mutex
+	keys  map[string]*pkcs11Signer
+}
+
+func NewPKCS11Backend(modulePath string, tokenLabel string, pin string) (*PKCS11Backend, error) {
+	ctx := pkcs11.New(modulePath)
+	if ctx == nil {
+		return nil, fmt.Errorf("unable to load PKCS#11 module %s", modulePath)
+	}
+
+	err := ctx.Initialize()
+	if err != nil {
+		return nil, err
+	}
+
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, slot := range slots {
+		tokenInfo, err2 := ctx.GetTokenInfo(slot)
+		if err2 != nil || tokenInfo.Label != tokenLabel {
+			continue
+		}
+
+		session, err2 := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err2 != nil {
+			return nil, err2
+		}
+
+		err2 = ctx.Login(session, pkcs11.CKU_USER, pin)
+		if err2 != nil && !errors.Is(err2, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			_ = ctx.CloseSession(session)
+			return nil, err2
+		}
+
+		return &PKCS11Backend{
+			ctx:     ctx,
+			session: session,
+			keys:    map[string]*pkcs11Signer{},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no PKCS#11 token with label %q", tokenLabel)
+}
+
+func (p *PKCS11Backend) GenerateKey(name string, bits int) (*rsa.PublicKey, error) {
+	objectId, err := newObjectId()
+	if err != nil {
+		return nil, err
+	}
+
+	publicTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, bits),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, name),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, objectId),
+	}
+	privateTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, name),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, objectId),
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	publicHandle, privateHandle, err := p.ctx.GenerateKeyPair(p.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)}, publicTemplate, privateTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := p.readPublicKey(publicHandle)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys[asserts.RSAPublicKey(publicKey).ID()] = &pkcs11Signer{backend: p, handle: privateHandle, publicKey: publicKey}
+	return publicKey, nil
+}
+
+func (p *PKCS11Backend) ImportKey(name string, privateKey *rsa.PrivateKey) (*rsa.PublicKey, error) {
+	if len(privateKey.Primes) != 2 {
+		return nil, errors.New("only RSA keys with two primes can be imported")
+	}
+
+	objectId, err := newObjectId()
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey.Precompute()
+	publicExponent := big.NewInt(int64(privateKey.E)).Bytes()
+
+	publicTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, privateKey.N.Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, publicExponent),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, name),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, objectId),
+	}
+	privateTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, name),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, objectId),
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, privateKey.N.Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, publicExponent),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE_EXPONENT, privateKey.D.Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIME_1, privateKey.Primes[0].Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIME_2, privateKey.Primes[1].Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_EXPONENT_1, privateKey.Precomputed.Dp.Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_EXPONENT_2, privateKey.Precomputed.Dq.Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_COEFFICIENT, privateKey.Precomputed.Qinv.Bytes()),
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	_, err = p.ctx.CreateObject(p.session, publicTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	privateHandle, err := p.ctx.CreateObject(p.session, privateTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := &privateKey.PublicKey
+	p.keys[asserts.RSAPublicKey(publicKey).ID()] = &pkcs11Signer{backend: p, handle: privateHandle, publicKey: publicKey}
+	return publicKey, nil
+}
+
+// Signer finds the key on the token by its public key, so keys put on the token with other tools, like
+// softhsm2-util or pkcs11-tool, can be used as long as the public and private key share a CKA_ID
+func (p *PKCS11Backend) Signer(keyId string) (crypto.Signer, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if signer, ok := p.keys[keyId]; ok {
+		return signer, nil
+	}
+
+	publicHandles, err := p.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, publicHandle := range publicHandles {
+		publicKey, err2 := p.readPublicKey(publicHandle)
+		if err2 != nil || asserts.RSAPublicKey(publicKey).ID() != keyId {
+			continue
+		}
+
+		attributes, err2 := p.ctx.GetAttributeValue(p.session, publicHandle, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, nil)})
+		if err2 != nil {
+			return nil, err2
+		}
+
+		privateHandles, err2 := p.findObjects([]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, attributes[0].Value),
+		})
+		if err2 != nil {
+			return nil, err2
+		}
+
+		if len(privateHandles) == 0 {
+			return nil, fmt.Errorf("%w: the token only has the public key of %s", ErrKeyNotFound, keyId)
+		}
+
+		signer := &pkcs11Signer{backend: p, handle: privateHandles[0], publicKey: publicKey}
+		p.keys[keyId] = signer
+		return signer, nil
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+func (p *PKCS11Backend) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	err := p.ctx.FindObjectsInit(p.session, template)
+	if err != nil {
+		return nil, err
+	}
+
+	var handles []pkcs11.ObjectHandle
+	for {
+		found, _, err2 := p.ctx.FindObjects(p.session, 100)
+		if err2 != nil {
+			_ = p.ctx.FindObjectsFinal(p.session)
+			return nil, err2
+		}
+
+		if len(found) == 0 {
+			break
+		}
+
+		handles = append(handles, found...)
+	}
+
+	return handles, p.ctx.FindObjectsFinal(p.session)
+}
+
+func (p *PKCS11Backend) readPublicKey(handle pkcs11.ObjectHandle) (*rsa.PublicKey, error) {
+	attributes, err := p.ctx.GetAttributeValue(p.session, handle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(attributes[1].Value)
+	if !exponent.IsInt64() {
+		return nil, errors.New("unsupported RSA public exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(attributes[0].Value),
+		E: int(exponent.Int64()),
+	}, nil
+}
+
+func newObjectId() ([]byte, error) {
+	objectId := make([]byte, 16)
+	_, err := io.ReadFull(rand.Reader, objectId)
+	return objectId, err
+}
+
+// pkcs11Signer signs PKCS#1 v1.5 digests with a private key on the token
+type pkcs11Signer struct {
+	backend   *PKCS11Backend
+	handle    pkcs11.ObjectHandle
+	publicKey *rsa.PublicKey
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	prefix, ok := pkcs1DigestPrefixes[opts.HashFunc()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash for PKCS#11 signing: %v", opts.HashFunc())
+	}
+
+	s.backend.mutex.Lock()
+	defer s.backend.mutex.Unlock()
+
+	ctx := s.backend.ctx
+	err := ctx.SignInit(s.backend.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)}, s.handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.Sign(s.backend.session, append(append([]byte{}, prefix...), digest...))
+}
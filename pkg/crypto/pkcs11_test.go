@@ -0,0 +1,121 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// softHSMModules are where distributions install the SoftHSM PKCS#11 module, KEBE_TEST_SOFTHSM_MODULE overrides
+// them
+var softHSMModules = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+}
+
+// newSoftHSMBackend initializes a SoftHSM token in a temporary directory and opens it, the test is skipped if
+// SoftHSM isn't installed
+func newSoftHSMBackend(t *testing.T) *PKCS11Backend {
+	modules := softHSMModules
+	if module := os.Getenv("KEBE_TEST_SOFTHSM_MODULE"); module != "" {
+		modules = []string{module}
+	}
+
+	var modulePath string
+	for _, module := range modules {
+		if _, err := os.Stat(module); err == nil {
+			modulePath = module
+			break
+		}
+	}
+
+	softHSMUtil, err := exec.LookPath("softhsm2-util")
+	if modulePath == "" || err != nil {
+		t.Skip("SoftHSM is not installed")
+	}
+
+	directory := t.TempDir()
+	tokens := filepath.Join(directory, "tokens")
+	err = os.Mkdir(tokens, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := filepath.Join(directory, "softhsm2.conf")
+	err = ioutil.WriteFile(conf, []byte("directories.tokendir = "+tokens+"\nobjectstore.backend = file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the module reads the configuration when it is initialized
+	previousConf, hadConf := os.LookupEnv("SOFTHSM2_CONF")
+	_ = os.Setenv("SOFTHSM2_CONF", conf)
+	t.Cleanup(func() {
+		if hadConf {
+			_ = os.Setenv("SOFTHSM2_CONF", previousConf)
+		} else {
+			_ = os.Unsetenv("SOFTHSM2_CONF")
+		}
+	})
+
+	output, err := exec.Command(softHSMUtil, "--init-token", "--free", "--label", "kebe-test", "--pin", "1234", "--so-pin", "1234").CombinedOutput()
+	if err != nil {
+		t.Fatalf("unable to initialize the token: %s: %s", err, output)
+	}
+
+	backend, err := NewPKCS11Backend(modulePath, "kebe-test", "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = backend.ctx.Logout(backend.session)
+		_ = backend.ctx.CloseSession(backend.session)
+		_ = backend.ctx.Finalize()
+		backend.ctx.Destroy()
+	})
+
+	return backend
+}
+
+func TestPKCS11GenerateKey(t *testing.T) {
+	backend := newSoftHSMBackend(t)
+
+	publicKey, err := backend.GenerateKey("test", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSignAssertion(t, backend, publicKey)
+}
+
+func TestPKCS11ImportKey(t *testing.T) {
+	backend := newSoftHSMBackend(t)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := backend.ImportKey("test", privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !publicKey.Equal(&privateKey.PublicKey) {
+		t.Error("the token returned a different public key")
+	}
+
+	checkSignAssertion(t, backend, publicKey)
+
+	_, err = backend.Signer("unknown")
+	if err != ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
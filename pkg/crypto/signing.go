@@ -0,0 +1,246 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+const (
+	signKeyHeader = "sign-key-sha3-384: "
+	// snapd wraps signatures at 76 characters
+	signatureLineLength = 76
+)
+
+// snapd creates its openpgp keys with a fixed timestamp, the signature has to use the same one
+var openpgpKeyTimestamp = time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// KeySource lists the keys an authority can sign with
+type KeySource interface {
+	// GetSigningKeys returns the keys of the account valid at the given time, the preferred key first
+	GetSigningKeys(accountId string, when time.Time) ([]models.Key, error)
+}
+
+// SigningDB signs assertions with keys held in a Backend. It can be used anywhere an assertstest.SignerDB is
+// expected.
+type SigningDB struct {
+	AuthorityID string
+	// KeyID is used when Sign isn't given a key and there's no key source, for an authority with a key source it
+	// is the key devices trust, which account-keys are signed with
+	KeyID string
+
+	backend Backend
+	keys    KeySource
+}
+
+func NewSigningDB(backend Backend, authorityId string, keyId string) *SigningDB {
+	return &SigningDB{
+		AuthorityID: authorityId,
+		KeyID:       keyId,
+		backend:     backend,
+	}
+}
+
+// NewAuthoritySigningDB returns a SigningDB that signs with the active key of the authority, so keys can be
+// rotated while it's in use
+func NewAuthoritySigningDB(backend Backend, keys KeySource, authorityId string, trustedKeyId string) *SigningDB {
+	return &SigningDB{
+		AuthorityID: authorityId,
+		KeyID:       trustedKeyId,
+		backend:     backend,
+		keys:        keys,
+	}
+}
+
+// ActiveKeyID returns the key new assertions are signed with, the most recent valid key of the authority held
+// by the backend
+func (db *SigningDB) ActiveKeyID() (string, error) {
+	if db.keys == nil {
+		return db.KeyID, nil
+	}
+
+	keys, err := db.keys.GetSigningKeys(db.AuthorityID, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range keys {
+		if _, err = db.backend.Signer(key.SHA3384); err == nil {
+			return key.SHA3384, nil
+		} else if !errors.Is(err, ErrKeyNotFound) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("%w: no valid key for %s", ErrKeyNotFound, db.AuthorityID)
+}
+
+// Sign signs the assertion, the authority-id defaults to the one of the SigningDB
+func (db *SigningDB) Sign(assertType *asserts.AssertionType, headers map[string]interface{}, body []byte, keyID string) (asserts.Assertion, error) {
+	if _, ok := headers["authority-id"]; !ok {
+		withAuthority := make(map[string]interface{}, len(headers)+1)
+		for k, v := range headers {
+			withAuthority[k] = v
+		}
+		withAuthority["authority-id"] = db.AuthorityID
+		headers = withAuthority
+	}
+
+	if keyID == "" {
+		activeKeyID, err := db.ActiveKeyID()
+		if err != nil {
+			return nil, err
+		}
+
+		keyID = activeKeyID
+	}
+
+	return SignAssertion(db.backend, assertType, headers, body, keyID)
+}
+
+// PublicKey returns the public key of a key held in the backend
+func (db *SigningDB) PublicKey(keyID string) (asserts.PublicKey, error) {
+	signer, err := db.backend.Signer(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaPublicKey, ok := signer.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrNotRSAPublicKey
+	}
+
+	return asserts.RSAPublicKey(rsaPublicKey), nil
+}
+
+var (
+	placeholderOnce sync.Once
+	placeholderDB   *assertstest.SigningDB
+)
+
+// SignAssertion signs an assertion with a key from the backend.
+//
+// snapd only signs with private keys it holds in memory, so the assertion is first assembled and signed by
+// snapd with a placeholder key. That gives us content in the exact format snapd expects, we then swap in the
+// real key id, which has the same length, and sign the content with the backend.
+func SignAssertion(backend Backend, assertType *asserts.AssertionType, headers map[string]interface{}, body []byte, keyID string) (asserts.Assertion, error) {
+	signer, err := backend.Signer(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaPublicKey, ok := signer.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrNotRSAPublicKey
+	}
+
+	publicKey := asserts.RSAPublicKey(rsaPublicKey)
+	if publicKey.ID() != keyID {
+		return nil, fmt.Errorf("backend returned key %s when asked for %s", publicKey.ID(), keyID)
+	}
+
+	placeholderOnce.Do(func() {
+		placeholderKey, _ := assertstest.GenerateKey(1024)
+		placeholderDB = assertstest.NewSigningDB("", placeholderKey)
+	})
+
+	placeholder, err := placeholderDB.Sign(assertType, headers, body, "")
+	if err != nil {
+		return nil, err
+	}
+
+	content, _ := placeholder.Signature()
+	content, err = replaceSignKey(content, len(body), placeholderDB.KeyID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := signContent(content, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([]byte, 0, len(content)+len(signature)+2)
+	encoded = append(encoded, content...)
+	encoded = append(encoded, '\n', '\n')
+	encoded = append(encoded, signature...)
+
+	assertion, err := asserts.Decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	err = asserts.SignatureCheck(assertion, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return assertion, nil
+}
+
+// replaceSignKey swaps the sign-key-sha3-384 header, it is always the last header so it's found right before
+// the body
+func replaceSignKey(content []byte, bodyLength int, placeholderKeyID string, keyID string) ([]byte, error) {
+	headersEnd := len(content)
+	if bodyLength > 0 {
+		headersEnd -= bodyLength + 2
+	}
+
+	placeholderHeader := []byte(signKeyHeader + placeholderKeyID)
+	if len(placeholderKeyID) != len(keyID) || headersEnd < 0 || !bytes.HasSuffix(content[:headersEnd], placeholderHeader) {
+		return nil, errors.New("unexpected assertion content, unable to set the signing key")
+	}
+
+	replaced := make([]byte, 0, len(content))
+	replaced = append(replaced, content[:headersEnd-len(keyID)]...)
+	replaced = append(replaced, keyID...)
+	replaced = append(replaced, content[headersEnd:]...)
+	return replaced, nil
+}
+
+// signContent creates the same openpgp signature snapd does, a SHA-512 RSA signature encoded as base64 with a
+// version byte in front
+func signContent(content []byte, signer crypto.Signer) ([]byte, error) {
+	privateKey := packet.NewSignerPrivateKey(openpgpKeyTimestamp, signer)
+	signature := &packet.Signature{
+		SigType:      packet.SigTypeBinary,
+		PubKeyAlgo:   privateKey.PubKeyAlgo,
+		Hash:         crypto.SHA512,
+		CreationTime: time.Now(),
+	}
+
+	h := crypto.SHA512.New()
+	h.Write(content)
+
+	err := signature.Sign(h, privateKey, &packet.Config{DefaultHash: crypto.SHA512})
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := new(bytes.Buffer)
+	err = signature.Serialize(serialized)
+	if err != nil {
+		return nil, err
+	}
+
+	flat := base64.StdEncoding.EncodeToString(append([]byte{0x1}, serialized.Bytes()...))
+	wrapped := new(bytes.Buffer)
+	for len(flat) > signatureLineLength {
+		wrapped.WriteString(flat[:signatureLineLength])
+		wrapped.WriteByte('\n')
+		flat = flat[signatureLineLength:]
+	}
+	wrapped.WriteString(flat)
+
+	return wrapped.Bytes(), nil
+}
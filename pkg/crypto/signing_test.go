@@ -0,0 +1,201 @@
+package crypto
+
+import (
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+)
+
+// checkSignAssertion signs an account assertion, which has no body, and an account-key assertion, which has
+// one, with the key and checks snapd accepts the signatures
+func checkSignAssertion(t *testing.T, backend Backend, publicKey *rsa.PublicKey) {
+	keyId := asserts.RSAPublicKey(publicKey).ID()
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	devicePrivateKey, _ := assertstest.GenerateKey(752)
+	deviceKeyBody, err := asserts.EncodePublicKey(devicePrivateKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		assertType *asserts.AssertionType
+		headers    map[string]interface{}
+		body       []byte
+	}{
+		{
+			name:       "without body",
+			assertType: asserts.AccountType,
+			headers: map[string]interface{}{
+				"authority-id": "test-store",
+				"account-id":   "test-account",
+				"display-name": "Test Account",
+				"username":     "test",
+				"validation":   "unproven",
+				"timestamp":    now,
+			},
+		},
+		{
+			name:       "with body",
+			assertType: asserts.AccountKeyType,
+			headers: map[string]interface{}{
+				"authority-id":        "test-store",
+				"account-id":          "test-account",
+				"name":                "device",
+				"public-key-sha3-384": devicePrivateKey.PublicKey().ID(),
+				"since":               now,
+			},
+			body: deviceKeyBody,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signed, err := SignAssertion(backend, test.assertType, test.headers, test.body, keyId)
+			if err != nil {
+				t.Fatalf("SignAssertion failed: %s", err)
+			}
+
+			decoded, err := asserts.Decode(asserts.Encode(signed))
+			if err != nil {
+				t.Fatalf("signed assertion doesn't decode: %s", err)
+			}
+
+			if decoded.Type() != test.assertType {
+				t.Errorf("expected a %s assertion, got %s", test.assertType.Name, decoded.Type().Name)
+			}
+
+			if decoded.SignKeyID() != keyId {
+				t.Errorf("expected sign-key-sha3-384 %s, got %s", keyId, decoded.SignKeyID())
+			}
+
+			if string(decoded.Body()) != string(test.body) {
+				t.Errorf("expected body %q, got %q", test.body, decoded.Body())
+			}
+
+			err = asserts.SignatureCheck(decoded, asserts.RSAPublicKey(publicKey))
+			if err != nil {
+				t.Errorf("signature check failed: %s", err)
+			}
+		})
+	}
+}
+
+func TestSignAssertion(t *testing.T) {
+	backend, err := NewKeyfileBackend(t.TempDir(), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := backend.GenerateKey("test", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSignAssertion(t, backend, publicKey)
+}
+
+func TestSignAssertionUnknownKey(t *testing.T) {
+	backend, err := NewKeyfileBackend(t.TempDir(), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = SignAssertion(backend, asserts.AccountType, map[string]interface{}{}, nil, "unknown")
+	if err != ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+type fakeKeySource struct {
+	keys []models.Key
+	err  error
+}
+
+func (f *fakeKeySource) GetSigningKeys(accountId string, when time.Time) ([]models.Key, error) {
+	return f.keys, f.err
+}
+
+func TestActiveKeyID(t *testing.T) {
+	backend, err := NewKeyfileBackend(t.TempDir(), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldPublicKey, err := backend.GenerateKey("old", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPublicKey, err := backend.GenerateKey("new", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKeyId := asserts.RSAPublicKey(oldPublicKey).ID()
+	newKeyId := asserts.RSAPublicKey(newPublicKey).ID()
+	errKeys := errors.New("unable to list keys")
+
+	tests := []struct {
+		name     string
+		keys     KeySource
+		expected string
+		err      error
+	}{
+		{name: "no key source", keys: nil, expected: oldKeyId},
+		{name: "rotated", keys: &fakeKeySource{keys: []models.Key{{SHA3384: newKeyId}, {SHA3384: oldKeyId}}}, expected: newKeyId},
+		{name: "not rotated yet", keys: &fakeKeySource{keys: []models.Key{{SHA3384: oldKeyId}}}, expected: oldKeyId},
+		{name: "preferred key held elsewhere", keys: &fakeKeySource{keys: []models.Key{{SHA3384: "elsewhere"}, {SHA3384: oldKeyId}}}, expected: oldKeyId},
+		{name: "all keys revoked or expired", keys: &fakeKeySource{}, err: ErrKeyNotFound},
+		{name: "no key held", keys: &fakeKeySource{keys: []models.Key{{SHA3384: "elsewhere"}}}, err: ErrKeyNotFound},
+		{name: "key source error", keys: &fakeKeySource{err: errKeys}, err: errKeys},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := NewSigningDB(backend, "test-store", oldKeyId)
+			if test.keys != nil {
+				db = NewAuthoritySigningDB(backend, test.keys, "test-store", oldKeyId)
+			}
+
+			keyId, err := db.ActiveKeyID()
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected %v, got %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ActiveKeyID failed: %s", err)
+			}
+
+			if keyId != test.expected {
+				t.Errorf("expected key %s, got %s", test.expected, keyId)
+			}
+
+			// Sign without a key uses the active key
+			signed, err := db.Sign(asserts.AccountType, map[string]interface{}{
+				"account-id":   "test-account",
+				"display-name": "Test Account",
+				"username":     "test",
+				"validation":   "unproven",
+				"timestamp":    time.Now().UTC().Format(time.RFC3339),
+			}, nil, "")
+			if err != nil {
+				t.Fatalf("Sign failed: %s", err)
+			}
+
+			if signed.SignKeyID() != test.expected {
+				t.Errorf("expected the assertion to be signed with %s, got %s", test.expected, signed.SignKeyID())
+			}
+		})
+	}
+}
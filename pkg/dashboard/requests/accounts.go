@@ -3,3 +3,8 @@ package requests
 type AccountKeyCreateRequest struct {
 	AccountKeyRequest string `json:"account_key_request"`
 }
+
+// SSHKeyCreateRequest is the body of POST /dev/api/account/ssh-keys, the key is in the authorized_keys format
+type SSHKeyCreateRequest struct {
+	PublicKey string `json:"public-key"`
+}
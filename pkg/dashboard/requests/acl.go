@@ -1,10 +1,19 @@
 package requests
 
+// ACLPackage names a snap the macaroon is restricted to, snapcraft sends the name while the snap_id is accepted
+// too
+type ACLPackage struct {
+	Name   string `json:"name"`
+	SnapId string `json:"snap_id"`
+	Series string `json:"series"`
+}
+
+// ACLRequest is the body snapcraft posts to /acl/ to get a root macaroon
 type ACLRequest struct {
-	Permissions []string
-	Channels    []string
-	Packages    []string
-	Expires     string
+	Permissions []string     `json:"permissions"`
+	Channels    []string     `json:"channels"`
+	Packages    []ACLPackage `json:"packages"`
+	Expires     string       `json:"expires"`
 }
 
 type AuthData struct {
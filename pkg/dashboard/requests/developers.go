@@ -0,0 +1,14 @@
+package requests
+
+// SnapDeveloper adds a collaborator to a snap or changes its permissions, the account is given by its account id
+// or its email
+type SnapDeveloper struct {
+	AccountId   string   `json:"account-id"`
+	Email       string   `json:"email"`
+	Permissions []string `json:"permissions"`
+}
+
+// SnapDevelopers is the body of POST /dev/api/snaps/{id}/developers
+type SnapDevelopers struct {
+	Developers []SnapDeveloper `json:"developers"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+// BinaryMetadataInfo is a single entry of the "info" field snapcraft sends to binary-metadata,
+// Key names the multipart file holding the media, when it is empty Hash refers to existing media
+type BinaryMetadataInfo struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Filename string `json:"filename"`
+	Hash     string `json:"hash"`
+}
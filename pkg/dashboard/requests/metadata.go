@@ -0,0 +1,29 @@
+package requests
+
+// SnapMetadata is the body of a metadata update, fields that are nil are left unchanged.
+// ConflictFields holds the values the client last saw for fields, when the store's current value of
+// one of those fields differs a non-forced update is rejected with a conflict.
+type SnapMetadata struct {
+	Title       *string `json:"title,omitempty"`
+	Summary     *string `json:"summary,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Contact     *string `json:"contact,omitempty"`
+	Website     *string `json:"website,omitempty"`
+	License     *string `json:"license,omitempty"`
+	Category    *string `json:"category,omitempty"`
+
+	ConflictFields map[string]string `json:"conflict_fields,omitempty"`
+}
+
+// Fields returns each field of the request keyed by its API name
+func (sm *SnapMetadata) Fields() map[string]*string {
+	return map[string]*string{
+		"title":       sm.Title,
+		"summary":     sm.Summary,
+		"description": sm.Description,
+		"contact":     sm.Contact,
+		"website":     sm.Website,
+		"license":     sm.License,
+		"category":    sm.Category,
+	}
+}
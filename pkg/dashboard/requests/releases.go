@@ -0,0 +1,13 @@
+package requests
+
+// RevisionRequest is the body of POST /v1/snap/{name}/revisions, the upload id is the one /unscanned-upload/
+// returned
+type RevisionRequest struct {
+	UploadId string `json:"upload-id"`
+}
+
+// ReleaseRequest releases a revision to a channel, a list of them is posted to /v1/snap/{name}/releases
+type ReleaseRequest struct {
+	Channel  string `json:"channel"`
+	Revision uint   `json:"revision"`
+}
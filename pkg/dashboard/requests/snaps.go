@@ -5,3 +5,9 @@ type RegisterSnapName struct {
 	Private bool   `json:"is_private"`
 	Store   string `json:"store"`
 }
+
+// RegisterNameDispute claims a name that is registered by another account or reserved
+type RegisterNameDispute struct {
+	Name    string `json:"snap_name"`
+	Comment string `json:"comment"`
+}
@@ -0,0 +1,17 @@
+package requests
+
+// TokenPackage is a package craft-store asks for a macaroon to be restricted to, only snaps are supported
+type TokenPackage struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// TokenRequest is the body craft-store posts to /v1/tokens to get a root macaroon
+type TokenRequest struct {
+	Permissions []string `json:"permissions"`
+	Description string   `json:"description"`
+	// TTL is how many seconds the macaroon is valid for
+	TTL      int64          `json:"ttl"`
+	Packages []TokenPackage `json:"packages"`
+	Channels []string       `json:"channels"`
+}
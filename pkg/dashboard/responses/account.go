@@ -1,5 +1,7 @@
 package responses
 
+import "time"
+
 type Snap struct {
 	Status  string `json:"status"`
 	SnapId  string `json:"snap-id"`
@@ -16,6 +18,14 @@ type AccountInfo struct {
 }
 
 type Key struct {
-	PublicKeySHA384 string `json:"public-key-sha3-384"`
-	Name            string `json:"name"`
+	PublicKeySHA384 string     `json:"public-key-sha3-384"`
+	Name            string     `json:"name"`
+	Since           time.Time  `json:"since"`
+	Until           *time.Time `json:"until"`
+}
+
+type SSHKey struct {
+	PublicKey   string    `json:"public-key"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created-at"`
 }
@@ -0,0 +1,19 @@
+package responses
+
+import "time"
+
+type SnapDeveloper struct {
+	AccountId   string     `json:"account-id"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	Permissions []string   `json:"permissions"`
+	Since       time.Time  `json:"since"`
+	Until       *time.Time `json:"until,omitempty"`
+}
+
+// SnapDevelopers lists the collaborators of a snap, removed collaborators have until set
+type SnapDevelopers struct {
+	SnapId      string          `json:"snap-id"`
+	PublisherId string          `json:"publisher-id"`
+	Developers  []SnapDeveloper `json:"developers"`
+}
@@ -0,0 +1,18 @@
+package responses
+
+type Error struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Extra   interface{} `json:"extra,omitempty"`
+}
+
+type ErrorList struct {
+	ErrorList []Error `json:"error_list"`
+}
+
+// RegisterNameErrorList is returned when a name can't be registered, snapcraft tells the publisher to dispute the name
+// at RegisterNameURL
+type RegisterNameErrorList struct {
+	ErrorList       []Error `json:"error_list"`
+	RegisterNameURL string  `json:"register_name_url,omitempty"`
+}
@@ -23,6 +23,7 @@ type Verify struct {
 	Device                *string        `json:"device"`
 	LastAuth              string         `json:"last_auth"`
 	Permissions           *[]string      `json:"permissions"`
-	SnapIds               *string        `json:"snap_ids"`
-	Channels              *string        `json:"channels"`
+	SnapIds               *[]string      `json:"snap_ids"`
+	Channels              *[]string      `json:"channels"`
+	Expires               *string        `json:"expires"`
 }
@@ -0,0 +1,10 @@
+package responses
+
+type BinaryMetadata struct {
+	Type     string `json:"type"`
+	Hash     string `json:"hash"`
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+	Width    int64  `json:"width"`
+	Height   int64  `json:"height"`
+}
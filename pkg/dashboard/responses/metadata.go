@@ -0,0 +1,17 @@
+package responses
+
+type SnapMetadata struct {
+	SnapId      string `json:"snap_id"`
+	Title       string `json:"title"`
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	Contact     string `json:"contact"`
+	Website     string `json:"website"`
+	License     string `json:"license"`
+	Category    string `json:"category"`
+}
+
+type MetadataConflict struct {
+	Name    string `json:"name"`
+	Current string `json:"current"`
+}
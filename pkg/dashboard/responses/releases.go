@@ -0,0 +1,75 @@
+package responses
+
+import "time"
+
+type RevisionStatusURL struct {
+	StatusURL string `json:"status-url"`
+}
+
+type RevisionStatus struct {
+	UploadId string  `json:"upload-id"`
+	Revision *int    `json:"revision"`
+	Status   string  `json:"status"`
+	Errors   []Error `json:"errors"`
+}
+
+type RevisionsStatus struct {
+	Revisions []RevisionStatus `json:"revisions"`
+}
+
+type ReleasedChannel struct {
+	Channel  string `json:"channel"`
+	Revision int    `json:"revision"`
+}
+
+type Released struct {
+	Released []ReleasedChannel `json:"released"`
+}
+
+type ReleaseProgressive struct {
+	Paused            *bool    `json:"paused"`
+	Percentage        *float64 `json:"percentage"`
+	CurrentPercentage *float64 `json:"current-percentage"`
+}
+
+type ReleaseChannelMap struct {
+	Architecture   string             `json:"architecture"`
+	Channel        string             `json:"channel"`
+	Revision       int                `json:"revision"`
+	Progressive    ReleaseProgressive `json:"progressive"`
+	When           time.Time          `json:"when"`
+	ExpirationDate *time.Time         `json:"expiration-date"`
+}
+
+type ReleaseChannel struct {
+	Name     string  `json:"name"`
+	Track    string  `json:"track"`
+	Risk     string  `json:"risk"`
+	Branch   *string `json:"branch"`
+	Fallback *string `json:"fallback"`
+}
+
+type ReleasePackage struct {
+	Channels []ReleaseChannel `json:"channels"`
+}
+
+type ReleaseRevision struct {
+	Architectures []string  `json:"architectures"`
+	Base          *string   `json:"base"`
+	BuildURL      *string   `json:"build-url"`
+	Confinement   string    `json:"confinement"`
+	CreatedAt     time.Time `json:"created-at"`
+	Grade         string    `json:"grade"`
+	Revision      int       `json:"revision"`
+	SHA3384       string    `json:"sha3-384"`
+	Size          int64     `json:"size"`
+	Status        string    `json:"status"`
+	Version       string    `json:"version"`
+}
+
+// SnapReleases is the release history of a snap in the publisher gateway format
+type SnapReleases struct {
+	ChannelMap []ReleaseChannelMap `json:"channel-map"`
+	Package    ReleasePackage      `json:"package"`
+	Revisions  []ReleaseRevision   `json:"revisions"`
+}
@@ -5,6 +5,13 @@ type RegisterSnap struct {
 	Name string `json:"snap_name"`
 }
 
+type SnapNameDispute struct {
+	Id      uint   `json:"id"`
+	Name    string `json:"snap_name"`
+	Status  string `json:"status"`
+	Comment string `json:"comment"`
+}
+
 type Status struct {
 	Processed bool   `json:"processed"`
 	Code      string `json:"code"`
@@ -0,0 +1,23 @@
+package responses
+
+type WhoAmIAccount struct {
+	Email      string `json:"email"`
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	Username   string `json:"username"`
+	Validation string `json:"validation"`
+}
+
+type WhoAmIPackage struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// WhoAmI is what craft-store is told about the account and ACL of its credentials
+type WhoAmI struct {
+	Account     WhoAmIAccount    `json:"account"`
+	Permissions []string         `json:"permissions"`
+	Channels    *[]string        `json:"channels"`
+	Packages    *[]WhoAmIPackage `json:"packages"`
+	Expires     *string          `json:"expires"`
+}
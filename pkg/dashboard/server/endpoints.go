@@ -1,6 +1,7 @@
 package server
 
 import (
+	"github.com/freetocompute/kebe/pkg/auth"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,19 +15,51 @@ func (s *Server) SetupEndpoints(checkForAuthorizedUser gin.HandlerFunc) {
 	public.GET("/snap-status/:id", s.getStatus)
 	public.POST("/acl/verify/", s.verifyACL)
 
+	// the craft-store flow of newer snapcraft releases
+	tokens := r.Group("/v1/tokens")
+	tokens.POST("", s.postTokens)
+	tokens.POST("/exchange", s.exchangeTokens)
+	tokens.GET("/whoami", checkForAuthorizedUser, s.whoAmI)
+
 	private := r.Group("/dev/api")
 	private.Use(checkForAuthorizedUser)
 
-	private.GET("/account", s.getAccount)
-	private.POST("/register-name", s.registerSnapName)
+	// snapcraft register-key and list-keys log in with modify_account_key only
+	private.GET("/account", requirePermission(auth.PermissionPackageAccess, auth.PermissionModifyAccountKey), s.getAccount)
+	private.POST("/register-name", requirePermission(auth.PermissionPackageRegister), s.registerSnapName)
+	private.POST("/register-name-dispute", requirePermission(auth.PermissionPackageRegister), s.disputeSnapName)
+
+	private.POST("/account/account-key", requirePermission(auth.PermissionModifyAccountKey), s.addAccountKey)
+	private.DELETE("/account/account-key/:key", requirePermission(auth.PermissionModifyAccountKey), s.revokeAccountKey)
+	private.GET("/account/ssh-keys", requirePermission(auth.PermissionPackageAccess), s.getSSHKeys)
+	private.POST("/account/ssh-keys", requirePermission(auth.PermissionModifyAccountKey), s.addSSHKey)
+	private.DELETE("/account/ssh-keys", requirePermission(auth.PermissionModifyAccountKey), s.deleteSSHKey)
+	private.POST("/snap-push", requirePermission(auth.PermissionPackagePush), s.pushSnap)
+	private.POST("/snap-release", requirePermission(auth.PermissionPackageRelease), s.snapRelease)
 
-	private.POST("/account/account-key", s.addAccountKey)
-	private.POST("/snap-push", s.pushSnap)
-	private.POST("/snap-release", s.snapRelease)
+	private.GET("/snaps/:id/binary-metadata", requirePermission(auth.PermissionPackageAccess), s.getBinaryMetadata)
+	private.POST("/snaps/:id/binary-metadata", requirePermission(auth.PermissionPackageUpdate), s.updateBinaryMetadata)
+	private.PUT("/snaps/:id/binary-metadata", requirePermission(auth.PermissionPackageUpdate), s.updateBinaryMetadata)
+	private.GET("/snaps/:id/metadata", requirePermission(auth.PermissionPackageAccess), s.getMetadata)
+	private.POST("/snaps/:id/metadata", requirePermission(auth.PermissionPackageUpdate), s.updateMetadata)
+	private.PUT("/snaps/:id/metadata", requirePermission(auth.PermissionPackageUpdate), s.updateMetadata)
+	private.GET("/snaps/:id/developers", requirePermission(auth.PermissionPackageAccess), s.getSnapDevelopers)
+	private.POST("/snaps/:id/developers", requirePermission(auth.PermissionPackageManage), s.updateSnapDevelopers)
+	private.PUT("/snaps/:id/developers", requirePermission(auth.PermissionPackageManage), s.updateSnapDevelopers)
+	private.DELETE("/snaps/:id/developers/:account", requirePermission(auth.PermissionPackageManage), s.removeSnapDeveloper)
 
 	apiV2Private := r.Group("/api/v2")
 	apiV2Private.Use(checkForAuthorizedUser)
-	apiV2Private.GET("/snaps/:snap/channel-map", s.getSnapChannelMap)
+	apiV2Private.GET("/snaps/:snap/channel-map", requirePermission(auth.PermissionPackageAccess), s.getSnapChannelMap)
+	apiV2Private.GET("/tokens/whoami", s.whoAmI)
+
+	// the publisher gateway API of newer snapcraft releases
+	v1Private := r.Group("/v1/snap")
+	v1Private.Use(checkForAuthorizedUser)
+	v1Private.POST("/:snap/revisions", requirePermission(auth.PermissionPackagePush), s.postRevision)
+	v1Private.GET("/:snap/revisions", requirePermission(auth.PermissionPackageAccess), s.getRevisions)
+	v1Private.POST("/:snap/releases", requirePermission(auth.PermissionPackageRelease), s.postReleases)
+	v1Private.GET("/:snap/releases", requirePermission(auth.PermissionPackageAccess), s.getReleases)
 
 	// TODO: implement /api/v2/snaps/<snap-name>/releases for `snapcraft list-revisions <snap-name>`
 }
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/freetocompute/kebe/config/configkey"
+	"github.com/freetocompute/kebe/pkg/auth"
+	"github.com/freetocompute/kebe/pkg/dashboard/responses"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+func newErrorList(code string, message string) *responses.ErrorList {
+	return &responses.ErrorList{
+		ErrorList: []responses.Error{
+			{Code: code, Message: message},
+		},
+	}
+}
+
+// abortWithHandlerError aborts with a status and error list matching the error returned by the handler
+func abortWithHandlerError(c *gin.Context, err error) {
+	if err == nil {
+		logrus.Error("unknown error encountered")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	logrus.Error(err)
+
+	var conflictErr *MetadataConflictError
+	if errors.As(err, &conflictErr) {
+		errorList := &responses.ErrorList{}
+		for _, conflict := range conflictErr.Conflicts {
+			errorList.ErrorList = append(errorList.ErrorList, responses.Error{
+				Code:    "conflict",
+				Message: "conflict in " + conflict.Name,
+				Extra:   conflict,
+			})
+		}
+
+		c.AbortWithStatusJSON(http.StatusConflict, errorList)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrSnapNameRegistered):
+		abortWithRegisterNameError(c, "already_registered", err)
+	case errors.Is(err, ErrSnapNameReserved):
+		abortWithRegisterNameError(c, "reserved_name", err)
+	case errors.Is(err, ErrSnapNameOwned):
+		c.AbortWithStatusJSON(http.StatusConflict, newErrorList("already_owned", err.Error()))
+	case errors.Is(err, ErrSnapNameInvalid):
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid", err.Error()))
+	case errors.Is(err, ErrSnapNotFound), errors.Is(err, ErrAccountNotFound), errors.Is(err, ErrAccountKeyNotFound),
+		errors.Is(err, ErrUploadNotFound), errors.Is(err, ErrDeveloperNotFound), errors.Is(err, ErrSSHKeyNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, newErrorList("resource-not-found", err.Error()))
+	case errors.Is(err, ErrSSHKeyExists):
+		c.AbortWithStatusJSON(http.StatusConflict, newErrorList("already-exists", err.Error()))
+	case errors.Is(err, ErrAccountSuspended):
+		c.AbortWithStatusJSON(http.StatusForbidden, newErrorList("account-suspended", err.Error()))
+	case errors.Is(err, ErrForbidden):
+		c.AbortWithStatusJSON(http.StatusForbidden, newErrorList("macaroon-permission-required", err.Error()))
+	case errors.Is(err, ErrInvalidAccountKeyRequest), errors.Is(err, ErrInvalidACLRequest), errors.Is(err, ErrInvalidReleaseRequest),
+		errors.Is(err, ErrInvalidDeveloperRequest), errors.Is(err, ErrInvalidDisputeRequest), errors.Is(err, ErrInvalidMetadata),
+		errors.Is(err, ErrInvalidBinaryMetadata), errors.Is(err, auth.ErrInvalidSSHKey):
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-request", err.Error()))
+	default:
+		// database and object store failures aren't the client's fault, their details stay in the log
+		c.AbortWithStatusJSON(http.StatusInternalServerError, newErrorList("internal-error", "internal server error"))
+	}
+}
+
+// abortWithRegisterNameError aborts with one of the register-name error codes snapcraft understands. Snapcraft
+// shows the publisher the page explaining how to dispute the name if one is configured.
+func abortWithRegisterNameError(c *gin.Context, code string, err error) {
+	c.AbortWithStatusJSON(http.StatusConflict, &responses.RegisterNameErrorList{
+		ErrorList:       newErrorList(code, err.Error()).ErrorList,
+		RegisterNameURL: viper.GetString(configkey.DashboardRegisterNameURL),
+	})
+}
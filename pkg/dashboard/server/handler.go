@@ -4,19 +4,29 @@ import (
 	bytes2 "bytes"
 	"context"
 	"crypto"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	generatedResponses "github.com/freetocompute/kebe/generated/responses"
 
 	store "github.com/freetocompute/kebe/pkg/store/responses"
 
+	"github.com/freetocompute/kebe/pkg/assertions"
+	"github.com/freetocompute/kebe/pkg/media"
 	"github.com/freetocompute/kebe/pkg/models"
 	"github.com/freetocompute/kebe/pkg/objectstore"
 	"github.com/freetocompute/kebe/pkg/sha"
+	"github.com/freetocompute/kebe/pkg/snap"
 	"github.com/minio/minio-go/v7"
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/snap/naming"
 
 	"github.com/freetocompute/kebe/config"
 	"github.com/freetocompute/kebe/config/configkey"
@@ -39,25 +49,79 @@ type IDashboardHandler interface {
 	VerifyACL(verify *requests.Verify) (*responses.Verify, error)
 	GetAccount(accountEmail string) (*responses.AccountInfo, error)
 	RegisterSnapName(accountEmail string, dryRun bool, snapName string) (*responses.RegisterSnap, error)
-	AddAccountKey(accountEmail string, keyName string, publicKeyId string, pubKeyEncoded string) (*models.Key, error)
-	GetACLMacaroon(acl string) (*macaroonv2.Macaroon, error)
+	DisputeSnapName(accountEmail string, snapName string, comment string) (*responses.SnapNameDispute, error)
+	AddAccountKey(accountEmail string, accountKeyRequest *asserts.AccountKeyRequest) (*models.Key, error)
+	RevokeAccountKey(accountEmail string, publicKeySHA3384 string) (*models.Key, error)
+	GetSSHKeys(accountEmail string) (*[]responses.SSHKey, error)
+	AddSSHKey(accountEmail string, publicKey string) (*responses.SSHKey, error)
+	DeleteSSHKey(accountEmail string, fingerprint string) (*[]responses.SSHKey, error)
+	GetACLMacaroon(aclRequest *requests.ACLRequest) (*macaroonv2.Macaroon, error)
+	GetTokenMacaroon(tokenRequest *requests.TokenRequest) (*macaroonv2.Macaroon, error)
+	ExchangeMacaroons(macaroons []*macaroonv2.Macaroon) (*macaroonv2.Macaroon, error)
+	WhoAmI(acl *auth.ACL) (*responses.WhoAmI, error)
 	GetUploadStatus(upDownId string) (*responses.Status, error)
-	PushSnap(snapName string, upDownId string, fileSize uint, channels []string) (*store.Upload, error)
-	ReleaseSnap(name string, revision uint, channels []string) (bool, error)
-	GetSnapChannelMap(snapName string) (*generatedResponses.Root, error)
+	NotifyRevision(acl *auth.ACL, snapName string, uploadId string) (*responses.RevisionStatusURL, error)
+	GetRevisionStatus(acl *auth.ACL, snapName string, uploadId string) (*responses.RevisionsStatus, error)
+	ReleaseRevisions(acl *auth.ACL, snapName string, releases []requests.ReleaseRequest) (*responses.Released, error)
+	GetSnapReleases(acl *auth.ACL, snapName string) (*responses.SnapReleases, error)
+	PushSnap(acl *auth.ACL, snapName string, upDownId string, fileSize uint, channels []string) (*store.Upload, error)
+	ReleaseSnap(acl *auth.ACL, name string, revision uint, channels []string) (bool, error)
+	GetSnapChannelMap(acl *auth.ACL, snapName string) (*generatedResponses.Root, error)
+	GetBinaryMetadata(acl *auth.ACL, snapId string) (*[]responses.BinaryMetadata, error)
+	UpdateBinaryMetadata(acl *auth.ACL, snapId string, replaceAll bool, info []requests.BinaryMetadataInfo, files map[string][]byte) (*[]responses.BinaryMetadata, error)
+	GetMetadata(acl *auth.ACL, snapId string) (*responses.SnapMetadata, error)
+	UpdateMetadata(acl *auth.ACL, snapId string, force bool, update *requests.SnapMetadata) (*responses.SnapMetadata, error)
+	GetSnapDevelopers(acl *auth.ACL, snapId string) (*responses.SnapDevelopers, error)
+	UpdateSnapDevelopers(acl *auth.ACL, snapId string, update *requests.SnapDevelopers) (*responses.SnapDevelopers, error)
+	RemoveSnapDeveloper(acl *auth.ACL, snapId string, accountId string) (*responses.SnapDevelopers, error)
+}
+
+var (
+	ErrSnapNotFound             = errors.New("snap not found")
+	ErrForbidden                = errors.New("account does not have access to this snap")
+	ErrAccountNotFound          = errors.New("account not found")
+	ErrAccountKeyNotFound       = errors.New("account key not found")
+	ErrInvalidAccountKeyRequest = errors.New("invalid account-key-request")
+	ErrInvalidACLRequest        = errors.New("invalid acl request")
+	ErrUploadNotFound           = errors.New("upload not found")
+	ErrInvalidReleaseRequest    = errors.New("invalid release request")
+	ErrInvalidDeveloperRequest  = errors.New("invalid developers request")
+	ErrDeveloperNotFound        = errors.New("developer not found")
+	ErrSSHKeyExists             = errors.New("ssh key already exists")
+	ErrSSHKeyNotFound           = errors.New("ssh key not found")
+	ErrAccountSuspended         = errors.New("account is suspended")
+	ErrSnapNameInvalid          = errors.New("invalid snap name")
+	ErrSnapNameRegistered       = errors.New("snap name is already registered")
+	ErrSnapNameOwned            = errors.New("snap name is already registered by the account")
+	ErrSnapNameReserved         = errors.New("snap name is reserved")
+	ErrInvalidDisputeRequest    = errors.New("invalid name dispute request")
+	ErrInvalidMetadata          = errors.New("invalid metadata")
+	ErrInvalidBinaryMetadata    = errors.New("invalid binary metadata")
+)
+
+// MetadataConflictError is returned when a metadata update conflicts with the values in the store
+type MetadataConflictError struct {
+	Conflicts []responses.MetadataConflict
+}
+
+func (e *MetadataConflictError) Error() string {
+	return fmt.Sprintf("metadata update conflicts with %d field(s) in the store", len(e.Conflicts))
 }
 
 type DashboardHandler struct {
-	accounts repositories.IAccountRepository
-	snaps    repositories.ISnapsRepository
+	accounts  repositories.IAccountRepository
+	snaps     repositories.ISnapsRepository
+	tokens    repositories.IDischargeTokensRepository
+	snapNames repositories.ISnapNamesRepository
 }
 
-func NewDashboardHandler(accts repositories.IAccountRepository, snaps repositories.ISnapsRepository) *DashboardHandler {
-	return &DashboardHandler{accounts: accts, snaps: snaps}
+func NewDashboardHandler(accts repositories.IAccountRepository, snaps repositories.ISnapsRepository, tokens repositories.IDischargeTokensRepository,
+	snapNames repositories.ISnapNamesRepository) *DashboardHandler {
+	return &DashboardHandler{accounts: accts, snaps: snaps, tokens: tokens, snapNames: snapNames}
 }
 
-func (d *DashboardHandler) GetSnapChannelMap(snapName string) (*generatedResponses.Root, error) {
-	snap, err := d.snaps.GetSnap(snapName, false)
+func (d *DashboardHandler) GetSnapChannelMap(acl *auth.ACL, snapName string) (*generatedResponses.Root, error) {
+	snap, err := d.getOwnedSnapByName(acl, snapName, auth.PermissionPackageAccess, nil)
 	if err == nil && snap != nil {
 		var root generatedResponses.Root
 		var channelMapItems []*generatedResponses.ChannelMapItems
@@ -133,9 +197,9 @@ func (d *DashboardHandler) GetSnapChannelMap(snapName string) (*generatedRespons
 	panic("unknown error encountered")
 }
 
-func (d *DashboardHandler) ReleaseSnap(name string, revision uint, channels []string) (bool, error) {
+func (d *DashboardHandler) ReleaseSnap(acl *auth.ACL, name string, revision uint, channels []string) (bool, error) {
 	if name != "" && revision != 0 && len(channels) > 0 {
-		snapEntry, err := d.snaps.GetSnap(name, false)
+		snapEntry, err := d.getOwnedSnapByName(acl, name, auth.PermissionPackageRelease, channels)
 		if err == nil && snapEntry != nil {
 			var trackForRelease string
 			var riskForRelease string
@@ -152,8 +216,9 @@ func (d *DashboardHandler) ReleaseSnap(name string, revision uint, channels []st
 					trackForRelease = parts[0]
 					riskForRelease = parts[1]
 				} else if len(parts) == 3 {
-					logrus.Error(errors.New("branches not supported yet"))
-					return false, errors.New("branches not supported yet")
+					branchesErr := fmt.Errorf("%w: branches not supported yet", ErrInvalidReleaseRequest)
+					logrus.Error(branchesErr)
+					return false, branchesErr
 				}
 
 				track, err2 := d.snaps.SetChannelRevision(trackForRelease, riskForRelease, revision, snapEntry.ID)
@@ -175,7 +240,7 @@ func (d *DashboardHandler) ReleaseSnap(name string, revision uint, channels []st
 			return false, err
 		}
 	} else {
-		emptyErr := errors.New("all fields must be non-empty")
+		emptyErr := fmt.Errorf("%w: all fields must be non-empty", ErrInvalidReleaseRequest)
 		logrus.Error(emptyErr)
 		return false, emptyErr
 	}
@@ -185,8 +250,28 @@ func (d *DashboardHandler) ReleaseSnap(name string, revision uint, channels []st
 	return false, unknownError
 }
 
-func (d *DashboardHandler) PushSnap(snapName string, upDownId string, fileSize uint, channels []string) (*store.Upload, error) {
-	snapUpload, err := d.snaps.AddUpload(snapName, upDownId, fileSize, channels)
+func (d *DashboardHandler) PushSnap(acl *auth.ACL, snapName string, upDownId string, fileSize uint, channels []string) (*store.Upload, error) {
+	snapEntry, err := d.getOwnedSnapByName(acl, snapName, auth.PermissionPackagePush, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	// the upload is released to the channels once it's processed
+	if len(channels) > 0 {
+		err = d.checkSnapAccess(acl, snapEntry, auth.PermissionPackageRelease, channels)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	account, err := d.accounts.GetAccountByEmail(acl.Email, false)
+	if err != nil {
+		return nil, err
+	} else if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	snapUpload, err := d.snaps.AddUpload(snapName, upDownId, fileSize, channels, account.ID)
 	if err == nil && snapUpload != nil {
 		//// File saved successfully. Return proper result
 		//// TODO: this URL needs to be serviced by a worker thread
@@ -206,18 +291,29 @@ func (d *DashboardHandler) GetUploadStatus(upDownId string) (*responses.Status,
 	// We need to move the snap from the unscanned bucket to the snaps bucket
 	snapUpload, err := d.snaps.GetUpload(upDownId)
 	if err == nil && snapUpload != nil {
+		// the upload was processed by an earlier poll, it's no longer in the unscanned bucket
+		if snapUpload.RevisionID != nil {
+			return &responses.Status{
+				Processed: true,
+				Code:      "ready_to_release",
+				Revision:  int(*snapUpload.RevisionID),
+			}, nil
+		}
+
 		snapFileName := upDownId + ".snap"
 
 		// get the sha3_384 of the file so we can figure out if it already exists as a revision
 		obj, err2 := objectstore.GetMinioClient().GetObject(context.Background(), "unscanned", snapFileName, minio.GetObjectOptions{})
 		if err2 != nil {
-			panic(err2)
+			logrus.Error(err2)
+			return nil, err2
 		}
 
 		bytes, err3 := io.ReadAll(obj)
 		h := crypto.SHA3_384.New()
 		if err3 != nil {
-			panic(err3)
+			logrus.Error(err3)
+			return nil, err3
 		}
 		h.Write(bytes)
 		actualSha3 := fmt.Sprintf("%x", h.Sum(nil))
@@ -248,7 +344,8 @@ func (d *DashboardHandler) GetUploadStatus(upDownId string) (*responses.Status,
 
 			digest, _, err5 := sha.SnapFileSHA3_384FromReader(bytes2.NewReader(bytes))
 			if err5 != nil {
-				panic(err5)
+				logrus.Error(err5)
+				return nil, err5
 			}
 
 			revision = models.SnapRevision{
@@ -257,6 +354,7 @@ func (d *DashboardHandler) GetUploadStatus(upDownId string) (*responses.Status,
 				SHA3_384:       actualSha3,
 				SHA3384Encoded: digest,
 				Size:           int64(snapUpload.Filesize),
+				UploaderID:     snapUpload.AccountID,
 			}
 
 			_, err2 = d.snaps.UpdateRevision(&revision, &bytes)
@@ -264,6 +362,12 @@ func (d *DashboardHandler) GetUploadStatus(upDownId string) (*responses.Status,
 				logrus.Error(err2)
 				return nil, err2
 			}
+
+			// a missing or broken icon shouldn't fail the upload
+			err2 = d.updateIconFromSnap(snapUpload.SnapEntryID, bytes)
+			if err2 != nil {
+				logrus.Error(err2)
+			}
 		} else {
 			logrus.Error(err4)
 			return nil, err4
@@ -272,6 +376,10 @@ func (d *DashboardHandler) GetUploadStatus(upDownId string) (*responses.Status,
 		// TODO: fix lazy
 		channels := strings.Split(snapUpload.Channels, ",")
 		err2 = d.snaps.ReleaseSnap(channels, snapUpload.SnapEntryID, revision.ID)
+		if err2 == nil {
+			err2 = d.snaps.SetUploadRevision(snapUpload.ID, revision.ID)
+		}
+
 		if err2 == nil {
 			resp := &responses.Status{
 				Processed: true,
@@ -290,7 +398,197 @@ func (d *DashboardHandler) GetUploadStatus(upDownId string) (*responses.Status,
 	return nil, err
 }
 
-func (d *DashboardHandler) GetACLMacaroon(acl string) (*macaroonv2.Macaroon, error) {
+// NotifyRevision is PushSnap for the publisher gateway, the upload is processed when its status is polled at the
+// returned path
+func (d *DashboardHandler) NotifyRevision(acl *auth.ACL, snapName string, uploadId string) (*responses.RevisionStatusURL, error) {
+	if uploadId == "" {
+		return nil, fmt.Errorf("%w: upload-id is required", ErrInvalidReleaseRequest)
+	}
+
+	// the publisher gateway doesn't send the size, it's taken from the uploaded file
+	info, err := objectstore.GetMinioClient().StatObject(context.Background(), "unscanned", uploadId+".snap", minio.StatObjectOptions{})
+	if err != nil {
+		logrus.Error(err)
+		return nil, fmt.Errorf("%w: %s", ErrUploadNotFound, uploadId)
+	}
+
+	_, err = d.PushSnap(acl, snapName, uploadId, uint(info.Size), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &responses.RevisionStatusURL{
+		StatusURL: "/v1/snap/" + url.PathEscape(snapName) + "/revisions?upload-id=" + url.QueryEscape(uploadId),
+	}, nil
+}
+
+// GetRevisionStatus is GetUploadStatus for the publisher gateway, the upload has to be for the snap
+func (d *DashboardHandler) GetRevisionStatus(acl *auth.ACL, snapName string, uploadId string) (*responses.RevisionsStatus, error) {
+	snap, err := d.getOwnedSnapByName(acl, snapName, auth.PermissionPackageAccess, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snapUpload, err := d.snaps.GetUpload(uploadId)
+	if err != nil || snapUpload == nil || snapUpload.SnapEntryID != snap.ID {
+		return nil, fmt.Errorf("%w: %s", ErrUploadNotFound, uploadId)
+	}
+
+	status, err := d.GetUploadStatus(uploadId)
+	if err != nil {
+		return nil, err
+	}
+
+	revisionStatus := responses.RevisionStatus{UploadId: uploadId, Status: revisionStatusFor(status)}
+	if status.Processed {
+		revision := status.Revision
+		revisionStatus.Revision = &revision
+	}
+
+	return &responses.RevisionsStatus{Revisions: []responses.RevisionStatus{revisionStatus}}, nil
+}
+
+// revisionStatusFor maps the status of an upload to the status of its revision in the publisher gateway
+func revisionStatusFor(status *responses.Status) string {
+	if !status.Processed {
+		return "processing"
+	}
+
+	if status.Code == "ready_to_release" {
+		return "approved"
+	}
+
+	return "rejected"
+}
+
+// ReleaseRevisions is ReleaseSnap for the publisher gateway, every channel is checked against the ACL before
+// anything is released
+func (d *DashboardHandler) ReleaseRevisions(acl *auth.ACL, snapName string, releases []requests.ReleaseRequest) (*responses.Released, error) {
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("%w: at least one release is required", ErrInvalidReleaseRequest)
+	}
+
+	var channels []string
+	for _, release := range releases {
+		if release.Channel == "" || release.Revision == 0 {
+			return nil, fmt.Errorf("%w: channel and revision are required", ErrInvalidReleaseRequest)
+		}
+
+		channels = append(channels, release.Channel)
+	}
+
+	snap, err := d.getOwnedSnapByName(acl, snapName, auth.PermissionPackageRelease, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	// every revision has to be of the snap, the releases are applied together so either all of them or none are
+	channelRevisions := map[string]uint{}
+	for _, release := range releases {
+		revision, err := d.snaps.GetRevision(release.Revision)
+		if err != nil || revision == nil || revision.SnapEntryID != snap.ID || revision.SnapFilename == "" {
+			return nil, fmt.Errorf("%w: revision %d not found", ErrInvalidReleaseRequest, release.Revision)
+		}
+
+		if strings.Count(release.Channel, "/") > 1 {
+			return nil, fmt.Errorf("%w: branches not supported yet", ErrInvalidReleaseRequest)
+		}
+
+		channelRevisions[release.Channel] = release.Revision
+	}
+
+	err = d.snaps.ReleaseRevisions(snap.ID, channelRevisions)
+	if errors.Is(err, repositories.ErrChannelNotFound) || errors.Is(err, repositories.ErrRevisionNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReleaseRequest, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+
+	released := responses.Released{Released: []responses.ReleasedChannel{}}
+	for _, release := range releases {
+		released.Released = append(released.Released, responses.ReleasedChannel{Channel: release.Channel, Revision: int(release.Revision)})
+	}
+
+	return &released, nil
+}
+
+// GetSnapReleases is the channel map of the snap in the publisher gateway format
+func (d *DashboardHandler) GetSnapReleases(acl *auth.ACL, snapName string) (*responses.SnapReleases, error) {
+	snap, err := d.getOwnedSnapByName(acl, snapName, auth.PermissionPackageAccess, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks, err := d.snaps.GetTracks(snap.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := responses.SnapReleases{
+		ChannelMap: []responses.ReleaseChannelMap{},
+		Package:    responses.ReleasePackage{Channels: []responses.ReleaseChannel{}},
+		Revisions:  []responses.ReleaseRevision{},
+	}
+	seenRevisions := map[uint]bool{}
+	for _, track := range *tracks {
+		risks, err2 := d.snaps.GetRisks(track.ID)
+		if err2 != nil {
+			return nil, err2
+		}
+
+		for _, risk := range *risks {
+			channel := track.Name + "/" + risk.Name
+			releases.Package.Channels = append(releases.Package.Channels, responses.ReleaseChannel{
+				Name:  channel,
+				Track: track.Name,
+				Risk:  risk.Name,
+			})
+
+			revision, err3 := d.snaps.GetRevision(risk.RevisionID)
+			if err3 != nil {
+				return nil, err3
+			}
+
+			// risks are created with an empty revision, nothing has been released to them
+			if revision.SnapFilename == "" {
+				continue
+			}
+
+			releases.ChannelMap = append(releases.ChannelMap, responses.ReleaseChannelMap{
+				Architecture: "amd64",
+				Channel:      channel,
+				Revision:     int(revision.ID),
+				When:         risk.UpdatedAt,
+			})
+
+			if !seenRevisions[revision.ID] {
+				seenRevisions[revision.ID] = true
+				releases.Revisions = append(releases.Revisions, responses.ReleaseRevision{
+					Architectures: []string{"amd64"},
+					Confinement:   "strict",
+					CreatedAt:     revision.CreatedAt,
+					Grade:         "stable",
+					Revision:      int(revision.ID),
+					SHA3384:       revision.SHA3_384,
+					Size:          revision.Size,
+					Status:        "released",
+					Version:       revision.Version,
+				})
+			}
+		}
+	}
+
+	return &releases, nil
+}
+
+// GetACLMacaroon returns a root macaroon restricted to the requested ACL, it still needs a discharge from the
+// login service to be used
+func (d *DashboardHandler) GetACLMacaroon(aclRequest *requests.ACLRequest) (*macaroonv2.Macaroon, error) {
+	acl, err := d.newACL(aclRequest)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO: check these sooner, cache the values and ensure they exist on start-up
 	rootKeyString := config.MustGetString(configkey.MacaroonRootKey)
 	rootMacaroonId := config.MustGetString(configkey.MacaroonRootId)
@@ -305,137 +603,1047 @@ func (d *DashboardHandler) GetACLMacaroon(acl string) (*macaroonv2.Macaroon, err
 	}
 	thirdPartyCaveatId := config.MustGetString(configkey.MacaroonThirdPartyCaveatId)
 	thirdPartLocation := config.MustGetString(configkey.MacaroonThirdPartyLocation)
-	err := m.AddThirdPartyCaveat([]byte(dischargeKeyString), []byte(thirdPartyCaveatId), thirdPartLocation)
+	err = m.AddThirdPartyCaveat([]byte(dischargeKeyString), []byte(thirdPartyCaveatId), thirdPartLocation)
 	if err != nil {
 		panic(err)
 	}
 
-	_ = m.AddFirstPartyCaveat([]byte(acl))
+	for _, caveat := range acl.Caveats() {
+		err = m.AddFirstPartyCaveat([]byte(caveat))
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return m, nil
 }
 
-func (d *DashboardHandler) AddAccountKey(accountEmail string, keyName string, publicKeyId string, pubKeyEncoded string) (*models.Key, error) {
-	if accountEmail != "" && keyName != "" && publicKeyId != "" && pubKeyEncoded != "" {
-		acct, err2 := d.accounts.AddKey(keyName, publicKeyId, pubKeyEncoded, accountEmail)
-		if err2 == nil && acct != nil {
-			return acct, nil
-		} else if err2 != nil {
-			logrus.Error(err2)
-			return nil, err2
+// GetTokenMacaroon returns a root macaroon for the craft-store token request, it's the same macaroon /acl/ issues
+func (d *DashboardHandler) GetTokenMacaroon(tokenRequest *requests.TokenRequest) (*macaroonv2.Macaroon, error) {
+	aclRequest := requests.ACLRequest{
+		Permissions: tokenRequest.Permissions,
+		Channels:    tokenRequest.Channels,
+	}
+
+	if tokenRequest.Packages != nil {
+		aclRequest.Packages = []requests.ACLPackage{}
+		for _, p := range tokenRequest.Packages {
+			if p.Type != "snap" {
+				return nil, fmt.Errorf("%w: unsupported package type %q", ErrInvalidACLRequest, p.Type)
+			}
+
+			aclRequest.Packages = append(aclRequest.Packages, requests.ACLPackage{Name: p.Name})
 		}
 	}
 
-	logrus.Errorf("accountEmail=%s, keyName=%s, publicKeyId=%s and pubKeyEncoded=%s must all be non-empty", accountEmail, keyName, publicKeyId, pubKeyEncoded)
-	return nil, errors.New("email, key name, public key id and public key encoded must all be non-empty")
+	if tokenRequest.TTL < 0 {
+		return nil, fmt.Errorf("%w: ttl must not be negative", ErrInvalidACLRequest)
+	} else if tokenRequest.TTL > 0 {
+		aclRequest.Expires = time.Now().Add(time.Duration(tokenRequest.TTL) * time.Second).UTC().Format(time.RFC3339)
+	}
+
+	return d.GetACLMacaroon(&aclRequest)
 }
 
-func (d *DashboardHandler) RegisterSnapName(accountEmail string, isDryRun bool, snapName string) (*responses.RegisterSnap, error) {
-	if accountEmail != "" {
-		account, err2 := d.accounts.GetAccountByEmail(accountEmail, false)
-		if err2 == nil && account != nil {
-			if !isDryRun {
-				logrus.Trace("This is not a dry run")
-				snap, err3 := d.snaps.AddSnap(snapName, account.ID)
-				if err3 == nil && snap != nil {
-					resp := responses.RegisterSnap{
-						Id:   snap.SnapStoreID,
-						Name: snap.Name,
-					}
+// ExchangeMacaroons verifies a root macaroon and its discharge, and returns a single macaroon carrying the caveats
+// of both. It expires with the discharge's token and is revoked with it.
+func (d *DashboardHandler) ExchangeMacaroons(macaroons []*macaroonv2.Macaroon) (*macaroonv2.Macaroon, error) {
+	if len(macaroons) != 2 {
+		return nil, fmt.Errorf("%w: a root macaroon and its discharge are required", auth.ErrUnauthorized)
+	}
 
-					return &resp, nil
-				}
-			} else {
-				logrus.Trace("This is a dry run")
-				snap, err3 := d.snaps.GetSnap(snapName, false)
-				if err3 == nil && snap == nil {
-					resp := responses.RegisterSnap{
-						Name: snapName,
-					}
-					return &resp, nil
-				}
+	rootKeyString := config.MustGetString(configkey.MacaroonRootKey)
+	acl, err := auth.VerifyMacaroons(rootKeyString, viper.GetString(configkey.MacaroonStoreId), macaroons[0], macaroons[1])
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := middleware.CheckDischargeToken(d.tokens, acl)
+	if err != nil {
+		return nil, err
+	}
+
+	rootMacaroonId := config.MustGetString(configkey.MacaroonRootId)
+	rootMacaroonLocation := config.MustGetString(configkey.MacaroonRootLocation)
+	m := auth.MustNewMacaroon([]byte(rootKeyString), []byte(rootMacaroonId), rootMacaroonLocation, macaroon.V1)
+
+	caveats := append(acl.Caveats(), auth.DischargeCaveats(acl.Email, acl.TokenID, token.ExpiresAt)...)
+	for _, caveat := range caveats {
+		err = m.AddFirstPartyCaveat([]byte(caveat))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// WhoAmI describes the account and ACL of the credentials for craft-store
+func (d *DashboardHandler) WhoAmI(acl *auth.ACL) (*responses.WhoAmI, error) {
+	account, err := d.accounts.GetAccountByEmail(acl.Email, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	whoAmI := responses.WhoAmI{
+		Account: responses.WhoAmIAccount{
+			Email:      account.Email,
+			Id:         account.AccountId,
+			Name:       account.DisplayName,
+			Username:   account.Username,
+			Validation: account.Validation,
+		},
+		Permissions: acl.Permissions,
+	}
+
+	if acl.Channels != nil {
+		whoAmI.Channels = &acl.Channels
+	}
+
+	if acl.SnapIDs != nil {
+		packages := []responses.WhoAmIPackage{}
+		for _, snapId := range acl.SnapIDs {
+			snapEntry, err2 := d.snaps.GetSnapByStoreId(snapId, false)
+			if err2 != nil {
+				return nil, err2
+			}
+
+			// a snap that's gone can't be used with the macaroon anyway
+			if snapEntry != nil {
+				packages = append(packages, responses.WhoAmIPackage{Type: "snap", Name: snapEntry.Name})
 			}
 		}
+
+		whoAmI.Packages = &packages
+	}
+
+	if acl.Expires != nil {
+		expires := acl.Expires.UTC().Format(time.RFC3339)
+		whoAmI.Expires = &expires
 	}
 
-	return nil, errors.New("account not found")
+	return &whoAmI, nil
 }
 
-func (d *DashboardHandler) GetAccount(accountEmail string) (*responses.AccountInfo, error) {
-	if accountEmail != "" {
-		account, err := d.accounts.GetAccountByEmail(accountEmail, true)
+// newACL validates the ACL request, the packages are turned into the ids of the snaps
+func (d *DashboardHandler) newACL(aclRequest *requests.ACLRequest) (*auth.ACL, error) {
+	if len(aclRequest.Permissions) == 0 {
+		return nil, fmt.Errorf("%w: at least one permission is required", ErrInvalidACLRequest)
+	}
 
-		if err == nil {
-			accountInfoResponse := responses.AccountInfo{
-				AccountId:   account.AccountId,
-				Snaps:       map[string]map[string]responses.Snap{},
-				AccountKeys: []responses.Key{},
+	for _, permission := range aclRequest.Permissions {
+		if !auth.IsValidPermission(permission) {
+			return nil, fmt.Errorf("%w: unknown permission %s", ErrInvalidACLRequest, permission)
+		}
+	}
+
+	for _, channel := range aclRequest.Channels {
+		parts := strings.Split(channel, "/")
+		if channel == "" || len(parts) > 3 || strings.Contains(channel, ",") {
+			return nil, fmt.Errorf("%w: invalid channel %q", ErrInvalidACLRequest, channel)
+		}
+	}
+
+	acl := &auth.ACL{
+		Permissions: aclRequest.Permissions,
+		Channels:    aclRequest.Channels,
+		StoreID:     viper.GetString(configkey.MacaroonStoreId),
+	}
+
+	if aclRequest.Packages != nil {
+		acl.SnapIDs = []string{}
+		for _, p := range aclRequest.Packages {
+			if p.Series != "" && p.Series != "16" {
+				return nil, fmt.Errorf("%w: unsupported series %s", ErrInvalidACLRequest, p.Series)
 			}
 
-			for _, k := range account.Keys {
-				accountInfoResponse.AccountKeys = append(accountInfoResponse.AccountKeys, responses.Key{
-					PublicKeySHA384: k.SHA3384,
-					Name:            k.Name,
-				})
+			var snapEntry *models.SnapEntry
+			var err error
+			if p.SnapId != "" {
+				snapEntry, err = d.snaps.GetSnapByStoreId(p.SnapId, false)
+			} else {
+				snapEntry, err = d.snaps.GetSnap(p.Name, false)
 			}
 
-			snaps := map[string]responses.Snap{}
-			for _, s := range account.SnapEntries {
-				// TODO: replace with real data
-				snaps[s.Name] = responses.Snap{
-					Status:  "Approved",
-					SnapId:  s.SnapStoreID,
-					Store:   "Global",
-					Since:   "2016-07-04T23:37:52Z",
-					Private: false,
-				}
+			if err != nil {
+				return nil, err
+			} else if snapEntry == nil {
+				return nil, fmt.Errorf("%w: %s%s", ErrSnapNotFound, p.Name, p.SnapId)
 			}
 
-			accountInfoResponse.Snaps["16"] = snaps
+			acl.SnapIDs = append(acl.SnapIDs, snapEntry.SnapStoreID)
+		}
+	}
 
-			// TODO: this would actually need to be filled in
-			return &accountInfoResponse, nil
+	if aclRequest.Expires != "" {
+		expires, err := parseExpires(aclRequest.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidACLRequest, err.Error())
 		}
 
-		logrus.Error(err)
+		if !expires.After(time.Now()) {
+			return nil, fmt.Errorf("%w: expires is in the past", ErrInvalidACLRequest)
+		}
+
+		acl.Expires = &expires
 	}
 
-	return nil, errors.New("not found")
+	return acl, nil
 }
 
-func (d *DashboardHandler) VerifyACL(verify *requests.Verify) (*responses.Verify, error) {
-	userEmail, err := middleware.VerifyAndGetEmail(verify.AuthData.Authorization)
+// parseExpires accepts the ISO 8601 forms snapcraft sends, a time without a zone is UTC
+func parseExpires(expires string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+		t, err := time.Parse(layout, expires)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse expires %q", expires)
+}
+
+// AddAccountKey registers the key of an account-key-request, the request has to be for the account and be
+// self-signed by the key it registers
+func (d *DashboardHandler) AddAccountKey(accountEmail string, accountKeyRequest *asserts.AccountKeyRequest) (*models.Key, error) {
+	account, err := d.accounts.GetAccountByEmail(accountEmail, false)
 	if err != nil {
-		logrus.Error(err)
 		return nil, err
 	}
 
-	user, err := d.accounts.GetAccountByEmail(*userEmail, false)
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	if accountKeyRequest.AccountID() != account.AccountId {
+		return nil, fmt.Errorf("%w: account-id %s is not the account of the requester", ErrInvalidAccountKeyRequest, accountKeyRequest.AccountID())
+	}
+
+	pubKey, err := assertions.GetPublicKeyFromBody(accountKeyRequest.Body())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAccountKeyRequest, err.Error())
+	}
+
+	err = asserts.SignatureCheck(accountKeyRequest, pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not self-signed by %s: %s", ErrInvalidAccountKeyRequest, pubKey.ID(), err.Error())
+	}
+
+	existing, err := d.accounts.GetKeyBySHA3384(pubKey.ID())
 	if err != nil {
-		logrus.Error(err)
 		return nil, err
 	}
 
-	if user != nil {
-		v := responses.Verify{
-			Allowed:               true,
-			DeviceRefreshRequired: false,
-			RefreshRequired:       false,
-			Account: &responses.VerifyAccount{
-				Email:       user.Email,
-				DisplayName: user.DisplayName,
-				OpenId:      "oid1234",
-				Verified:    true,
-			},
-			Device:      nil,
-			LastAuth:    "2016-05-26T12:53:23Z",
-			Permissions: &[]string{"package_access", "package_manage", "package_push", "package_register", "package_release", "package_update"},
-			SnapIds:     nil,
-			Channels:    nil,
-		}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: key %s is already registered", ErrInvalidAccountKeyRequest, pubKey.ID())
+	}
 
-		return &v, nil
+	encodedPublicKey, err := asserts.EncodePublicKey(pubKey)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("user not found")
+	var until *time.Time
+	if !accountKeyRequest.Until().IsZero() {
+		requestedUntil := accountKeyRequest.Until().UTC()
+		until = &requestedUntil
+	}
+
+	return d.accounts.AddKey(accountKeyRequest.Name(), pubKey.ID(), base64.StdEncoding.EncodeToString(encodedPublicKey), accountEmail, accountKeyRequest.Since().UTC(), until)
+}
+
+// RevokeAccountKey stops the key from being trusted from now on, only the owner of the key can revoke it
+func (d *DashboardHandler) RevokeAccountKey(accountEmail string, publicKeySHA3384 string) (*models.Key, error) {
+	key, err := d.accounts.GetKeyBySHA3384(publicKeySHA3384)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil || key.Account.Email != accountEmail {
+		return nil, fmt.Errorf("%w: %s", ErrAccountKeyNotFound, publicKeySHA3384)
+	}
+
+	return d.accounts.RevokeKey(publicKeySHA3384)
+}
+
+func (d *DashboardHandler) RegisterSnapName(accountEmail string, isDryRun bool, snapName string) (*responses.RegisterSnap, error) {
+	if accountEmail == "" {
+		return nil, ErrAccountNotFound
+	}
+
+	account, err := d.accounts.GetAccountByEmail(accountEmail, false)
+	if err != nil {
+		return nil, err
+	} else if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	if account.IsSuspended() {
+		return nil, ErrAccountSuspended
+	}
+
+	err = d.checkSnapNameAvailable(account, snapName)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDryRun {
+		logrus.Trace("This is a dry run")
+		return &responses.RegisterSnap{Name: snapName}, nil
+	}
+
+	logrus.Trace("This is not a dry run")
+	snap, err := d.snaps.AddSnap(snapName, account.ID)
+	if errors.Is(err, repositories.ErrSnapExists) {
+		// registered by someone else since the name was checked
+		return nil, fmt.Errorf("%w: %s", ErrSnapNameRegistered, snapName)
+	} else if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Registered %s for %s", snap.Name, account.AccountId)
+	return &responses.RegisterSnap{
+		Id:   snap.SnapStoreID,
+		Name: snap.Name,
+	}, nil
+}
+
+// checkSnapNameAvailable returns an error if the account can't register the name, because it doesn't follow snapd's
+// naming rules, is registered already or is reserved for someone else
+func (d *DashboardHandler) checkSnapNameAvailable(account *models.Account, snapName string) error {
+	if naming.ValidateSnap(snapName) != nil {
+		return fmt.Errorf("%w: %q, names are 2 to 40 lowercase letters, digits and single hyphens, with at least one letter", ErrSnapNameInvalid, snapName)
+	}
+
+	existing, err := d.snaps.GetSnap(snapName, false)
+	if err != nil {
+		return err
+	} else if existing != nil && existing.AccountID == account.ID {
+		return fmt.Errorf("%w: %s", ErrSnapNameOwned, snapName)
+	} else if existing != nil {
+		return fmt.Errorf("%w: %s", ErrSnapNameRegistered, snapName)
+	}
+
+	reservedName, err := d.snapNames.GetReservedName(snapName)
+	if err != nil {
+		return err
+	} else if reservedName != nil && !reservedName.IsReservedFor(account.ID) {
+		return fmt.Errorf("%w: %s", ErrSnapNameReserved, snapName)
+	}
+
+	return nil
+}
+
+// DisputeSnapName records the account's claim to a name registered by another account or reserved, for an admin
+// to resolve. Disputing a name the account already has an open dispute for returns that dispute.
+func (d *DashboardHandler) DisputeSnapName(accountEmail string, snapName string, comment string) (*responses.SnapNameDispute, error) {
+	if accountEmail == "" {
+		return nil, ErrAccountNotFound
+	}
+
+	account, err := d.accounts.GetAccountByEmail(accountEmail, false)
+	if err != nil {
+		return nil, err
+	} else if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	err = d.checkSnapNameAvailable(account, snapName)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %s is available, register it instead", ErrInvalidDisputeRequest, snapName)
+	} else if !errors.Is(err, ErrSnapNameRegistered) && !errors.Is(err, ErrSnapNameReserved) {
+		return nil, err
+	}
+
+	dispute, err := d.snapNames.GetOpenDispute(snapName, account.ID)
+	if err == nil && dispute == nil {
+		dispute, err = d.snapNames.AddDispute(snapName, account.ID, comment)
+		if err == nil {
+			logrus.Infof("%s disputed the name %s", account.AccountId, snapName)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &responses.SnapNameDispute{
+		Id:      dispute.ID,
+		Name:    dispute.Name,
+		Status:  dispute.Status,
+		Comment: dispute.Comment,
+	}, nil
+}
+
+func (d *DashboardHandler) GetAccount(accountEmail string) (*responses.AccountInfo, error) {
+	if accountEmail != "" {
+		account, err := d.accounts.GetAccountByEmail(accountEmail, true)
+
+		if err == nil && account != nil {
+			accountInfoResponse := responses.AccountInfo{
+				AccountId:   account.AccountId,
+				Snaps:       map[string]map[string]responses.Snap{},
+				AccountKeys: []responses.Key{},
+			}
+
+			// revoked and expired keys are listed too, their until says they are no longer valid
+			for _, k := range account.Keys {
+				accountInfoResponse.AccountKeys = append(accountInfoResponse.AccountKeys, responses.Key{
+					PublicKeySHA384: k.SHA3384,
+					Name:            k.Name,
+					Since:           k.Since,
+					Until:           k.Until,
+				})
+			}
+
+			// the snaps of the account's organizations are listed with its own
+			organizationSnaps, err2 := d.accounts.GetOrganizationSnaps(account.ID)
+			if err2 != nil {
+				logrus.Error(err2)
+				return nil, err2
+			}
+
+			snaps := map[string]responses.Snap{}
+			for _, s := range append(account.SnapEntries, *organizationSnaps...) {
+				// TODO: replace with real data
+				snaps[s.Name] = responses.Snap{
+					Status:  "Approved",
+					SnapId:  s.SnapStoreID,
+					Store:   "Global",
+					Since:   "2016-07-04T23:37:52Z",
+					Private: false,
+				}
+			}
+
+			accountInfoResponse.Snaps["16"] = snaps
+
+			// TODO: this would actually need to be filled in
+			return &accountInfoResponse, nil
+		} else if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+	}
+
+	return nil, ErrAccountNotFound
+}
+
+// GetSSHKeys lists the keys snap create-user and console-conf install for the account
+func (d *DashboardHandler) GetSSHKeys(accountEmail string) (*[]responses.SSHKey, error) {
+	account, err := d.accounts.GetAccountByEmail(accountEmail, false)
+	if err != nil {
+		return nil, err
+	} else if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return d.getSSHKeys(account)
+}
+
+// AddSSHKey adds a key to the account, a key with the same fingerprint can only be added once whatever its comment
+func (d *DashboardHandler) AddSSHKey(accountEmail string, publicKey string) (*responses.SSHKey, error) {
+	account, err := d.accounts.GetAccountByEmail(accountEmail, false)
+	if err != nil {
+		return nil, err
+	} else if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	normalized, fingerprint, err := auth.ParseSSHPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := d.accounts.GetSSHKeyByFingerprint(fingerprint)
+	if err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSSHKeyExists, fingerprint)
+	}
+
+	sshKey, err := d.accounts.AddSSHKey(account.ID, normalized, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	sshKeyResp := toSSHKeyResponse(sshKey)
+	return &sshKeyResp, nil
+}
+
+// DeleteSSHKey removes the account's key with the fingerprint, the remaining keys are returned
+func (d *DashboardHandler) DeleteSSHKey(accountEmail string, fingerprint string) (*[]responses.SSHKey, error) {
+	account, err := d.accounts.GetAccountByEmail(accountEmail, false)
+	if err != nil {
+		return nil, err
+	} else if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	deleted, err := d.accounts.DeleteSSHKey(account.ID, fingerprint)
+	if err != nil {
+		return nil, err
+	} else if !deleted {
+		return nil, fmt.Errorf("%w: %s", ErrSSHKeyNotFound, fingerprint)
+	}
+
+	return d.getSSHKeys(account)
+}
+
+func (d *DashboardHandler) getSSHKeys(account *models.Account) (*[]responses.SSHKey, error) {
+	sshKeys, err := d.accounts.GetSSHKeys(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sshKeyResponses := []responses.SSHKey{}
+	for i := range *sshKeys {
+		sshKeyResponses = append(sshKeyResponses, toSSHKeyResponse(&(*sshKeys)[i]))
+	}
+
+	return &sshKeyResponses, nil
+}
+
+func toSSHKeyResponse(sshKey *models.SSHKey) responses.SSHKey {
+	return responses.SSHKey{
+		PublicKey:   sshKey.PublicKeyString,
+		Fingerprint: sshKey.Fingerprint,
+		CreatedAt:   sshKey.CreatedAt,
+	}
+}
+
+func (d *DashboardHandler) VerifyACL(verify *requests.Verify) (*responses.Verify, error) {
+	acl, err := middleware.VerifyMacaroons(d.tokens, verify.AuthData.Authorization)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+
+	user, err := d.accounts.GetAccountByEmail(acl.Email, false)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+
+	if user != nil {
+		v := responses.Verify{
+			Allowed:               true,
+			DeviceRefreshRequired: false,
+			RefreshRequired:       false,
+			Account: &responses.VerifyAccount{
+				Email:       user.Email,
+				DisplayName: user.DisplayName,
+				OpenId:      "oid1234",
+				Verified:    true,
+			},
+			Device:      nil,
+			LastAuth:    "2016-05-26T12:53:23Z",
+			Permissions: &acl.Permissions,
+		}
+
+		if acl.SnapIDs != nil {
+			v.SnapIds = &acl.SnapIDs
+		}
+
+		if acl.Channels != nil {
+			v.Channels = &acl.Channels
+		}
+
+		if acl.Expires != nil {
+			expires := acl.Expires.UTC().Format(time.RFC3339)
+			v.Expires = &expires
+		}
+
+		return &v, nil
+	}
+
+	return nil, errors.New("user not found")
+}
+
+func (d *DashboardHandler) GetBinaryMetadata(acl *auth.ACL, snapId string) (*[]responses.BinaryMetadata, error) {
+	snapEntry, err := d.getOwnedSnap(acl, snapId, auth.PermissionPackageAccess, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.getBinaryMetadata(snapEntry.ID)
+}
+
+// UpdateBinaryMetadata sets the media for a snap. When replaceAll is true info is the complete set of media
+// for the snap, otherwise only the media types named in info are replaced.
+func (d *DashboardHandler) UpdateBinaryMetadata(acl *auth.ACL, snapId string, replaceAll bool, info []requests.BinaryMetadataInfo, files map[string][]byte) (*[]responses.BinaryMetadata, error) {
+	snapEntry, err := d.getOwnedSnap(acl, snapId, auth.PermissionPackageUpdate, false)
+	if err != nil {
+		return nil, err
+	}
+
+	existingMedia, err := d.snaps.GetMedia(snapEntry.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	existingByHash := map[string]models.SnapMedia{}
+	for _, m := range *existingMedia {
+		existingByHash[m.SHA3_384] = m
+	}
+
+	var mediaTypes []string
+	if replaceAll {
+		mediaTypes = []string{models.MediaTypeIcon, models.MediaTypeScreenshot, models.MediaTypeBanner, models.MediaTypeBannerIcon}
+	}
+
+	var newMedia []models.SnapMedia
+	positions := map[string]int{}
+	for _, entry := range info {
+		if !models.IsValidMediaType(entry.Type) {
+			return nil, fmt.Errorf("%w: unsupported media type: %s", ErrInvalidBinaryMetadata, entry.Type)
+		}
+
+		if !replaceAll && !contains(mediaTypes, entry.Type) {
+			mediaTypes = append(mediaTypes, entry.Type)
+		}
+
+		var snapMedia *models.SnapMedia
+		if entry.Key != "" {
+			fileBytes, ok := files[entry.Key]
+			if !ok {
+				return nil, fmt.Errorf("%w: no file was provided for %s", ErrInvalidBinaryMetadata, entry.Key)
+			}
+
+			snapMedia, err = media.NewSnapMedia(snapEntry.ID, entry.Type, entry.Filename, fileBytes)
+			if err != nil {
+				return nil, err
+			}
+
+			if entry.Hash != "" && entry.Hash != snapMedia.SHA3_384 {
+				return nil, fmt.Errorf("%w: hash for %s does not match the file provided", ErrInvalidBinaryMetadata, entry.Filename)
+			}
+
+			err = media.Save(snapMedia, fileBytes)
+			if err != nil {
+				return nil, err
+			}
+		} else if existing, ok := existingByHash[entry.Hash]; ok {
+			snapMedia = &existing
+			snapMedia.Type = entry.Type
+		} else {
+			return nil, fmt.Errorf("%w: no file was provided for %s and no existing media has hash %s", ErrInvalidBinaryMetadata, entry.Filename, entry.Hash)
+		}
+
+		if entry.Type == models.MediaTypeIcon && positions[entry.Type] > 0 {
+			return nil, fmt.Errorf("%w: a snap can only have one icon", ErrInvalidBinaryMetadata)
+		}
+
+		snapMedia.Position = positions[entry.Type]
+		positions[entry.Type]++
+		newMedia = append(newMedia, *snapMedia)
+	}
+
+	err = d.snaps.ReplaceMedia(snapEntry.ID, mediaTypes, newMedia)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+
+	return d.getBinaryMetadata(snapEntry.ID)
+}
+
+func (d *DashboardHandler) getBinaryMetadata(snapEntryId uint) (*[]responses.BinaryMetadata, error) {
+	snapMedia, err := d.snaps.GetMedia(snapEntryId)
+	if err != nil {
+		return nil, err
+	}
+
+	binaryMetadata := []responses.BinaryMetadata{}
+	for _, m := range *snapMedia {
+		binaryMetadata = append(binaryMetadata, responses.BinaryMetadata{
+			Type:     m.Type,
+			Hash:     m.SHA3_384,
+			Filename: m.OriginalFilename,
+			URL:      m.URL(),
+			Width:    m.Width,
+			Height:   m.Height,
+		})
+	}
+
+	return &binaryMetadata, nil
+}
+
+// updateIconFromSnap replaces the icon of the snap with the one in meta/gui of the uploaded snap, if it has one
+func (d *DashboardHandler) updateIconFromSnap(snapEntryId uint, snapBytes []byte) error {
+	icon, err := snap.GetSnapIconFromBytes(snapBytes, "/tmp")
+	if err != nil || icon == nil {
+		return err
+	}
+
+	snapMedia, err := media.NewSnapMedia(snapEntryId, models.MediaTypeIcon, icon.Filename, icon.Bytes)
+	if err != nil {
+		return err
+	}
+
+	err = media.Save(snapMedia, icon.Bytes)
+	if err != nil {
+		return err
+	}
+
+	return d.snaps.ReplaceMedia(snapEntryId, []string{models.MediaTypeIcon}, []models.SnapMedia{*snapMedia})
+}
+
+// getOwnedSnap returns the snap with the given store id if it belongs to the account of the ACL, or the account
+// collaborates on it with the permission, and the ACL grants access to it
+func (d *DashboardHandler) getOwnedSnap(acl *auth.ACL, snapId string, permission string, preloadAssociations bool) (*models.SnapEntry, error) {
+	snapEntry, err := d.snaps.GetSnapByStoreId(snapId, preloadAssociations)
+	if err != nil {
+		return nil, err
+	} else if snapEntry == nil {
+		return nil, ErrSnapNotFound
+	}
+
+	return snapEntry, d.checkSnapAccess(acl, snapEntry, permission, nil)
+}
+
+// getOwnedSnapByName is getOwnedSnap for a snap name, the ACL also has to allow releasing to the channels
+func (d *DashboardHandler) getOwnedSnapByName(acl *auth.ACL, snapName string, permission string, channels []string) (*models.SnapEntry, error) {
+	snapEntry, err := d.snaps.GetSnap(snapName, false)
+	if err != nil {
+		return nil, err
+	} else if snapEntry == nil {
+		return nil, ErrSnapNotFound
+	}
+
+	return snapEntry, d.checkSnapAccess(acl, snapEntry, permission, channels)
+}
+
+// checkSnapAccess lets the publisher of the snap through, other accounts have to be collaborators on the snap with
+// the permission
+func (d *DashboardHandler) checkSnapAccess(acl *auth.ACL, snapEntry *models.SnapEntry, permission string, channels []string) error {
+	account, err := d.accounts.GetAccountByEmail(acl.Email, false)
+	if err != nil {
+		return err
+	} else if account == nil {
+		return ErrAccountNotFound
+	}
+
+	// neither a suspended account nor the collaborators on a suspended account's snaps can push or release
+	if permission == auth.PermissionPackagePush || permission == auth.PermissionPackageRelease {
+		publisher := account
+		if snapEntry.AccountID != account.ID {
+			publisher, err = d.accounts.GetAccount(snapEntry.AccountID, false)
+			if err != nil {
+				return err
+			} else if publisher == nil {
+				return ErrAccountNotFound
+			}
+		}
+
+		if account.IsSuspended() || publisher.IsSuspended() {
+			return ErrAccountSuspended
+		}
+	}
+
+	if !acl.AllowsSnap(snapEntry.SnapStoreID) {
+		return ErrForbidden
+	}
+
+	if snapEntry.AccountID != account.ID {
+		allowed, err2 := d.hasDelegatedPermission(snapEntry, account, permission)
+		if err2 != nil {
+			return err2
+		} else if !allowed {
+			return ErrForbidden
+		}
+	}
+
+	if !acl.AllowsChannels(channels) {
+		return fmt.Errorf("%w: not allowed to release to %s", ErrForbidden, strings.Join(channels, ", "))
+	}
+
+	return nil
+}
+
+// organizationRolePermissions are the permissions the members of an organization have on its snaps
+var organizationRolePermissions = map[string][]string{
+	models.OrganizationRoleViewer: {auth.PermissionPackageAccess, auth.PermissionPackageMetrics},
+	models.OrganizationRoleDeveloper: {auth.PermissionPackageAccess, auth.PermissionPackageMetrics, auth.PermissionPackagePush,
+		auth.PermissionPackageUpdate},
+	models.OrganizationRoleReleaser: {auth.PermissionPackageAccess, auth.PermissionPackageMetrics, auth.PermissionPackagePush,
+		auth.PermissionPackageUpdate, auth.PermissionPackageRelease},
+	models.OrganizationRoleAdmin: {auth.PermissionPackageAccess, auth.PermissionPackageMetrics, auth.PermissionPackagePush,
+		auth.PermissionPackageUpdate, auth.PermissionPackageRelease, auth.PermissionPackageManage},
+}
+
+// hasDelegatedPermission is true if the account isn't the publisher of the snap but has the permission on it, as a
+// member of the organization publishing it or as a collaborator
+func (d *DashboardHandler) hasDelegatedPermission(snapEntry *models.SnapEntry, account *models.Account, permission string) (bool, error) {
+	role, err := d.accounts.GetOrganizationRole(snapEntry.AccountID, account.ID)
+	if err != nil {
+		return false, err
+	} else if contains(organizationRolePermissions[role], permission) {
+		return true, nil
+	}
+
+	collaborator, err := d.snaps.GetCollaborator(snapEntry.ID, account.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return collaborator != nil && collaborator.HasPermission(permission), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *DashboardHandler) GetMetadata(acl *auth.ACL, snapId string) (*responses.SnapMetadata, error) {
+	snapEntry, err := d.getOwnedSnap(acl, snapId, auth.PermissionPackageAccess, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return toMetadataResponse(snapEntry), nil
+}
+
+// UpdateMetadata edits the text metadata of a snap, edited values take precedence over those in snap.yaml.
+// A field conflicts when its current value in the store differs from the value the client says it last
+// saw in conflict_fields. Unless force is set, a field with no conflict_fields entry also conflicts when
+// it was already edited in the store to something else.
+func (d *DashboardHandler) UpdateMetadata(acl *auth.ACL, snapId string, force bool, update *requests.SnapMetadata) (*responses.SnapMetadata, error) {
+	snapEntry, err := d.getOwnedSnap(acl, snapId, auth.PermissionPackageUpdate, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateMetadata(update)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := d.snaps.GetMetadata(snapEntry.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentValues := snapEntry.GetMetadataValues(snapEntry.LatestRevision())
+	storedFields := metadata.Fields()
+
+	// only the fields being updated that the client sent the value it last saw for are compared
+	var conflicts []responses.MetadataConflict
+	for name, value := range update.Fields() {
+		if value == nil || force {
+			continue
+		}
+
+		current := currentValues[name]
+		if previous, ok := update.ConflictFields[name]; ok && previous != current {
+			conflicts = append(conflicts, responses.MetadataConflict{Name: name, Current: current})
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Name < conflicts[j].Name })
+		return nil, &MetadataConflictError{Conflicts: conflicts}
+	}
+
+	for name, value := range update.Fields() {
+		if value != nil {
+			v := *value
+			*storedFields[name] = &v
+		}
+	}
+
+	err = d.snaps.SaveMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	snapEntry.Metadata = metadata
+	return toMetadataResponse(snapEntry), nil
+}
+
+func validateMetadata(update *requests.SnapMetadata) error {
+	if update.Title != nil && utf8.RuneCountInString(*update.Title) > 40 {
+		return fmt.Errorf("%w: title must be 40 characters or less", ErrInvalidMetadata)
+	}
+
+	if update.Summary != nil && utf8.RuneCountInString(*update.Summary) > 128 {
+		return fmt.Errorf("%w: summary must be 128 characters or less", ErrInvalidMetadata)
+	}
+
+	if update.Website != nil && *update.Website != "" {
+		u, err := url.Parse(*update.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("%w: website must be an http or https URL", ErrInvalidMetadata)
+		}
+	}
+
+	return nil
+}
+
+func toMetadataResponse(snapEntry *models.SnapEntry) *responses.SnapMetadata {
+	values := snapEntry.GetMetadataValues(snapEntry.LatestRevision())
+	return &responses.SnapMetadata{
+		SnapId:      snapEntry.SnapStoreID,
+		Title:       values[models.MetadataFieldTitle],
+		Summary:     values[models.MetadataFieldSummary],
+		Description: values[models.MetadataFieldDescription],
+		Contact:     values[models.MetadataFieldContact],
+		Website:     values[models.MetadataFieldWebsite],
+		License:     values[models.MetadataFieldLicense],
+		Category:    values[models.MetadataFieldCategory],
+	}
+}
+
+// collaboratorPermissions are the permissions a collaborator can be given on a snap, managing the snap and who
+// collaborates on it is left to the publisher
+var collaboratorPermissions = []string{
+	auth.PermissionPackageAccess,
+	auth.PermissionPackagePush,
+	auth.PermissionPackageRelease,
+	auth.PermissionPackageUpdate,
+}
+
+// GetSnapDevelopers lists the collaborators of a snap, including the ones that were removed
+func (d *DashboardHandler) GetSnapDevelopers(acl *auth.ACL, snapId string) (*responses.SnapDevelopers, error) {
+	snapEntry, err := d.getOwnedSnap(acl, snapId, auth.PermissionPackageAccess, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.getSnapDevelopers(snapEntry)
+}
+
+// UpdateSnapDevelopers adds collaborators to a snap or replaces the permissions of existing ones, only the publisher
+// can do this. Every developer is checked before any is saved.
+func (d *DashboardHandler) UpdateSnapDevelopers(acl *auth.ACL, snapId string, update *requests.SnapDevelopers) (*responses.SnapDevelopers, error) {
+	snapEntry, err := d.getOwnedSnap(acl, snapId, auth.PermissionPackageManage, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(update.Developers) == 0 {
+		return nil, fmt.Errorf("%w: no developers given", ErrInvalidDeveloperRequest)
+	}
+
+	var collaborators []models.SnapCollaborator
+	for _, developer := range update.Developers {
+		var account *models.Account
+		if developer.AccountId != "" {
+			account, err = d.accounts.GetAccountById(developer.AccountId, false)
+		} else if developer.Email != "" {
+			account, err = d.accounts.GetAccountByEmail(developer.Email, false)
+		} else {
+			return nil, fmt.Errorf("%w: account-id or email is required", ErrInvalidDeveloperRequest)
+		}
+
+		if err != nil {
+			return nil, err
+		} else if account == nil {
+			return nil, fmt.Errorf("%w: %s%s", ErrAccountNotFound, developer.AccountId, developer.Email)
+		}
+
+		if account.ID == snapEntry.AccountID {
+			return nil, fmt.Errorf("%w: %s is the publisher of the snap", ErrInvalidDeveloperRequest, account.AccountId)
+		}
+
+		// every collaborator can see the snap
+		permissions := []string{auth.PermissionPackageAccess}
+		for _, permission := range developer.Permissions {
+			if !contains(collaboratorPermissions, permission) {
+				return nil, fmt.Errorf("%w: a collaborator can't be given %s", ErrInvalidDeveloperRequest, permission)
+			}
+
+			if !contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+
+		collaborator, err2 := d.snaps.GetCollaborator(snapEntry.ID, account.ID)
+		if err2 != nil {
+			return nil, err2
+		} else if collaborator == nil {
+			collaborator = &models.SnapCollaborator{
+				SnapEntryID: snapEntry.ID,
+				AccountID:   account.ID,
+				Since:       time.Now().UTC(),
+			}
+		}
+
+		collaborator.Permissions = strings.Join(permissions, ",")
+		collaborators = append(collaborators, *collaborator)
+	}
+
+	for i := range collaborators {
+		err = d.snaps.SaveCollaborator(&collaborators[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d.getSnapDevelopers(snapEntry)
+}
+
+// RemoveSnapDeveloper ends the collaboration of the account on the snap, revisions it uploaded before remain valid
+func (d *DashboardHandler) RemoveSnapDeveloper(acl *auth.ACL, snapId string, accountId string) (*responses.SnapDevelopers, error) {
+	snapEntry, err := d.getOwnedSnap(acl, snapId, auth.PermissionPackageManage, true)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := d.accounts.GetAccountById(accountId, false)
+	if err != nil {
+		return nil, err
+	} else if account == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountId)
+	}
+
+	collaborator, err := d.snaps.GetCollaborator(snapEntry.ID, account.ID)
+	if err != nil {
+		return nil, err
+	} else if collaborator == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDeveloperNotFound, accountId)
+	}
+
+	until := time.Now().UTC()
+	collaborator.Until = &until
+	err = d.snaps.SaveCollaborator(collaborator)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.getSnapDevelopers(snapEntry)
+}
+
+func (d *DashboardHandler) getSnapDevelopers(snapEntry *models.SnapEntry) (*responses.SnapDevelopers, error) {
+	collaborators, err := d.snaps.GetCollaborators(snapEntry.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	developers := responses.SnapDevelopers{
+		SnapId:      snapEntry.SnapStoreID,
+		PublisherId: snapEntry.Account.AccountId,
+		Developers:  []responses.SnapDeveloper{},
+	}
+
+	for _, collaborator := range *collaborators {
+		developers.Developers = append(developers.Developers, responses.SnapDeveloper{
+			AccountId:   collaborator.Account.AccountId,
+			Username:    collaborator.Account.Username,
+			Email:       collaborator.Account.Email,
+			Permissions: collaborator.GetPermissions(),
+			Since:       collaborator.Since,
+			Until:       collaborator.Until,
+		})
+	}
+
+	return &developers, nil
 }
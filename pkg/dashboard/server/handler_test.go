@@ -0,0 +1,181 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+)
+
+// fakeAccounts implements the account lookups and key changes AddAccountKey and RevokeAccountKey make, any other
+// call panics
+type fakeAccounts struct {
+	repositories.IAccountRepository
+	accounts map[string]*models.Account
+	keys     map[string]*models.Key
+}
+
+func (f *fakeAccounts) GetAccountByEmail(email string, preload bool) (*models.Account, error) {
+	return f.accounts[email], nil
+}
+
+func (f *fakeAccounts) GetKeyBySHA3384(sha3384 string) (*models.Key, error) {
+	return f.keys[sha3384], nil
+}
+
+func (f *fakeAccounts) AddKey(name string, SHA3384 string, encodedPublicKey string, email string, since time.Time, until *time.Time) (*models.Key, error) {
+	key := &models.Key{Name: name, SHA3384: SHA3384, EncodedPublicKey: encodedPublicKey, Account: *f.accounts[email], Since: since, Until: until}
+	f.keys[SHA3384] = key
+	return key, nil
+}
+
+func (f *fakeAccounts) RevokeKey(sha3384 string) (*models.Key, error) {
+	now := time.Now().UTC()
+	key := f.keys[sha3384]
+	key.Until = &now
+	return key, nil
+}
+
+func newFakeAccounts() *fakeAccounts {
+	return &fakeAccounts{
+		accounts: map[string]*models.Account{
+			"dev@example.com":   {AccountId: "dev-account", Email: "dev@example.com"},
+			"other@example.com": {AccountId: "other-account", Email: "other@example.com"},
+		},
+		keys: map[string]*models.Key{},
+	}
+}
+
+// newAccountKeyRequest returns an account-key-request for the key signed by signer
+func newAccountKeyRequest(t *testing.T, accountId string, key asserts.PrivateKey, signer asserts.PrivateKey, until time.Time) *asserts.AccountKeyRequest {
+	body, err := asserts.EncodePublicKey(key.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := map[string]interface{}{
+		"account-id":          accountId,
+		"name":                "default",
+		"public-key-sha3-384": key.PublicKey().ID(),
+		"since":               time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	}
+
+	if !until.IsZero() {
+		headers["until"] = until.UTC().Format(time.RFC3339)
+	}
+
+	signed, err := asserts.SignWithoutAuthority(asserts.AccountKeyRequestType, headers, body, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed.(*asserts.AccountKeyRequest)
+}
+
+func TestAddAccountKey(t *testing.T) {
+	key, _ := assertstest.GenerateKey(752)
+	otherKey, _ := assertstest.GenerateKey(752)
+	registeredKey, _ := assertstest.GenerateKey(752)
+	until := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name    string
+		email   string
+		request *asserts.AccountKeyRequest
+		until   *time.Time
+		err     error
+	}{
+		{name: "valid", email: "dev@example.com", request: newAccountKeyRequest(t, "dev-account", key, key, time.Time{})},
+		{name: "valid until", email: "dev@example.com", request: newAccountKeyRequest(t, "dev-account", key, key, until), until: &until},
+		{name: "wrong signer", email: "dev@example.com", request: newAccountKeyRequest(t, "dev-account", key, otherKey, time.Time{}), err: ErrInvalidAccountKeyRequest},
+		{name: "another account", email: "dev@example.com", request: newAccountKeyRequest(t, "other-account", key, key, time.Time{}), err: ErrInvalidAccountKeyRequest},
+		{name: "already registered", email: "dev@example.com", request: newAccountKeyRequest(t, "dev-account", registeredKey, registeredKey, time.Time{}), err: ErrInvalidAccountKeyRequest},
+		{name: "unknown account", email: "unknown@example.com", request: newAccountKeyRequest(t, "dev-account", key, key, time.Time{}), err: ErrAccountNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accounts := newFakeAccounts()
+			accounts.keys[registeredKey.PublicKey().ID()] = &models.Key{SHA3384: registeredKey.PublicKey().ID(), Account: *accounts.accounts["other@example.com"]}
+			handler := NewDashboardHandler(accounts, nil, nil, nil)
+
+			added, err := handler.AddAccountKey(test.email, test.request)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected %v, got %v", test.err, err)
+				}
+
+				if len(accounts.keys) != 1 {
+					t.Errorf("expected no key to be added, got %d keys", len(accounts.keys))
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("AddAccountKey failed: %s", err)
+			}
+
+			if added.SHA3384 != key.PublicKey().ID() || added.Name != "default" {
+				t.Errorf("expected key %s named default, got %s named %s", key.PublicKey().ID(), added.SHA3384, added.Name)
+			}
+
+			if !added.Since.Equal(test.request.Since()) {
+				t.Errorf("expected since %s, got %s", test.request.Since(), added.Since)
+			}
+
+			if (test.until == nil) != (added.Until == nil) || (test.until != nil && !test.until.Equal(*added.Until)) {
+				t.Errorf("expected until %v, got %v", test.until, added.Until)
+			}
+		})
+	}
+}
+
+func TestRevokeAccountKey(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name  string
+		email string
+		keyId string
+		err   error
+	}{
+		{name: "own key", email: "dev@example.com", keyId: "dev-key"},
+		{name: "expired key", email: "dev@example.com", keyId: "expired-key"},
+		{name: "key of another account", email: "other@example.com", keyId: "dev-key", err: ErrAccountKeyNotFound},
+		{name: "unknown key", email: "dev@example.com", keyId: "unknown", err: ErrAccountKeyNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accounts := newFakeAccounts()
+			accounts.keys["dev-key"] = &models.Key{SHA3384: "dev-key", Account: *accounts.accounts["dev@example.com"]}
+			accounts.keys["expired-key"] = &models.Key{SHA3384: "expired-key", Account: *accounts.accounts["dev@example.com"], Until: &expired}
+			handler := NewDashboardHandler(accounts, nil, nil, nil)
+
+			revoked, err := handler.RevokeAccountKey(test.email, test.keyId)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected %v, got %v", test.err, err)
+				}
+
+				if key := accounts.keys["dev-key"]; key.Until != nil {
+					t.Error("expected the key to not be revoked")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("RevokeAccountKey failed: %s", err)
+			}
+
+			if revoked.Until == nil || revoked.IsValidAt(time.Now().Add(time.Second)) {
+				t.Errorf("expected the key to no longer be valid, until is %v", revoked.Until)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/freetocompute/kebe/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// requirePermission only lets requests through if their macaroon grants one of the permissions
+func requirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		acl := middleware.GetACL(c)
+		if acl != nil {
+			for _, permission := range permissions {
+				if acl.HasPermission(permission) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, newErrorList("macaroon-permission-required", "permission "+strings.Join(permissions, " or ")+" is required"))
+	}
+}
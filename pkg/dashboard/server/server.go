@@ -3,14 +3,16 @@ package server
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/freetocompute/kebe/pkg/media"
 	"github.com/freetocompute/kebe/pkg/middleware"
 
-	"github.com/freetocompute/kebe/pkg/assertions"
 	"github.com/snapcore/snapd/asserts"
 
 	"github.com/freetocompute/kebe/pkg/dashboard/requests"
@@ -21,6 +23,7 @@ import (
 	storeRequests "github.com/freetocompute/kebe/pkg/store/requests"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	macaroonv2 "gopkg.in/macaroon.v2"
 )
 
 type Server struct {
@@ -62,14 +65,90 @@ func (s *Server) getAccount(c *gin.Context) {
 			c.JSON(http.StatusOK, &accountInfo)
 			return
 		}
+
+		abortWithHandlerError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
+
+// revokeAccountKey stops one of the account's keys from being trusted, the account-key assertion served for it
+// gets an until of now
+func (s *Server) revokeAccountKey(c *gin.Context) {
+	accountEmail := c.GetString("email")
+	key, err := s.handler.RevokeAccountKey(accountEmail, c.Param("key"))
+	if err == nil && key != nil {
+		c.JSON(http.StatusOK, &responses.Key{
+			PublicKeySHA384: key.SHA3384,
+			Name:            key.Name,
+			Since:           key.Since,
+			Until:           key.Until,
+		})
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) getSSHKeys(c *gin.Context) {
+	accountEmail := c.GetString("email")
+	sshKeys, err := s.handler.GetSSHKeys(accountEmail)
+	if err == nil && sshKeys != nil {
+		c.JSON(http.StatusOK, sshKeys)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) addSSHKey(c *gin.Context) {
+	accountEmail := c.GetString("email")
+
+	var sshKeyRequest requests.SSHKeyCreateRequest
+	err := json.NewDecoder(c.Request.Body).Decode(&sshKeyRequest)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-field", err.Error()))
+		return
 	}
+
+	sshKey, err := s.handler.AddSSHKey(accountEmail, sshKeyRequest.PublicKey)
+	if err == nil && sshKey != nil {
+		c.JSON(http.StatusCreated, sshKey)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+// deleteSSHKey takes the fingerprint as a query parameter, SHA256 fingerprints can contain a "/"
+func (s *Server) deleteSSHKey(c *gin.Context) {
+	accountEmail := c.GetString("email")
+	fingerprint := c.Query("fingerprint")
+	if fingerprint == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("missing-field", "fingerprint is required"))
+		return
+	}
+
+	sshKeys, err := s.handler.DeleteSSHKey(accountEmail, fingerprint)
+	if err == nil && sshKeys != nil {
+		c.JSON(http.StatusOK, sshKeys)
+		return
+	}
+
+	abortWithHandlerError(c, err)
 }
 
 func (s *Server) postACL(c *gin.Context) {
-	bodyBytes, _ := io.ReadAll(c.Request.Body)
-	bodyString := string(bodyBytes)
+	var aclRequest requests.ACLRequest
+	err := json.NewDecoder(c.Request.Body).Decode(&aclRequest)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-request", err.Error()))
+		return
+	}
 
-	m, err := s.handler.GetACLMacaroon(bodyString)
+	m, err := s.handler.GetACLMacaroon(&aclRequest)
 	if err == nil {
 		ser, _ := auth.MacaroonSerialize(m)
 		mac := &responses.Macaroon{Macaroon: ser}
@@ -77,7 +156,79 @@ func (s *Server) postACL(c *gin.Context) {
 		return
 	}
 
-	c.Status(http.StatusInternalServerError)
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) postTokens(c *gin.Context) {
+	var tokenRequest requests.TokenRequest
+	err := json.NewDecoder(c.Request.Body).Decode(&tokenRequest)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-request", err.Error()))
+		return
+	}
+
+	m, err := s.handler.GetTokenMacaroon(&tokenRequest)
+	if err == nil {
+		ser, _ := auth.MacaroonSerialize(m)
+		c.JSON(http.StatusOK, &responses.Macaroon{Macaroon: ser})
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+// exchangeTokens takes the discharged macaroons in the Macaroons header, a base64 encoded JSON list of the root
+// macaroon and its discharge, and returns a single macaroon for the Authorization header
+func (s *Server) exchangeTokens(c *gin.Context) {
+	macaroons, err := decodeMacaroonsHeader(c.GetHeader("Macaroons"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-request", err.Error()))
+		return
+	}
+
+	m, err := s.handler.ExchangeMacaroons(macaroons)
+	if err == nil {
+		ser, _ := auth.MacaroonSerialize(m)
+		c.JSON(http.StatusOK, &responses.Macaroon{Macaroon: ser})
+		return
+	} else if errors.Is(err, auth.ErrUnauthorized) {
+		logrus.Error(err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, newErrorList("invalid-credentials", err.Error()))
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func decodeMacaroonsHeader(header string) ([]*macaroonv2.Macaroon, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, errors.New("the Macaroons header is required")
+	}
+
+	// padding is optional
+	decoded, err := base64.URLEncoding.DecodeString(header + strings.Repeat("=", (4-len(header)%4)%4))
+	if err != nil {
+		return nil, fmt.Errorf("the Macaroons header is not base64 encoded: %w", err)
+	}
+
+	var macaroons []*macaroonv2.Macaroon
+	err = json.Unmarshal(decoded, &macaroons)
+	if err != nil {
+		return nil, fmt.Errorf("the Macaroons header is not a list of macaroons: %w", err)
+	}
+
+	return macaroons, nil
+}
+
+func (s *Server) whoAmI(c *gin.Context) {
+	whoAmI, err := s.handler.WhoAmI(middleware.GetACL(c))
+	if err == nil {
+		c.JSON(http.StatusOK, whoAmI)
+		return
+	}
+
+	abortWithHandlerError(c, err)
 }
 
 func (s *Server) pushSnap(c *gin.Context) {
@@ -97,13 +248,16 @@ func (s *Server) pushSnap(c *gin.Context) {
 			return
 		}
 
-		uploadResp, err2 := s.handler.PushSnap(pushSnap.Name, pushSnap.UpDownId, uint(pushSnap.BinaryFileSize), pushSnap.Channels)
+		uploadResp, err2 := s.handler.PushSnap(middleware.GetACL(c), pushSnap.Name, pushSnap.UpDownId, uint(pushSnap.BinaryFileSize), pushSnap.Channels)
 		if err2 == nil && uploadResp != nil {
 			//	// File saved successfully. Return proper result
 			//	// TODO: this URL needs to be serviced by a worker thread
 			c.JSON(http.StatusAccepted, uploadResp)
 			return
 		}
+
+		abortWithHandlerError(c, err2)
+		return
 	}
 
 	logrus.Error(err)
@@ -111,6 +265,67 @@ func (s *Server) pushSnap(c *gin.Context) {
 	c.AbortWithStatus(http.StatusInternalServerError)
 }
 
+func (s *Server) postRevision(c *gin.Context) {
+	var revisionRequest requests.RevisionRequest
+	err := json.NewDecoder(c.Request.Body).Decode(&revisionRequest)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-request", err.Error()))
+		return
+	}
+
+	statusURL, err := s.handler.NotifyRevision(middleware.GetACL(c), c.Param("snap"), revisionRequest.UploadId)
+	if err == nil {
+		c.JSON(http.StatusOK, statusURL)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+// getRevisions only reports the status of an upload for now, the upload-id query is required
+func (s *Server) getRevisions(c *gin.Context) {
+	uploadId := c.Query("upload-id")
+	if uploadId == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-request", "upload-id is required"))
+		return
+	}
+
+	status, err := s.handler.GetRevisionStatus(middleware.GetACL(c), c.Param("snap"), uploadId)
+	if err == nil {
+		c.JSON(http.StatusOK, status)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) postReleases(c *gin.Context) {
+	var releaseRequests []requests.ReleaseRequest
+	err := json.NewDecoder(c.Request.Body).Decode(&releaseRequests)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-request", err.Error()))
+		return
+	}
+
+	released, err := s.handler.ReleaseRevisions(middleware.GetACL(c), c.Param("snap"), releaseRequests)
+	if err == nil {
+		c.JSON(http.StatusOK, released)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) getReleases(c *gin.Context) {
+	releases, err := s.handler.GetSnapReleases(middleware.GetACL(c), c.Param("snap"))
+	if err == nil {
+		c.JSON(http.StatusOK, releases)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
 // The id here is the up-down id generated from the upload to /unscanned-upload/
 func (s *Server) getStatus(c *gin.Context) {
 	// TODO: Do whatever we need to here and then return that it's processed, some day, (hopefully!) this will need to be async!
@@ -138,11 +353,14 @@ func (s *Server) snapRelease(c *gin.Context) {
 			return
 		}
 
-		released, err2 := s.handler.ReleaseSnap(rel.Name, uint(revision), rel.Channels)
+		released, err2 := s.handler.ReleaseSnap(middleware.GetACL(c), rel.Name, uint(revision), rel.Channels)
 		if err2 == nil {
 			c.JSON(http.StatusOK, &responses.SnapRelease{Success: released})
 			return
 		}
+
+		abortWithHandlerError(c, err2)
+		return
 	} else {
 		logrus.Error(err)
 	}
@@ -152,18 +370,13 @@ func (s *Server) snapRelease(c *gin.Context) {
 
 func (s *Server) getSnapChannelMap(c *gin.Context) {
 	snapName := c.Param("snap")
-	channelMapRoot, err := s.handler.GetSnapChannelMap(snapName)
+	channelMapRoot, err := s.handler.GetSnapChannelMap(middleware.GetACL(c), snapName)
 	if err == nil && channelMapRoot != nil {
 		c.JSON(http.StatusOK, channelMapRoot)
 		return
-	} else if err != nil {
-		logrus.Error(err)
-	} else {
-		// logrus.Error("unknown error encountered")
-		panic("unknown error encountered")
 	}
 
-	c.AbortWithStatus(http.StatusInternalServerError)
+	abortWithHandlerError(c, err)
 }
 
 func (s *Server) verifyACL(c *gin.Context) {
@@ -177,6 +390,10 @@ func (s *Server) verifyACL(c *gin.Context) {
 	response, err := s.handler.VerifyACL(&verify)
 	if err == nil && response != nil {
 		c.JSON(http.StatusOK, &response)
+		return
+	} else if errors.Is(err, auth.ErrUnauthorized) {
+		c.JSON(http.StatusUnauthorized, &responses.Verify{Allowed: false})
+		return
 	} else if err != nil {
 		logrus.Error(err)
 	}
@@ -187,28 +404,48 @@ func (s *Server) verifyACL(c *gin.Context) {
 func (s *Server) registerSnapName(c *gin.Context) {
 	var registerSnapName requests.RegisterSnapName
 	err := json.NewDecoder(c.Request.Body).Decode(&registerSnapName)
-	if err == nil {
-		accountEmail := c.GetString("email")
-
-		isDryRun := false
-		dryRunString := c.Query("dry_run")
-		if len(dryRunString) != 0 {
-			dryRun, err2 := strconv.ParseBool(dryRunString)
-			if err2 == nil {
-				isDryRun = dryRun
-			}
-		}
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-field", err.Error()))
+		return
+	}
 
-		resp, err2 := s.handler.RegisterSnapName(accountEmail, isDryRun, registerSnapName.Name)
-		if err2 == nil && resp != nil {
-			c.JSON(http.StatusOK, resp)
-			return
+	accountEmail := c.GetString("email")
+
+	isDryRun := false
+	dryRunString := c.Query("dry_run")
+	if len(dryRunString) != 0 {
+		dryRun, err2 := strconv.ParseBool(dryRunString)
+		if err2 == nil {
+			isDryRun = dryRun
 		}
-	} else {
+	}
+
+	resp, err := s.handler.RegisterSnapName(accountEmail, isDryRun, registerSnapName.Name)
+	if err == nil && resp != nil {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) disputeSnapName(c *gin.Context) {
+	var disputeRequest requests.RegisterNameDispute
+	err := json.NewDecoder(c.Request.Body).Decode(&disputeRequest)
+	if err != nil {
 		logrus.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-field", err.Error()))
+		return
 	}
 
-	c.AbortWithStatus(http.StatusInternalServerError)
+	dispute, err := s.handler.DisputeSnapName(c.GetString("email"), disputeRequest.Name, disputeRequest.Comment)
+	if err == nil && dispute != nil {
+		c.JSON(http.StatusCreated, dispute)
+		return
+	}
+
+	abortWithHandlerError(c, err)
 }
 
 func (s *Server) addAccountKey(c *gin.Context) {
@@ -236,33 +473,173 @@ func (s *Server) addAccountKey(c *gin.Context) {
 		if ass, ok := assertion.(*asserts.AccountKeyRequest); ok {
 			logrus.Infof("Account key public key id: %s", ass.PublicKeyID())
 
-			pubKey, err2 := assertions.GetPublicKeyFromBody(ass.Body())
-			if err2 != nil {
-				panic(err2)
-			}
-
-			encodedPublicKey, err2 := asserts.EncodePublicKey(pubKey)
-			if err2 != nil {
-				panic(err2)
-			}
-
-			pubKeyEncodedString := base64.StdEncoding.EncodeToString(encodedPublicKey)
-
-			acct, err2 := s.handler.AddAccountKey(accountEmail, ass.Name(), ass.PublicKeyID(), pubKeyEncodedString)
-			if err2 == nil && acct != nil {
+			key, err2 := s.handler.AddAccountKey(accountEmail, ass)
+			if err2 == nil && key != nil {
 				c.JSON(http.StatusOK, struct {
 					PublicKey string
 				}{
-					PublicKey: ass.PublicKeyID(),
+					PublicKey: key.SHA3384,
 				})
 				return
-			} else if err2 != nil {
-				logrus.Error(err2)
 			}
 
-			c.Status(http.StatusInternalServerError)
+			abortWithHandlerError(c, err2)
+			return
 		}
 	}
 
 	c.Data(http.StatusBadRequest, "text", []byte("Assertion type wrong, or invalid."))
 }
+
+func (s *Server) getBinaryMetadata(c *gin.Context) {
+	acl := middleware.GetACL(c)
+	snapId := c.Param("id")
+
+	binaryMetadata, err := s.handler.GetBinaryMetadata(acl, snapId)
+	if err == nil && binaryMetadata != nil {
+		c.JSON(http.StatusOK, binaryMetadata)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+// updateBinaryMetadata handles both POST and PUT, a PUT replaces all media for the snap
+// while a POST only replaces media of the types it includes
+func (s *Server) updateBinaryMetadata(c *gin.Context) {
+	acl := middleware.GetACL(c)
+	snapId := c.Param("id")
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-field", err.Error()))
+		return
+	}
+
+	var info []requests.BinaryMetadataInfo
+	if infoValues, ok := form.Value["info"]; ok && len(infoValues) > 0 {
+		err = json.Unmarshal([]byte(infoValues[0]), &info)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-field", err.Error()))
+			return
+		}
+	}
+
+	files := map[string][]byte{}
+	for key, fileHeaders := range form.File {
+		if len(fileHeaders) == 0 {
+			continue
+		}
+
+		file, err2 := fileHeaders[0].Open()
+		if err2 != nil {
+			logrus.Error(err2)
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		fileBytes, err2 := io.ReadAll(io.LimitReader(file, media.MaxMediaSize+1))
+		_ = file.Close()
+		if err2 != nil {
+			logrus.Error(err2)
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		files[key] = fileBytes
+	}
+
+	replaceAll := c.Request.Method == http.MethodPut
+	binaryMetadata, err := s.handler.UpdateBinaryMetadata(acl, snapId, replaceAll, info, files)
+	if err == nil && binaryMetadata != nil {
+		c.JSON(http.StatusOK, binaryMetadata)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) getMetadata(c *gin.Context) {
+	acl := middleware.GetACL(c)
+	snapId := c.Param("id")
+
+	metadata, err := s.handler.GetMetadata(acl, snapId)
+	if err == nil && metadata != nil {
+		c.JSON(http.StatusOK, metadata)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+// updateMetadata handles both POST and PUT, a PUT only reports conflicts for fields in conflict_fields
+func (s *Server) updateMetadata(c *gin.Context) {
+	acl := middleware.GetACL(c)
+	snapId := c.Param("id")
+
+	var update requests.SnapMetadata
+	err := json.NewDecoder(c.Request.Body).Decode(&update)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-field", err.Error()))
+		return
+	}
+
+	force := c.Request.Method == http.MethodPut
+	metadata, err := s.handler.UpdateMetadata(acl, snapId, force, &update)
+	if err == nil && metadata != nil {
+		c.JSON(http.StatusOK, metadata)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) getSnapDevelopers(c *gin.Context) {
+	acl := middleware.GetACL(c)
+	snapId := c.Param("id")
+
+	developers, err := s.handler.GetSnapDevelopers(acl, snapId)
+	if err == nil && developers != nil {
+		c.JSON(http.StatusOK, developers)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) updateSnapDevelopers(c *gin.Context) {
+	acl := middleware.GetACL(c)
+	snapId := c.Param("id")
+
+	var update requests.SnapDevelopers
+	err := json.NewDecoder(c.Request.Body).Decode(&update)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, newErrorList("invalid-field", err.Error()))
+		return
+	}
+
+	developers, err := s.handler.UpdateSnapDevelopers(acl, snapId, &update)
+	if err == nil && developers != nil {
+		c.JSON(http.StatusOK, developers)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
+
+func (s *Server) removeSnapDeveloper(c *gin.Context) {
+	acl := middleware.GetACL(c)
+	snapId := c.Param("id")
+	accountId := c.Param("account")
+
+	developers, err := s.handler.RemoveSnapDeveloper(acl, snapId, accountId)
+	if err == nil && developers != nil {
+		c.JSON(http.StatusOK, developers)
+		return
+	}
+
+	abortWithHandlerError(c, err)
+}
@@ -74,4 +74,23 @@ func MigrateDatabase(db *gorm.DB) {
 	MigrateWithLog("models.SnapTrack", &models.SnapTrack{}, db)
 	MigrateWithLog("models.SnapRisk", &models.SnapRisk{}, db)
 	MigrateWithLog("models.SnapBranch", &models.SnapBranch{}, db)
+	MigrateWithLog("models.SnapMedia", &models.SnapMedia{}, db)
+	MigrateWithLog("models.SnapMetadata", &models.SnapMetadata{}, db)
+	MigrateWithLog("models.Assertion", &models.Assertion{}, db)
+	MigrateWithLog("models.AuthRequest", &models.AuthRequest{}, db)
+	MigrateWithLog("models.Serial", &models.Serial{}, db)
+	MigrateWithLog("models.BrandModel", &models.BrandModel{}, db)
+	MigrateWithLog("models.Nonce", &models.Nonce{}, db)
+	MigrateWithLog("models.DeviceSession", &models.DeviceSession{}, db)
+	MigrateWithLog("models.ValidationSet", &models.ValidationSet{}, db)
+	MigrateWithLog("models.AdminAction", &models.AdminAction{}, db)
+	MigrateWithLog("models.DischargeToken", &models.DischargeToken{}, db)
+	MigrateWithLog("models.TOTPDevice", &models.TOTPDevice{}, db)
+	MigrateWithLog("models.LocalCredential", &models.LocalCredential{}, db)
+	MigrateWithLog("models.AdminSession", &models.AdminSession{}, db)
+	MigrateWithLog("models.SnapCollaborator", &models.SnapCollaborator{}, db)
+	MigrateWithLog("models.Organization", &models.Organization{}, db)
+	MigrateWithLog("models.OrganizationMember", &models.OrganizationMember{}, db)
+	MigrateWithLog("models.ReservedSnapName", &models.ReservedSnapName{}, db)
+	MigrateWithLog("models.SnapNameDispute", &models.SnapNameDispute{}, db)
 }
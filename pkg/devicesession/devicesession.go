@@ -0,0 +1,263 @@
+package devicesession
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/assertions"
+	"github.com/freetocompute/kebe/pkg/auth"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/freetocompute/kebe/pkg/store/requests"
+	"github.com/google/uuid"
+	"github.com/snapcore/snapd/asserts"
+	macaroonv2 "gopkg.in/macaroon.v2"
+)
+
+const (
+	// NonceExpiry is how long a device has to use a nonce in its device-session-request
+	NonceExpiry = 10 * time.Minute
+	// SessionExpiry is how long a device macaroon is accepted, snapd asks for a new session when it expires
+	SessionExpiry = 24 * time.Hour
+
+	deviceCaveatPrefix  = "device="
+	expiresCaveatPrefix = "expires="
+)
+
+var (
+	ErrInvalidSessionRequest = errors.New("invalid device session request")
+	ErrInvalidSession        = errors.New("invalid device session")
+)
+
+// Manager issues nonces and device sessions and identifies devices from their device macaroons
+type Manager struct {
+	sessions   repositories.IDeviceSessionsRepository
+	serials    repositories.ISerialsRepository
+	assertions repositories.IAssertionsRepository
+	assertsDB  *asserts.Database
+	rootKey    []byte
+	location   string
+}
+
+func NewManager(sessions repositories.IDeviceSessionsRepository, serials repositories.ISerialsRepository, assertions repositories.IAssertionsRepository, assertsDB *asserts.Database, rootKey string, location string) *Manager {
+	return &Manager{
+		sessions:   sessions,
+		serials:    serials,
+		assertions: assertions,
+		assertsDB:  assertsDB,
+		rootKey:    []byte(rootKey),
+		location:   location,
+	}
+}
+
+func (m *Manager) IssueNonce() (string, error) {
+	nonce := models.Nonce{
+		Value:     uuid.New().String(),
+		ExpiresAt: time.Now().Add(NonceExpiry),
+	}
+
+	err := m.sessions.AddNonce(&nonce)
+	if err != nil {
+		return "", err
+	}
+
+	return nonce.Value, nil
+}
+
+// CreateSession checks the device-session-request is signed by the device key of a serial we issued, for a
+// registered model, with a nonce we handed out, and returns a serialized device macaroon
+func (m *Manager) CreateSession(sessionRequest *requests.Session) (string, error) {
+	deviceSessionRequest, serial, model, err := decodeSessionRequest(sessionRequest)
+	if err != nil {
+		return "", err
+	}
+
+	if model.BrandID() != serial.BrandID() || model.Model() != serial.Model() {
+		return "", fmt.Errorf("%w: serial is not for model %s/%s", ErrInvalidSessionRequest, model.BrandID(), model.Model())
+	}
+
+	registered, err := m.assertions.GetLatestAssertion(asserts.ModelType.Name, assertions.JoinKey([]string{model.Series(), model.BrandID(), model.Model()}))
+	if err != nil {
+		return "", err
+	}
+
+	if registered == nil {
+		return "", fmt.Errorf("%w: unknown model %s/%s", ErrInvalidSessionRequest, model.BrandID(), model.Model())
+	}
+
+	err = m.checkModelSignature(model)
+	if err != nil {
+		return "", err
+	}
+
+	storedSerial, err := m.serials.GetSerial(serial.BrandID(), serial.Model(), serial.Serial())
+	if err != nil {
+		return "", err
+	}
+
+	if storedSerial == nil || storedSerial.DeviceKeySHA3384 != serial.DeviceKey().ID() {
+		return "", fmt.Errorf("%w: serial %s was not issued by this store", ErrInvalidSessionRequest, serial.Serial())
+	}
+
+	if storedSerial.IsRevoked() {
+		return "", fmt.Errorf("%w: serial %s has been revoked", ErrInvalidSessionRequest, serial.Serial())
+	}
+
+	if deviceSessionRequest.BrandID() != serial.BrandID() || deviceSessionRequest.Model() != serial.Model() || deviceSessionRequest.Serial() != serial.Serial() {
+		return "", fmt.Errorf("%w: device-session-request does not match the serial", ErrInvalidSessionRequest)
+	}
+
+	err = asserts.SignatureCheck(deviceSessionRequest, serial.DeviceKey())
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSessionRequest, err.Error())
+	}
+
+	used, err := m.sessions.UseNonce(deviceSessionRequest.Nonce())
+	if err != nil {
+		return "", err
+	}
+
+	if !used {
+		return "", fmt.Errorf("%w: unknown, expired or already used nonce", ErrInvalidSessionRequest)
+	}
+
+	session := models.DeviceSession{
+		SerialID:  storedSerial.ID,
+		ExpiresAt: time.Now().Add(SessionExpiry),
+	}
+
+	err = m.sessions.AddSession(&session)
+	if err != nil {
+		return "", err
+	}
+
+	mac := auth.MustNewMacaroon(m.rootKey, []byte(strconv.Itoa(int(session.ID))), m.location, macaroonv2.LatestVersion)
+	err = mac.AddFirstPartyCaveat([]byte(deviceCaveatPrefix + deviceName(storedSerial)))
+	if err != nil {
+		return "", err
+	}
+
+	err = mac.AddFirstPartyCaveat([]byte(expiresCaveatPrefix + session.ExpiresAt.UTC().Format(time.RFC3339)))
+	if err != nil {
+		return "", err
+	}
+
+	return auth.MacaroonSerialize(mac)
+}
+
+// Authenticate returns the serial of the device the X-Device-Authorization header value belongs to
+func (m *Manager) Authenticate(authorization string) (*models.Serial, error) {
+	serialized := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(authorization), "Macaroon"))
+	serialized = strings.TrimPrefix(serialized, "root=")
+	serialized = strings.Trim(serialized, "\"")
+
+	mac, err := auth.MacaroonDeserialize(serialized)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSession, err.Error())
+	}
+
+	var device string
+	err = mac.Verify(m.rootKey, func(caveat string) error {
+		switch {
+		case strings.HasPrefix(caveat, deviceCaveatPrefix):
+			device = strings.TrimPrefix(caveat, deviceCaveatPrefix)
+			return nil
+		case strings.HasPrefix(caveat, expiresCaveatPrefix):
+			expires, err2 := time.Parse(time.RFC3339, strings.TrimPrefix(caveat, expiresCaveatPrefix))
+			if err2 != nil {
+				return err2
+			}
+
+			if time.Now().After(expires) {
+				return errors.New("session has expired")
+			}
+
+			return nil
+		}
+
+		return fmt.Errorf("unknown caveat %q", caveat)
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSession, err.Error())
+	}
+
+	sessionId, err := strconv.ParseUint(string(mac.Id()), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed session id", ErrInvalidSession)
+	}
+
+	session, err := m.sessions.GetSession(uint(sessionId))
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil || time.Now().After(session.ExpiresAt) {
+		return nil, fmt.Errorf("%w: unknown or expired session", ErrInvalidSession)
+	}
+
+	if session.Serial.IsRevoked() || deviceName(&session.Serial) != device {
+		return nil, fmt.Errorf("%w: serial is no longer valid", ErrInvalidSession)
+	}
+
+	return &session.Serial, nil
+}
+
+// checkModelSignature checks the model assertion the device sent is signed by a key of its authority that the
+// assertion database knows and that was valid when the model was signed
+func (m *Manager) checkModelSignature(model *asserts.Model) error {
+	signingKey, err := m.assertsDB.Find(asserts.AccountKeyType, map[string]string{
+		"account-id":          model.AuthorityID(),
+		"public-key-sha3-384": model.SignKeyID(),
+	})
+	if asserts.IsNotFound(err) {
+		return fmt.Errorf("%w: model %s/%s is signed with unknown key %s", ErrInvalidSessionRequest, model.BrandID(), model.Model(), model.SignKeyID())
+	} else if err != nil {
+		return err
+	}
+
+	accountKey := signingKey.(*asserts.AccountKey)
+	now := time.Now()
+	for _, check := range []asserts.Checker{asserts.CheckSignature, asserts.CheckTimestampVsSigningKeyValidity} {
+		err = check(model, accountKey, m.assertsDB, now, now)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidSessionRequest, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func deviceName(serial *models.Serial) string {
+	return serial.BrandID + "/" + serial.ModelName + "/" + serial.SerialUUID
+}
+
+func decodeSessionRequest(sessionRequest *requests.Session) (*asserts.DeviceSessionRequest, *asserts.Serial, *asserts.Model, error) {
+	decoded := make([]asserts.Assertion, 3)
+	for i, encoded := range []string{sessionRequest.DeviceSessionRequest, sessionRequest.SerialAssertion, sessionRequest.ModelAssertion} {
+		assertion, err := asserts.Decode([]byte(encoded))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%w: %s", ErrInvalidSessionRequest, err.Error())
+		}
+		decoded[i] = assertion
+	}
+
+	deviceSessionRequest, ok := decoded[0].(*asserts.DeviceSessionRequest)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: expected a device-session-request", ErrInvalidSessionRequest)
+	}
+
+	serial, ok := decoded[1].(*asserts.Serial)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: expected a serial assertion", ErrInvalidSessionRequest)
+	}
+
+	model, ok := decoded[2].(*asserts.Model)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: expected a model assertion", ErrInvalidSessionRequest)
+	}
+
+	return deviceSessionRequest, serial, model, nil
+}
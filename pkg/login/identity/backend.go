@@ -0,0 +1,40 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/freetocompute/kebe/config"
+	"github.com/freetocompute/kebe/config/configkey"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// Identity backends that can be set with login.identity.backend
+const (
+	BackendLocal = "local"
+	BackendOIDC  = "oidc"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Backend checks the passwords publishers log in to the store with
+type Backend interface {
+	// Authenticate returns ErrInvalidCredentials if the password isn't the account's
+	Authenticate(ctx context.Context, account *models.Account, password string) error
+}
+
+// NewBackendFromConfig opens the identity backend set in the configuration
+func NewBackendFromConfig(ctx context.Context, db *gorm.DB) (Backend, error) {
+	switch backend := viper.GetString(configkey.LoginIdentityBackend); backend {
+	case BackendLocal:
+		return NewLocalBackend(repositories.NewLocalCredentialsRepository(db)), nil
+	case BackendOIDC:
+		return NewOIDCBackend(ctx, config.MustGetString(configkey.OIDCProviderURL), config.MustGetString(configkey.OIDCClientId), viper.GetString(configkey.OIDCClientSecret))
+	default:
+		return nil, fmt.Errorf("unknown identity backend: %q", backend)
+	}
+}
@@ -0,0 +1,61 @@
+package identity
+
+import (
+	"context"
+	"errors"
+
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength is the shortest password the local backend accepts
+const MinPasswordLength = 8
+
+var ErrPasswordTooShort = errors.New("password is too short")
+
+// dummyHash is compared against when an account has no password, so it takes as long to fail as a wrong password
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("kebe-dummy-password"), bcrypt.DefaultCost)
+
+// LocalBackend checks passwords against the bcrypt hashes kept in the store's database, admins set them with
+// kebe-admin
+type LocalBackend struct {
+	credentials repositories.ILocalCredentialsRepository
+}
+
+func NewLocalBackend(credentials repositories.ILocalCredentialsRepository) *LocalBackend {
+	return &LocalBackend{credentials: credentials}
+}
+
+func (l *LocalBackend) Authenticate(ctx context.Context, account *models.Account, password string) error {
+	credential, err := l.credentials.GetCredential(account.ID)
+	if err != nil {
+		return err
+	}
+
+	if credential == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return ErrInvalidCredentials
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(credential.PasswordHash), []byte(password))
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// HashPassword returns the bcrypt hash of the password to store for the local backend
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", ErrPasswordTooShort
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
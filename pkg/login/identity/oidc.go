@@ -0,0 +1,57 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// OIDCBackend checks passwords with an OAuth2 password grant against an OIDC provider, accounts log in with their
+// username there. The provider is discovered once, when the backend is created.
+type OIDCBackend struct {
+	verifier     *oidc.IDTokenVerifier
+	oauth2Config oauth2.Config
+}
+
+func NewOIDCBackend(ctx context.Context, providerURL string, clientId string, clientSecret string) (*OIDCBackend, error) {
+	provider, err := oidc.NewProvider(ctx, providerURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover OIDC provider %s: %w", providerURL, err)
+	}
+
+	return &OIDCBackend{
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientId}),
+		oauth2Config: oauth2.Config{
+			ClientID:     clientId,
+			ClientSecret: clientSecret,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID},
+		},
+	}, nil
+}
+
+func (o *OIDCBackend) Authenticate(ctx context.Context, account *models.Account, password string) error {
+	oauth2Token, err := o.oauth2Config.PasswordCredentialsToken(ctx, account.Username, password)
+	if err != nil {
+		logrus.Errorf("Failed to exchange token: %v", err)
+		return ErrInvalidCredentials
+	}
+
+	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+	if !ok {
+		logrus.Error("No id_token field in oauth2 token.")
+		return ErrInvalidCredentials
+	}
+
+	_, err = o.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		logrus.Errorf("Failed to verify ID Token: %v", err)
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
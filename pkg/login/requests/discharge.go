@@ -4,4 +4,6 @@ type Discharge struct {
 	Email    string
 	Password string
 	CaveatId string `json:"caveat_id"`
+	// Otp is the code from the account's authenticator, it's needed once two-factor authentication is enabled
+	Otp string `json:"otp"`
 }
@@ -0,0 +1,12 @@
+package requests
+
+type TwoFactorEnrol struct {
+	Email    string
+	Password string
+}
+
+type TwoFactor struct {
+	Email    string
+	Password string
+	Otp      string `json:"otp"`
+}
@@ -0,0 +1,7 @@
+package responses
+
+type TwoFactorEnrolment struct {
+	Secret string `json:"secret"`
+	// URL is the otpauth URL of the secret, authenticator apps read it from a QR code
+	URL string `json:"otpauth_url"`
+}
@@ -3,6 +3,10 @@ package server
 import "github.com/gin-gonic/gin"
 
 func (s *Server) SetupEndpoints(r *gin.Engine) {
+	// the legacy and craft-store flows both discharge here, /v1/tokens, the exchange and whoami are the dashboard's
 	r.POST("/api/v2/tokens/discharge", s.dischargeTokens)
 	r.GET("/api/v2/keys/:email", s.getSSHKeys)
+	r.POST("/api/v2/twofactor/enrol", s.enrolTwoFactor)
+	r.POST("/api/v2/twofactor/confirm", s.confirmTwoFactor)
+	r.POST("/api/v2/twofactor/disable", s.disableTwoFactor)
 }
@@ -6,30 +6,33 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"time"
 
-	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/freetocompute/kebe/config"
 	"github.com/freetocompute/kebe/config/configkey"
 	"github.com/freetocompute/kebe/pkg/auth"
 	"github.com/freetocompute/kebe/pkg/dashboard/responses"
 	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/login/identity"
 	"github.com/freetocompute/kebe/pkg/login/requests"
 	responses2 "github.com/freetocompute/kebe/pkg/login/responses"
 	"github.com/freetocompute/kebe/pkg/middleware"
 	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
-	"golang.org/x/oauth2"
 	"gopkg.in/macaroon.v2"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type Server struct {
-	db *gorm.DB
+	db          *gorm.DB
+	totpDevices *repositories.TOTPDevicesRepository
+	identity    identity.Backend
 }
 
 func (s *Server) Run() {
@@ -59,6 +62,14 @@ func (s *Server) Run() {
 
 	db, _ := database.CreateDatabase()
 	s.db = db
+	s.totpDevices = repositories.NewTOTPDevicesRepository(db)
+
+	identityBackend, err := identity.NewBackendFromConfig(context.Background(), db)
+	if err != nil {
+		logrus.Error(err)
+		panic(err)
+	}
+	s.identity = identityBackend
 
 	s.SetupEndpoints(r)
 
@@ -74,90 +85,97 @@ func MustNew(rootKey, id []byte, loc string, vers macaroon.Version) *macaroon.Ma
 	return m
 }
 
+// error codes snapcraft understands in the error_list of a failed request
+const (
+	errorCodeInvalidCredentials = "invalid-credentials"
+	errorCodeTwoFactorRequired  = "twofactor-required"
+	errorCodeTwoFactorFailure   = "twofactor-failure"
+	errorCodeAccountSuspended   = "account-suspended"
+)
+
+func abortWithErrorList(c *gin.Context, status int, code string, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error_list": []gin.H{{"code": code, "message": message}}})
+}
+
 func (s *Server) dischargeTokens(c *gin.Context) {
 	var dischargeRequest requests.Discharge
 	bodyBytes, _ := io.ReadAll(c.Request.Body)
 	_ = json.Unmarshal(bodyBytes, &dischargeRequest)
 
-	// find an account for this discharge token
-	var userAccount models.Account
-	db := s.db.Where(&models.Account{Email: dischargeRequest.Email}).Find(&userAccount)
-	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+	userAccount, ok := s.authenticate(c, dischargeRequest.Email, dischargeRequest.Password)
+	if !ok {
+		return
+	}
 
-		clientID := viper.GetString(configkey.OIDCClientId)
-		clientSecret := viper.GetString(configkey.OIDCClientSecret)
-		providerURL := viper.GetString(configkey.OIDCProviderURL)
+	if !s.checkTwoFactor(c, userAccount, dischargeRequest.Otp) {
+		return
+	}
+
+	dischargeKeyString := viper.GetString(configkey.MacaroonDischargeKey)
+	if len(dischargeKeyString) == 0 {
+		// this is panic worthy
+		panic(errors.New("discharge key must be set"))
+	}
 
-		ctx := context.Background()
+	// the token is recorded so it can be listed and revoked, it expires regardless
+	dischargeToken := models.DischargeToken{
+		TokenID:   uuid.New().String(),
+		AccountID: userAccount.ID,
+		ExpiresAt: time.Now().Add(viper.GetDuration(configkey.MacaroonDischargeExpiry)),
+	}
+	err := repositories.NewDischargeTokensRepository(s.db).AddToken(&dischargeToken)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
 
-		provider, err := oidc.NewProvider(ctx, providerURL)
+	dm := MustNew([]byte(dischargeKeyString), []byte(dischargeRequest.CaveatId), "remote location", macaroon.LatestVersion)
+	for _, caveat := range auth.DischargeCaveats(userAccount.Email, dischargeToken.TokenID, dischargeToken.ExpiresAt) {
+		err = dm.AddFirstPartyCaveat([]byte(caveat))
 		if err != nil {
-			log.Fatal(err)
-		}
-		oidcConfig := &oidc.Config{
-			ClientID: clientID,
+			panic(err)
 		}
-		verifier := provider.Verifier(oidcConfig)
+	}
 
-		oauth2Config := oauth2.Config{
-			ClientID:     clientID,
-			ClientSecret: clientSecret,
-			Endpoint:     provider.Endpoint(),
-			Scopes:       []string{oidc.ScopeOpenID, "openid"},
-		}
+	ser, err := auth.MacaroonSerialize(dm)
+	if err != nil {
+		logrus.Errorf("error: %s, msg: %s", err, "unable to serialize token")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
 
-		oauth2Token, err := oauth2Config.PasswordCredentialsToken(ctx, userAccount.Username, dischargeRequest.Password)
-		if err == nil {
-			rawIDToken, ok := oauth2Token.Extra("id_token").(string)
-			if ok {
-				idToken, err8 := verifier.Verify(ctx, rawIDToken)
-				if err8 == nil {
-					resp := struct {
-						OAuth2Token   *oauth2.Token
-						IDTokenClaims *json.RawMessage // ID Token payload is just JSON.
-					}{oauth2Token, new(json.RawMessage)}
-
-					if err9 := idToken.Claims(&resp.IDTokenClaims); err9 == nil {
-						dischargeKeyString := viper.GetString(configkey.MacaroonDischargeKey)
-						if len(dischargeKeyString) == 0 {
-							// this is panic worthy
-							panic(errors.New("discharge key must be set"))
-						}
-
-						dm := MustNew([]byte(dischargeKeyString), []byte(dischargeRequest.CaveatId), "remote location", macaroon.LatestVersion)
-						err2 := dm.AddFirstPartyCaveat([]byte("email=" + dischargeRequest.Email))
-						if err2 != nil {
-							panic(err2)
-						}
-
-						ser, err3 := auth.MacaroonSerialize(dm)
-						if err3 != nil {
-							logrus.Errorf("error: %s, msg: %s", err3, "unable to serialize token")
-							c.AbortWithStatus(500)
-						}
-
-						mac := &responses.DischargeMacaroon{DischargeMacaroon: ser}
-						fmt.Printf("%+v\n", mac)
-						bytes, _ := json.Marshal(mac)
-						fmt.Println(string(bytes))
-						c.JSON(200, mac)
-					} else {
-						logrus.Error(err)
-						c.AbortWithStatus(http.StatusInternalServerError)
-						return
-					}
-				} else {
-					logrus.Errorf("Failed to verify ID Token: %v", err)
-				}
-			} else {
-				logrus.Error("No id_token field in oauth2 token.")
-			}
-		} else {
-			logrus.Errorf("Failed to exchange token: %v", err)
-		}
+	c.JSON(http.StatusOK, &responses.DischargeMacaroon{DischargeMacaroon: ser})
+}
+
+// authenticate checks the password of the account with the email with the identity backend, the request is
+// aborted if they don't match
+func (s *Server) authenticate(c *gin.Context, email string, password string) (*models.Account, bool) {
+	// find an account for this discharge token
+	var userAccount models.Account
+	db := s.db.Where(&models.Account{Email: email}).Find(&userAccount)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); !ok || email == "" {
+		logrus.Errorf("No account found for %s", email)
+		abortWithErrorList(c, http.StatusUnauthorized, errorCodeInvalidCredentials, "Provided email/password is not correct.")
+		return nil, false
+	}
+
+	err := s.identity.Authenticate(c.Request.Context(), &userAccount, password)
+	if err == nil && userAccount.IsSuspended() {
+		logrus.Warnf("Account %s is suspended", userAccount.AccountId)
+		abortWithErrorList(c, http.StatusForbidden, errorCodeAccountSuspended, "The account is suspended.")
+		return nil, false
+	} else if err == nil {
+		return &userAccount, true
+	} else if !errors.Is(err, identity.ErrInvalidCredentials) {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return nil, false
 	}
 
-	c.AbortWithStatus(http.StatusUnauthorized)
+	logrus.Warnf("Invalid credentials for %s", email)
+	abortWithErrorList(c, http.StatusUnauthorized, errorCodeInvalidCredentials, "Provided email/password is not correct.")
+	return nil, false
 }
 
 func (s *Server) getSSHKeys(c *gin.Context) {
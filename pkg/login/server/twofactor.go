@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/freetocompute/kebe/config/configkey"
+	"github.com/freetocompute/kebe/pkg/auth"
+	"github.com/freetocompute/kebe/pkg/login/requests"
+	"github.com/freetocompute/kebe/pkg/login/responses"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	errorCodeTwoFactorEnabled     = "twofactor-enabled"
+	errorCodeTwoFactorNotEnrolled = "twofactor-not-enrolled"
+)
+
+// checkTwoFactor makes sure the code is from the account's authenticator if it has confirmed one, the request is
+// aborted if it isn't
+func (s *Server) checkTwoFactor(c *gin.Context, account *models.Account, code string) bool {
+	device, err := s.totpDevices.GetDevice(account.ID)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return false
+	}
+
+	if device == nil || !device.IsConfirmed() {
+		return true
+	}
+
+	if code == "" {
+		abortWithErrorList(c, http.StatusUnauthorized, errorCodeTwoFactorRequired, "2-factor authentication required.")
+		return false
+	}
+
+	return s.verifyCode(c, device, code)
+}
+
+// verifyCode checks the code is from the device and hasn't been used before, the request is aborted if it isn't
+func (s *Server) verifyCode(c *gin.Context, device *models.TOTPDevice, code string) bool {
+	step, ok := auth.ValidateTOTP(device.Secret, code, time.Now())
+	if ok {
+		used, err := s.totpDevices.UseStep(device, step)
+		if err != nil {
+			logrus.Error(err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return false
+		}
+
+		if used {
+			return true
+		}
+	}
+
+	logrus.Warnf("Invalid 2-factor code for account %d", device.AccountID)
+	abortWithErrorList(c, http.StatusForbidden, errorCodeTwoFactorFailure, "The provided 2-factor key is not recognised.")
+	return false
+}
+
+// enrolTwoFactor generates a secret for the account's authenticator, it's only asked for once it's confirmed with
+// a code. A device that isn't confirmed yet is replaced.
+func (s *Server) enrolTwoFactor(c *gin.Context) {
+	var enrolRequest requests.TwoFactorEnrol
+	err := json.NewDecoder(c.Request.Body).Decode(&enrolRequest)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	account, ok := s.authenticate(c, enrolRequest.Email, enrolRequest.Password)
+	if !ok {
+		return
+	}
+
+	device, err := s.totpDevices.GetDevice(account.ID)
+	if err == nil && device != nil && device.IsConfirmed() {
+		abortWithErrorList(c, http.StatusConflict, errorCodeTwoFactorEnabled, "2-factor authentication is already enabled, disable it first.")
+		return
+	} else if err == nil {
+		key, err2 := auth.GenerateTOTPKey(viper.GetString(configkey.LoginTOTPIssuer), account.Email)
+		if err2 == nil {
+			_, err2 = s.totpDevices.EnrolDevice(account.ID, key.Secret())
+			if err2 == nil {
+				logrus.Infof("Enrolled a 2-factor device for %s", account.AccountId)
+				c.JSON(http.StatusOK, &responses.TwoFactorEnrolment{Secret: key.Secret(), URL: key.URL()})
+				return
+			}
+		}
+
+		err = err2
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// confirmTwoFactor enables two-factor authentication once a code from the enrolled device is given
+func (s *Server) confirmTwoFactor(c *gin.Context) {
+	var confirmRequest requests.TwoFactor
+	err := json.NewDecoder(c.Request.Body).Decode(&confirmRequest)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	account, ok := s.authenticate(c, confirmRequest.Email, confirmRequest.Password)
+	if !ok {
+		return
+	}
+
+	device, err := s.totpDevices.GetDevice(account.ID)
+	if err == nil && device == nil {
+		abortWithErrorList(c, http.StatusNotFound, errorCodeTwoFactorNotEnrolled, "No 2-factor device is enrolled.")
+		return
+	} else if err == nil {
+		if device.IsConfirmed() {
+			abortWithErrorList(c, http.StatusConflict, errorCodeTwoFactorEnabled, "2-factor authentication is already enabled.")
+			return
+		}
+
+		if !s.verifyCode(c, device, confirmRequest.Otp) {
+			return
+		}
+
+		err = s.totpDevices.ConfirmDevice(device)
+		if err == nil {
+			logrus.Infof("Enabled 2-factor authentication for %s", account.AccountId)
+			c.Status(http.StatusOK)
+			return
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// disableTwoFactor removes the account's device, a code from it is needed if it was confirmed
+func (s *Server) disableTwoFactor(c *gin.Context) {
+	var disableRequest requests.TwoFactor
+	err := json.NewDecoder(c.Request.Body).Decode(&disableRequest)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	account, ok := s.authenticate(c, disableRequest.Email, disableRequest.Password)
+	if !ok {
+		return
+	}
+
+	device, err := s.totpDevices.GetDevice(account.ID)
+	if err == nil && device == nil {
+		abortWithErrorList(c, http.StatusNotFound, errorCodeTwoFactorNotEnrolled, "No 2-factor device is enrolled.")
+		return
+	} else if err == nil {
+		if device.IsConfirmed() && !s.verifyCode(c, device, disableRequest.Otp) {
+			return
+		}
+
+		err = s.totpDevices.DeleteDevice(account.ID)
+		if err == nil {
+			logrus.Infof("Disabled 2-factor authentication for %s", account.AccountId)
+			c.Status(http.StatusOK)
+			return
+		}
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
@@ -0,0 +1,98 @@
+package media
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"path"
+	"strings"
+
+	// image decoders for the formats we accept
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/objectstore"
+	// registers crypto.SHA3_384
+	_ "golang.org/x/crypto/sha3"
+)
+
+// MaxMediaSize is the largest media file that will be accepted
+const MaxMediaSize = 10 * 1024 * 1024
+
+var ErrUnsupportedMedia = errors.New("unsupported media format, must be png, jpeg, gif or svg")
+
+// NewSnapMedia inspects the media bytes and returns a SnapMedia describing them,
+// it is not saved to either the object store or the database
+func NewSnapMedia(snapEntryId uint, mediaType string, originalFilename string, mediaBytes []byte) (*models.SnapMedia, error) {
+	if !models.IsValidMediaType(mediaType) {
+		return nil, fmt.Errorf("unsupported media type: %s", mediaType)
+	}
+
+	if len(mediaBytes) > MaxMediaSize {
+		return nil, fmt.Errorf("media %s is larger than %d bytes", originalFilename, MaxMediaSize)
+	}
+
+	contentType, ext, width, height, err := inspect(mediaBytes, originalFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := SHA3_384(mediaBytes)
+
+	return &models.SnapMedia{
+		Type:             mediaType,
+		Filename:         digest + ext,
+		OriginalFilename: originalFilename,
+		SHA3_384:         digest,
+		ContentType:      contentType,
+		Width:            width,
+		Height:           height,
+		SnapEntryID:      snapEntryId,
+	}, nil
+}
+
+// Save puts the media bytes in the media bucket under the name of the SnapMedia
+func Save(snapMedia *models.SnapMedia, mediaBytes []byte) error {
+	obs := objectstore.NewObjectStore()
+	return obs.SaveBytesToBucket(models.MediaBucket, snapMedia.Filename, mediaBytes, snapMedia.ContentType)
+}
+
+// SHA3_384 returns the hex encoded sha3-384 of the bytes, this is the hash snapcraft sends for binary metadata
+func SHA3_384(b []byte) string {
+	h := crypto.SHA3_384.New()
+	h.Write(b)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func inspect(mediaBytes []byte, originalFilename string) (string, string, int64, int64, error) {
+	if strings.ToLower(path.Ext(originalFilename)) == ".svg" || isSVG(mediaBytes) {
+		// SVGs are scalable, they have no meaningful width and height
+		return "image/svg+xml", ".svg", 0, 0, nil
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(mediaBytes))
+	if err != nil {
+		return "", "", 0, 0, ErrUnsupportedMedia
+	}
+
+	ext := "." + format
+	if format == "jpeg" {
+		ext = ".jpg"
+	}
+
+	return http.DetectContentType(mediaBytes), ext, int64(cfg.Width), int64(cfg.Height), nil
+}
+
+func isSVG(mediaBytes []byte) bool {
+	head := mediaBytes
+	if len(head) > 512 {
+		head = head[:512]
+	}
+
+	return bytes.Contains(head, []byte("<svg"))
+}
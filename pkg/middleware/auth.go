@@ -1,119 +1,142 @@
 package middleware
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/freetocompute/kebe/config"
 	"github.com/freetocompute/kebe/config/configkey"
 	"github.com/freetocompute/kebe/pkg/auth"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/macaroon.v2"
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
-func VerifyAndGetEmail(authData string) (*string, error) {
+// VerifyMacaroons returns the ACL granted by the macaroons of an Authorization header value, the discharge's
+// token must not have been revoked
+func VerifyMacaroons(tokens repositories.IDischargeTokensRepository, authData string) (*auth.ACL, error) {
 	rootKey := config.MustGetString(configkey.MacaroonRootKey)
+	return verifyAuthorization(tokens, rootKey, viper.GetString(configkey.MacaroonStoreId), authData)
+}
+
+// verifyAuthorization accepts either a root macaroon and its discharge, "Macaroon root=..., discharge=...", or a
+// single macaroon exchanged for them at /v1/tokens/exchange, "Macaroon ..."
+func verifyAuthorization(tokens repositories.IDischargeTokensRepository, rootKey string, storeId string, authData string) (*auth.ACL, error) {
+	var acl *auth.ACL
+	var err error
 
 	rootS, dischargeS := GetRootMacaroonsFromString(authData)
-	root, _ := auth.MacaroonDeserialize(rootS)
-	discharge, _ := auth.MacaroonDeserialize(dischargeS)
+	if rootS == "" {
+		m, _ := auth.MacaroonDeserialize(strings.TrimSpace(dischargeS))
+		acl, err = auth.VerifyStoreMacaroon(rootKey, storeId, m)
+	} else {
+		root, _ := auth.MacaroonDeserialize(rootS)
+		discharge, _ := auth.MacaroonDeserialize(dischargeS)
+		acl, err = auth.VerifyMacaroons(rootKey, storeId, root, discharge)
+	}
 
-	if root != nil && discharge != nil {
-		err := root.Verify([]byte(rootKey), func(caveat string) error {
-			return nil
-		}, []*macaroon.Macaroon{discharge})
+	if err != nil {
+		return nil, err
+	}
 
-		if err != nil {
-			logrus.Error(err)
-			return nil, err
-		} else {
-			isAuthorized := false
-			for _, cav := range root.Caveats() {
-				if string(cav.Id) != "is-authorized-or-whatever" {
-					isAuthorized = true
-					break
-				}
-			}
-
-			if isAuthorized {
-				var email string
-				for _, cav := range discharge.Caveats() {
-					cavAsString := string(cav.Id)
-					if strings.Contains(cavAsString, "email") {
-						parts := strings.Split(cavAsString, "=")
-						if len(parts) != 2 {
-							return nil, errors.New("email caveat malformed")
-						}
-						email = parts[1]
-					}
-				}
-
-				// find an account for this discharge token
-				//var userAccount models.Account
-				//db := db.Where(&models.Account{Email: email}).Find(&userAccount)
-				//if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
-				//	return &userAccount, nil
-				//}
-				return &email, nil
-			}
-		}
+	_, err = CheckDischargeToken(tokens, acl)
+	if err != nil {
+		return nil, err
+	}
+
+	return acl, nil
+}
+
+// CheckDischargeToken checks the token of the discharge was issued to the ACL's email and is still valid, the
+// token is returned
+func CheckDischargeToken(tokens repositories.IDischargeTokensRepository, acl *auth.ACL) (*models.DischargeToken, error) {
+	token, err := tokens.GetToken(acl.TokenID)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("unauthorized")
+	if token == nil || token.Account.Email != acl.Email {
+		return nil, fmt.Errorf("%w: unknown token %s", auth.ErrUnauthorized, acl.TokenID)
+	}
+
+	if !token.IsValidAt(time.Now()) {
+		return nil, fmt.Errorf("%w: token %s has expired or been revoked", auth.ErrUnauthorized, acl.TokenID)
+	}
+
+	return token, nil
 }
 
+// CheckForAuthorizedUserWithMacaroons only lets requests through with macaroons that verify, the email and ACL
+// they grant are set in the context. Requests for a snap or channel outside the scope of the macaroon are rejected,
+// routes name the snap with a :snap (name) or :id (snap id) parameter and the channel with a channel query
+// parameter.
 func CheckForAuthorizedUserWithMacaroons(db *gorm.DB, rootKey string) gin.HandlerFunc {
+	storeId := viper.GetString(configkey.MacaroonStoreId)
+	tokens := repositories.NewDischargeTokensRepository(db)
+
 	return func(c *gin.Context) {
-		rootS, dischargeS := GetRootMacaroons(c)
-		root, _ := auth.MacaroonDeserialize(rootS)
-		discharge, _ := auth.MacaroonDeserialize(dischargeS)
+		acl, err := verifyAuthorization(tokens, rootKey, storeId, c.GetHeader("Authorization"))
+		if err != nil {
+			logrus.Error(err)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		err = checkScope(c, db, acl)
+		if err != nil {
+			logrus.Error(err)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error_list": []gin.H{{"code": "macaroon-permission-required", "message": err.Error()}}})
+			return
+		}
+
+		c.Set("email", acl.Email)
+		c.Set("acl", acl)
+		c.Next()
+	}
+}
+
+func checkScope(c *gin.Context, db *gorm.DB, acl *auth.ACL) error {
+	if channel := c.Query("channel"); channel != "" && !acl.AllowsChannels([]string{channel}) {
+		return fmt.Errorf("the macaroon does not allow channel %s", channel)
+	}
 
-		if root != nil && discharge != nil {
-			err := root.Verify([]byte(rootKey), func(caveat string) error {
-				return nil
-			}, []*macaroon.Macaroon{discharge})
-
-			if err != nil {
-				logrus.Error(err)
-				c.AbortWithStatus(http.StatusUnauthorized)
-			} else {
-				isAuthorized := false
-				for _, cav := range root.Caveats() {
-					if string(cav.Id) != "is-authorized-or-whatever" {
-						isAuthorized = true
-						break
-					}
-				}
-
-				if isAuthorized {
-					var email string
-					for _, cav := range discharge.Caveats() {
-						cavAsString := string(cav.Id)
-						if strings.Contains(cavAsString, "email") {
-							parts := strings.Split(cavAsString, "=")
-							if len(parts) != 2 {
-								c.AbortWithStatus(http.StatusUnauthorized)
-							}
-							email = parts[1]
-							c.Set("email", email)
-						} else {
-							c.Set("acl", cav.Id)
-						}
-					}
-
-					c.Set("email", email)
-					c.Next()
-					return
-				}
-			}
+	if acl.SnapIDs == nil {
+		return nil
+	}
+
+	snapId := c.Param("id")
+	if snapName := c.Param("snap"); snapName != "" {
+		var snapEntry models.SnapEntry
+		result := db.Where(&models.SnapEntry{Name: snapName}).Find(&snapEntry)
+		if result.Error != nil {
+			return result.Error
 		}
 
-		c.AbortWithStatus(http.StatusUnauthorized)
+		// an unknown snap is left to the handler to report
+		snapId = snapEntry.SnapStoreID
+	}
+
+	if snapId != "" && !acl.AllowsSnap(snapId) {
+		return fmt.Errorf("the macaroon does not allow snap %s", snapId)
 	}
+
+	return nil
+}
+
+// GetACL returns the ACL set by CheckForAuthorizedUserWithMacaroons, nil if there is none
+func GetACL(c *gin.Context) *auth.ACL {
+	value, ok := c.Get("acl")
+	if !ok {
+		return nil
+	}
+
+	acl, _ := value.(*auth.ACL)
+	return acl
 }
 
 func GetRootMacaroons(c *gin.Context) (string, string) {
@@ -123,8 +146,6 @@ func GetRootMacaroons(c *gin.Context) (string, string) {
 	var root string
 	var discharge string
 	for _, t := range tokens {
-		fmt.Println(t)
-
 		if strings.Contains(t, " root=") {
 			root = strings.TrimPrefix(t, " root=")
 		} else {
@@ -141,8 +162,6 @@ func GetRootMacaroonsFromString(macaroonAuth string) (string, string) {
 	var root string
 	var discharge string
 	for _, t := range tokens {
-		fmt.Println(t)
-
 		if strings.Contains(t, " root=") {
 			root = strings.TrimPrefix(t, " root=")
 		} else {
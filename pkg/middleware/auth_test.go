@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/auth"
+	"github.com/freetocompute/kebe/pkg/models"
+	macaroonv2 "gopkg.in/macaroon.v2"
+)
+
+const (
+	testRootKey      = "test-root-key"
+	testDischargeKey = "test-discharge-key"
+	testStoreId      = "test-store"
+)
+
+var errTestDatabase = errors.New("database is down")
+
+type fakeDischargeTokens struct {
+	tokens map[string]*models.DischargeToken
+	err    error
+}
+
+func (f *fakeDischargeTokens) AddToken(token *models.DischargeToken) error {
+	f.tokens[token.TokenID] = token
+	return nil
+}
+
+func (f *fakeDischargeTokens) GetToken(tokenId string) (*models.DischargeToken, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return f.tokens[tokenId], nil
+}
+
+func (f *fakeDischargeTokens) GetTokens(accountId string) (*[]models.DischargeToken, error) {
+	return nil, nil
+}
+
+func (f *fakeDischargeTokens) RevokeToken(tokenId string) (*models.DischargeToken, error) {
+	return nil, nil
+}
+
+func (f *fakeDischargeTokens) RevokeAccountTokens(accountId string) (int64, error) {
+	return 0, nil
+}
+
+// newTestAuthorization returns an Authorization header value with a root macaroon signed with rootKey and a
+// discharge of it with the caveats
+func newTestAuthorization(t *testing.T, rootKey string, dischargeCaveats []string) string {
+	root := auth.MustNewMacaroon([]byte(rootKey), []byte("root-id"), "dashboard", macaroonv2.V2)
+	acl := auth.ACL{StoreID: testStoreId, Permissions: []string{auth.PermissionPackageAccess}}
+	for _, caveat := range acl.Caveats() {
+		if err := root.AddFirstPartyCaveat([]byte(caveat)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := root.AddThirdPartyCaveat([]byte(testDischargeKey), []byte("caveat-id"), "login")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discharge := auth.MustNewMacaroon([]byte(testDischargeKey), []byte("caveat-id"), "login", macaroonv2.V2)
+	for _, caveat := range dischargeCaveats {
+		if err = discharge.AddFirstPartyCaveat([]byte(caveat)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	discharge.Bind(root.Signature())
+
+	rootS, err := auth.MacaroonSerialize(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dischargeS, err := auth.MacaroonSerialize(discharge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return "Macaroon root=" + rootS + ", discharge=" + dischargeS
+}
+
+func TestVerifyAuthorization(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	account := models.Account{Email: "dev@example.com"}
+	tokens := &fakeDischargeTokens{tokens: map[string]*models.DischargeToken{
+		"valid":         {TokenID: "valid", Account: account, ExpiresAt: future},
+		"expired":       {TokenID: "expired", Account: account, ExpiresAt: past},
+		"revoked":       {TokenID: "revoked", Account: account, ExpiresAt: future, RevokedAt: &past},
+		"later-revoked": {TokenID: "later-revoked", Account: account, ExpiresAt: future, RevokedAt: &future},
+		"other-account": {TokenID: "other-account", Account: models.Account{Email: "other@example.com"}, ExpiresAt: future},
+	}}
+
+	tests := []struct {
+		name          string
+		tokens        *fakeDischargeTokens
+		authorization string
+		expected      error
+	}{
+		{
+			name:          "valid",
+			tokens:        tokens,
+			authorization: newTestAuthorization(t, testRootKey, auth.DischargeCaveats("dev@example.com", "valid", future)),
+		},
+		{
+			name:          "revoked in the future",
+			tokens:        tokens,
+			authorization: newTestAuthorization(t, testRootKey, auth.DischargeCaveats("dev@example.com", "later-revoked", future)),
+		},
+		{
+			name:          "expired token",
+			tokens:        tokens,
+			authorization: newTestAuthorization(t, testRootKey, auth.DischargeCaveats("dev@example.com", "expired", future)),
+			expected:      auth.ErrUnauthorized,
+		},
+		{
+			name:          "expired discharge",
+			tokens:        tokens,
+			authorization: newTestAuthorization(t, testRootKey, auth.DischargeCaveats("dev@example.com", "valid", past)),
+			expected:      auth.ErrUnauthorized,
+		},
+		{
+			name:          "revoked",
+			tokens:        tokens,
+			authorization: newTestAuthorization(t, testRootKey, auth.DischargeCaveats("dev@example.com", "revoked", future)),
+			expected:      auth.ErrUnauthorized,
+		},
+		{
+			name:          "unknown token",
+			tokens:        tokens,
+			authorization: newTestAuthorization(t, testRootKey, auth.DischargeCaveats("dev@example.com", "unknown", future)),
+			expected:      auth.ErrUnauthorized,
+		},
+		{
+			name:          "token of another account",
+			tokens:        tokens,
+			authorization: newTestAuthorization(t, testRootKey, auth.DischargeCaveats("dev@example.com", "other-account", future)),
+			expected:      auth.ErrUnauthorized,
+		},
+		{
+			name:          "wrong signer",
+			tokens:        tokens,
+			authorization: newTestAuthorization(t, "another-root-key", auth.DischargeCaveats("dev@example.com", "valid", future)),
+			expected:      auth.ErrUnauthorized,
+		},
+		{
+			name:          "malformed",
+			tokens:        tokens,
+			authorization: "Macaroon root=not-a-macaroon, discharge=not-a-macaroon",
+			expected:      auth.ErrUnauthorized,
+		},
+		{
+			name:          "no macaroons",
+			tokens:        tokens,
+			authorization: "",
+			expected:      auth.ErrUnauthorized,
+		},
+		{
+			name:          "repository error",
+			tokens:        &fakeDischargeTokens{err: errTestDatabase},
+			authorization: newTestAuthorization(t, testRootKey, auth.DischargeCaveats("dev@example.com", "valid", future)),
+			expected:      errTestDatabase,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			acl, err := verifyAuthorization(test.tokens, testRootKey, testStoreId, test.authorization)
+			if test.expected != nil {
+				if !errors.Is(err, test.expected) {
+					t.Fatalf("expected %v, got %v", test.expected, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("verifyAuthorization failed: %s", err)
+			}
+
+			if acl.Email != "dev@example.com" {
+				t.Errorf("expected the email of the discharge, got %s", acl.Email)
+			}
+		})
+	}
+}
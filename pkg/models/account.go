@@ -1,6 +1,19 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"github.com/snapcore/snapd/snap"
+	"gorm.io/gorm"
+)
+
+// Account validation levels, these match the values snapd understands in the
+// "validation" header of account assertions and the publisher blocks of the store API.
+const (
+	AccountValidationUnproven = "unproven"
+	AccountValidationStarred  = "starred"
+	AccountValidationVerified = "verified"
+)
 
 type Key struct {
 	gorm.Model
@@ -9,23 +22,73 @@ type Key struct {
 	EncodedPublicKey string
 	AccountID        uint
 	Account          Account
+	// Since and Until bound when the key is trusted, a key without Until does not expire. Revoking a key
+	// sets Until so assertions signed with it after that are no longer accepted.
+	Since time.Time
+	Until *time.Time
+}
+
+// IsValidAt is true if the key can be used to sign, or verify, assertions at the given time
+func (k *Key) IsValidAt(when time.Time) bool {
+	return !when.Before(k.Since) && (k.Until == nil || when.Before(*k.Until))
 }
 
 type SSHKey struct {
 	gorm.Model
 	PublicKeyString string `gorm:"unique"`
-	AccountID       uint
-	Account         Account
+	// Fingerprint is the SHA256 fingerprint of the key, the same key can't be added twice with another comment
+	Fingerprint string `gorm:"uniqueIndex"`
+	AccountID   uint
+	Account     Account
 }
 
 type Account struct {
 	gorm.Model
 	// AccountId is the same as publisher-id and developer-id
 	AccountId   string `gorm:"unique"`
-	DisplayName string `gorm:"unique"`
+	DisplayName string
 	Username    string `gorm:"unique"`
 	Keys        []Key
 	SnapEntries []SnapEntry
 	SSHKeys     []SSHKey
 	Email       string
+	// Validation is one of unproven, starred or verified
+	Validation string `gorm:"default:unproven"`
+	// SuspendedAt is set while the account is suspended, it can't log in, push or release and its snaps aren't found
+	SuspendedAt *time.Time
+	// Admin lets the account log in to admind with its local credential when admind doesn't use OIDC
+	Admin bool `gorm:"default:false"`
+}
+
+func (a *Account) IsSuspended() bool {
+	return a.SuspendedAt != nil
+}
+
+// IsValidAccountValidation returns true if the given value is a validation level snapd understands
+func IsValidAccountValidation(validation string) bool {
+	switch validation {
+	case AccountValidationUnproven, AccountValidationStarred, AccountValidationVerified:
+		return true
+	}
+
+	return false
+}
+
+// GetValidation returns the validation level of the account, defaulting to unproven
+func (a *Account) GetValidation() string {
+	if a.Validation == "" {
+		return AccountValidationUnproven
+	}
+
+	return a.Validation
+}
+
+// ToStoreAccount returns the publisher block used in store responses for this account
+func (a *Account) ToStoreAccount() snap.StoreAccount {
+	return snap.StoreAccount{
+		ID:          a.AccountId,
+		Username:    a.Username,
+		DisplayName: a.DisplayName,
+		Validation:  a.GetValidation(),
+	}
 }
@@ -0,0 +1,18 @@
+package models
+
+import "gorm.io/gorm"
+
+// AdminAction records a change made through admind and the admin who made it
+type AdminAction struct {
+	gorm.Model
+	// Subject is the OIDC subject of the admin
+	Subject  string `gorm:"index"`
+	Username string
+	Email    string
+	Method   string
+	Path     string
+	// Query and Body are what the change was made to, with passwords, private keys and other secrets redacted
+	Query  string
+	Body   string `gorm:"type:text"`
+	Status int
+}
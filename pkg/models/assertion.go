@@ -0,0 +1,25 @@
+package models
+
+import "gorm.io/gorm"
+
+// Assertion is a signed assertion as it was served, it is stored so the same bytes can be
+// served again and a new revision is only signed when the content changes. The table is also
+// the backstore of the assertion database, so every store instance sees the same assertions.
+type Assertion struct {
+	gorm.Model
+	Type string `gorm:"uniqueIndex:idx_assertions_type_primary_key_revision"`
+	// PrimaryKey is the primary key header values of the assertion joined with "/"
+	PrimaryKey string `gorm:"uniqueIndex:idx_assertions_type_primary_key_revision"`
+	Revision   int    `gorm:"uniqueIndex:idx_assertions_type_primary_key_revision"`
+	Format     int
+	// SequenceKey and Sequence are only set for sequence forming assertions, the sequence key
+	// is the primary key without the sequence number
+	SequenceKey string `gorm:"index"`
+	Sequence    int
+	// Headers are the headers of the assertion as JSON, the backstore searches them
+	Headers string `gorm:"type:jsonb"`
+	// ContentDigest identifies the content of the assertion, ignoring its timestamp and revision.
+	// It is empty for assertions added to the assertion database rather than signed by the store.
+	ContentDigest string
+	Encoded       string `gorm:"type:text"`
+}
@@ -1,12 +1,101 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// AuthRequest is a request id handed out to devices, it is used as the nonce in a serial request
 type AuthRequest struct {
 	gorm.Model
+	// UsedAt is set when a serial request is first made with this request id
+	UsedAt *time.Time
+	// DeviceKeySHA3384 is the device key of the serial request that used this request id
+	DeviceKeySHA3384 string
 }
 
+// Serial is a serial assertion issued to a device by the serial vault
 type Serial struct {
 	gorm.Model
-	SerialUUID string
+	BrandID          string `gorm:"uniqueIndex:idx_serials_brand_id_model_serial_uuid"`
+	ModelName        string `gorm:"column:model;uniqueIndex:idx_serials_brand_id_model_serial_uuid"`
+	SerialUUID       string `gorm:"uniqueIndex:idx_serials_brand_id_model_serial_uuid"`
+	DeviceKeySHA3384 string `gorm:"index"`
+	EncodedDeviceKey string
+	// Encoded is the signed serial assertion returned to the device
+	Encoded   string
+	RevokedAt *time.Time
+}
+
+func (s *Serial) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// Nonce is handed out to devices to include in their device-session-request, it can only be used once
+type Nonce struct {
+	gorm.Model
+	Value     string `gorm:"unique"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// DeviceSession is a session issued to a device with a serial, the device macaroon refers to it by id
+type DeviceSession struct {
+	gorm.Model
+	SerialID  uint
+	Serial    Serial
+	ExpiresAt time.Time
+}
+
+// DischargeToken is a discharge macaroon issued by the login service, the macaroon carries the token id so it
+// can be revoked
+type DischargeToken struct {
+	gorm.Model
+	TokenID   string `gorm:"unique"`
+	AccountID uint   `gorm:"index"`
+	Account   Account
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+// IsValidAt checks the token has neither expired nor been revoked at the given time
+func (dt *DischargeToken) IsValidAt(when time.Time) bool {
+	return when.Before(dt.ExpiresAt) && (dt.RevokedAt == nil || when.Before(*dt.RevokedAt))
+}
+
+// TOTPDevice is the authenticator an account enrolled for two-factor authentication, the login service asks for a
+// code from it once it's confirmed
+type TOTPDevice struct {
+	gorm.Model
+	AccountID uint `gorm:"uniqueIndex"`
+	Account   Account
+	// Secret is the base32 encoded shared secret
+	Secret      string
+	ConfirmedAt *time.Time
+	// LastUsedStep is the time step of the last code accepted, a code can't be used twice
+	LastUsedStep int64
+}
+
+func (td *TOTPDevice) IsConfirmed() bool {
+	return td.ConfirmedAt != nil
+}
+
+// LocalCredential is the password of an account for the login service's local identity backend
+type LocalCredential struct {
+	gorm.Model
+	AccountID uint `gorm:"uniqueIndex"`
+	Account   Account
+	// PasswordHash is the bcrypt hash of the password
+	PasswordHash string
+}
+
+// AdminSession is issued by admind to an admin account that logged in with its local credential, kebe-admin sends
+// the token as a bearer token. Only the sha256 of the token is stored.
+type AdminSession struct {
+	gorm.Model
+	TokenHash string `gorm:"unique"`
+	AccountID uint   `gorm:"index"`
+	Account   Account
+	ExpiresAt time.Time
 }
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// ModelSnap is an entry of the snaps header of a UC20+ model assertion
+type ModelSnap struct {
+	Name           string   `json:"name"`
+	ID             string   `json:"id,omitempty"`
+	Type           string   `json:"type,omitempty"`
+	DefaultChannel string   `json:"default-channel,omitempty"`
+	Presence       string   `json:"presence,omitempty"`
+	Modes          []string `json:"modes,omitempty"`
+}
+
+// ModelDefinition is what a brand asks for in a model assertion, it uses the same names as the assertion
+// headers so existing model json files can be used as is
+type ModelDefinition struct {
+	BrandID         string      `json:"brand-id"`
+	Model           string      `json:"model"`
+	DisplayName     string      `json:"display-name,omitempty"`
+	Architecture    string      `json:"architecture"`
+	Base            string      `json:"base,omitempty"`
+	Kernel          string      `json:"kernel,omitempty"`
+	Gadget          string      `json:"gadget,omitempty"`
+	Store           string      `json:"store,omitempty"`
+	Grade           string      `json:"grade,omitempty"`
+	SerialAuthority []string    `json:"serial-authority,omitempty"`
+	RequiredSnaps   []string    `json:"required-snaps,omitempty"`
+	Snaps           []ModelSnap `json:"snaps,omitempty"`
+}
+
+// BrandModel is a model definition registered for a brand account, the signed model assertions are kept
+// with the other assertions
+type BrandModel struct {
+	gorm.Model
+	BrandID   string `gorm:"uniqueIndex:idx_brand_models_brand_id_model"`
+	ModelName string `gorm:"column:model;uniqueIndex:idx_brand_models_brand_id_model"`
+	// Definition is the ModelDefinition as json
+	Definition string
+}
+
+func (bm *BrandModel) GetDefinition() (*ModelDefinition, error) {
+	var definition ModelDefinition
+	err := json.Unmarshal([]byte(bm.Definition), &definition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &definition, nil
+}
+
+// Headers returns the model assertion headers for the definition, leaving revision and timestamp to the signer
+func (md *ModelDefinition) Headers() map[string]interface{} {
+	headers := map[string]interface{}{
+		"authority-id": md.BrandID,
+		"series":       "16",
+		"brand-id":     md.BrandID,
+		"model":        md.Model,
+		"architecture": md.Architecture,
+	}
+
+	optional := map[string]string{
+		"display-name": md.DisplayName,
+		"base":         md.Base,
+		"kernel":       md.Kernel,
+		"gadget":       md.Gadget,
+		"store":        md.Store,
+		"grade":        md.Grade,
+	}
+	for k, v := range optional {
+		if v != "" {
+			headers[k] = v
+		}
+	}
+
+	if len(md.SerialAuthority) > 0 {
+		headers["serial-authority"] = toInterfaceList(md.SerialAuthority)
+	}
+
+	if len(md.RequiredSnaps) > 0 {
+		headers["required-snaps"] = toInterfaceList(md.RequiredSnaps)
+	}
+
+	if len(md.Snaps) > 0 {
+		var snaps []interface{}
+		for _, s := range md.Snaps {
+			snap := map[string]interface{}{
+				"name": s.Name,
+			}
+			if s.ID != "" {
+				snap["id"] = s.ID
+			}
+			if s.Type != "" {
+				snap["type"] = s.Type
+			}
+			if s.DefaultChannel != "" {
+				snap["default-channel"] = s.DefaultChannel
+			}
+			if s.Presence != "" {
+				snap["presence"] = s.Presence
+			}
+			if len(s.Modes) > 0 {
+				snap["modes"] = toInterfaceList(s.Modes)
+			}
+			snaps = append(snaps, snap)
+		}
+		headers["snaps"] = snaps
+	}
+
+	return headers
+}
+
+func toInterfaceList(values []string) []interface{} {
+	var list []interface{}
+	for _, v := range values {
+		list = append(list, v)
+	}
+
+	return list
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SnapCollaborator is an account other than the publisher that can work on a snap. A collaborator that is removed
+// keeps its row with Until set, the snap-developer assertion lists every period an account was a developer so
+// revisions uploaded during it stay valid.
+type SnapCollaborator struct {
+	gorm.Model
+	SnapEntryID uint `gorm:"index"`
+	SnapEntry   SnapEntry
+
+	AccountID uint `gorm:"index"`
+	Account   Account
+
+	// Permissions is a comma-separated string of the dashboard permissions granted on the snap
+	Permissions string
+	Since       time.Time
+	Until       *time.Time
+}
+
+func (sc *SnapCollaborator) IsActive() bool {
+	return sc.Until == nil
+}
+
+func (sc *SnapCollaborator) GetPermissions() []string {
+	if sc.Permissions == "" {
+		return []string{}
+	}
+
+	return strings.Split(sc.Permissions, ",")
+}
+
+func (sc *SnapCollaborator) HasPermission(permission string) bool {
+	for _, p := range sc.GetPermissions() {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
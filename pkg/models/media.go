@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/freetocompute/kebe/config/configkey"
+	"github.com/freetocompute/kebe/pkg/store/responses"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// Media types, these match what snapcraft sends to binary-metadata and what snapd expects in the
+// media of find/info responses
+const (
+	MediaTypeIcon       = "icon"
+	MediaTypeScreenshot = "screenshot"
+	MediaTypeBanner     = "banner"
+	MediaTypeBannerIcon = "banner-icon"
+)
+
+// MediaBucket is the object store bucket media files are kept in
+const MediaBucket = "media"
+
+type SnapMedia struct {
+	gorm.Model
+	Type string
+	// Filename is the name of the object in the media bucket, it is content addressed
+	Filename string `gorm:"index"`
+	// OriginalFilename is the name of the file as it was uploaded or found in the snap
+	OriginalFilename string
+	SHA3_384         string `gorm:"column:sha3_384"`
+	ContentType      string
+	Width            int64
+	Height           int64
+	// Position orders media of the same type, e.g. screenshots
+	Position    int
+	SnapEntryID uint
+}
+
+func (SnapMedia) TableName() string {
+	return "snap_media"
+}
+
+// IsValidMediaType returns true if the type is one of the media types the store supports
+func IsValidMediaType(mediaType string) bool {
+	switch mediaType {
+	case MediaTypeIcon, MediaTypeScreenshot, MediaTypeBanner, MediaTypeBannerIcon:
+		return true
+	}
+
+	return false
+}
+
+func (sm *SnapMedia) URL() string {
+	return fmt.Sprintf(viper.GetString(configkey.StoreAPIURL)+"/media/%s", sm.Filename)
+}
+
+func (sm *SnapMedia) ToStoreSnapMedia() responses.StoreSnapMedia {
+	return responses.StoreSnapMedia{
+		Type:   sm.Type,
+		URL:    sm.URL(),
+		Width:  sm.Width,
+		Height: sm.Height,
+	}
+}
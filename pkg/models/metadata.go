@@ -0,0 +1,118 @@
+package models
+
+import (
+	"github.com/freetocompute/kebe/pkg/store/responses"
+	"gorm.io/gorm"
+)
+
+// Metadata field names, these are the names used by the dashboard metadata API
+const (
+	MetadataFieldTitle       = "title"
+	MetadataFieldSummary     = "summary"
+	MetadataFieldDescription = "description"
+	MetadataFieldContact     = "contact"
+	MetadataFieldWebsite     = "website"
+	MetadataFieldLicense     = "license"
+	MetadataFieldCategory    = "category"
+)
+
+// SnapMetadata holds the metadata a publisher has edited through the dashboard, a nil
+// field has not been edited and the value from the snap.yaml of the latest revision is used
+type SnapMetadata struct {
+	gorm.Model
+	SnapEntryID uint `gorm:"unique"`
+
+	Title       *string
+	Summary     *string
+	Description *string
+	Contact     *string
+	Website     *string
+	License     *string
+	Category    *string
+}
+
+func (SnapMetadata) TableName() string {
+	return "snap_metadata"
+}
+
+// Fields returns pointers to each editable field keyed by its API name
+func (sm *SnapMetadata) Fields() map[string]**string {
+	return map[string]**string{
+		MetadataFieldTitle:       &sm.Title,
+		MetadataFieldSummary:     &sm.Summary,
+		MetadataFieldDescription: &sm.Description,
+		MetadataFieldContact:     &sm.Contact,
+		MetadataFieldWebsite:     &sm.Website,
+		MetadataFieldLicense:     &sm.License,
+		MetadataFieldCategory:    &sm.Category,
+	}
+}
+
+// GetMetadataValues returns the current value of each metadata field for the snap, edited values
+// take precedence over those from the snap.yaml of the given revision, which may be nil
+func (se *SnapEntry) GetMetadataValues(revision *SnapRevision) map[string]string {
+	values := map[string]string{
+		MetadataFieldTitle:       "",
+		MetadataFieldSummary:     "",
+		MetadataFieldDescription: "",
+		MetadataFieldContact:     "",
+		MetadataFieldWebsite:     "",
+		MetadataFieldLicense:     "",
+		MetadataFieldCategory:    "",
+	}
+
+	if revision != nil {
+		values[MetadataFieldTitle] = revision.Title
+		values[MetadataFieldSummary] = revision.Summary
+		values[MetadataFieldDescription] = revision.Description
+		values[MetadataFieldLicense] = revision.License
+	}
+
+	if se.Metadata != nil {
+		for name, field := range se.Metadata.Fields() {
+			if *field != nil {
+				values[name] = **field
+			}
+		}
+	}
+
+	if values[MetadataFieldTitle] == "" {
+		values[MetadataFieldTitle] = se.Name
+	}
+
+	return values
+}
+
+// ApplyMetadata fills in the text fields of the store snap from the snap's metadata
+func (se *SnapEntry) ApplyMetadata(storeSnap *responses.StoreSnap, revision *SnapRevision) {
+	values := se.GetMetadataValues(revision)
+	storeSnap.Title = values[MetadataFieldTitle]
+	storeSnap.Summary = values[MetadataFieldSummary]
+	storeSnap.Description = values[MetadataFieldDescription]
+	storeSnap.Contact = values[MetadataFieldContact]
+	storeSnap.Website = values[MetadataFieldWebsite]
+	storeSnap.License = values[MetadataFieldLicense]
+
+	if revision != nil {
+		storeSnap.Version = revision.Version
+	}
+}
+
+// LatestRevision returns the most recently uploaded revision of the snap, the Revisions
+// association must have been loaded
+func (se *SnapEntry) LatestRevision() *SnapRevision {
+	var latest *SnapRevision
+	for i := range se.Revisions {
+		rev := &se.Revisions[i]
+		// skip the empty revision created when the snap was registered
+		if rev.SnapFilename == "" {
+			continue
+		}
+
+		if latest == nil || rev.ID > latest.ID {
+			latest = rev
+		}
+	}
+
+	return latest
+}
@@ -0,0 +1,40 @@
+package models
+
+import "gorm.io/gorm"
+
+// Organization member roles, from the least to the most that can be done with the organization's snaps
+const (
+	OrganizationRoleViewer    = "viewer"
+	OrganizationRoleDeveloper = "developer"
+	OrganizationRoleReleaser  = "releaser"
+	OrganizationRoleAdmin     = "admin"
+)
+
+// Organization owns snaps on behalf of its members. It publishes as its own account, the snaps it owns belong to
+// that account so their assertions have the organization's account id as the publisher-id.
+type Organization struct {
+	gorm.Model
+	Name      string `gorm:"unique"`
+	AccountID uint   `gorm:"unique"`
+	Account   Account
+	Members   []OrganizationMember
+}
+
+type OrganizationMember struct {
+	gorm.Model
+	OrganizationID uint `gorm:"uniqueIndex:idx_organization_members_organization_id_account_id"`
+	Organization   Organization
+	AccountID      uint `gorm:"uniqueIndex:idx_organization_members_organization_id_account_id"`
+	Account        Account
+	Role           string
+}
+
+// IsValidOrganizationRole returns true if the given value is one of the organization member roles
+func IsValidOrganizationRole(role string) bool {
+	switch role {
+	case OrganizationRoleViewer, OrganizationRoleDeveloper, OrganizationRoleReleaser, OrganizationRoleAdmin:
+		return true
+	}
+
+	return false
+}
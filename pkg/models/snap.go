@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/viper"
 	"gorm.io/gorm"
 	"io"
+	"sort"
 )
 
 type SnapTrack struct {
@@ -52,13 +53,15 @@ type SnapBranch struct {
 
 type SnapEntry struct {
 	gorm.Model
-	Name        string `json:"name"`
+	Name        string `json:"name" gorm:"uniqueIndex:idx_snap_entries_name,where:deleted_at IS NULL"`
 	SnapStoreID string `json:"snap-id"`
 	Revisions   []SnapRevision
 	Type        string
 	Confinement string
 	Base        string
 	Uploads     []SnapUpload
+	Media       []SnapMedia
+	Metadata    *SnapMetadata
 
 	AccountID uint
 	Account   Account
@@ -71,6 +74,15 @@ type SnapRevision struct {
 	SHA3_384       string
 	SHA3384Encoded string `gorm:"column:sha3_384_encoded"`
 	Size           int64
+	// UploaderID is the account that pushed the revision, it is 0 for revisions pushed before it was recorded
+	UploaderID uint
+
+	// These come from the snap.yaml of the revision
+	Version     string
+	Title       string
+	Summary     string
+	Description string
+	License     string
 }
 
 type SnapUpload struct {
@@ -82,6 +94,10 @@ type SnapUpload struct {
 	Channels    string
 	SnapEntryID uint
 	SnapEntry   SnapEntry
+	// AccountID is the account that pushed the upload, the publisher or a collaborator
+	AccountID uint
+	// RevisionID is set once the upload has been processed into a revision
+	RevisionID *uint
 }
 
 func (se *SnapEntry) ToStoreSnap(snapRevision *SnapRevision) (*responses.StoreSnap, error) {
@@ -111,7 +127,31 @@ func (se *SnapEntry) ToStoreSnap(snapRevision *SnapRevision) (*responses.StoreSn
 		},
 		Confinement: se.Confinement,
 		Base:        &se.Base,
+		Publisher:   se.Account.ToStoreAccount(),
 	}
 
+	storeSnap.Media = se.GetStoreSnapMedia()
+	se.ApplyMetadata(storeSnap, snapRevision)
+
 	return storeSnap, nil
 }
+
+// GetStoreSnapMedia returns the media for the snap in the form used in store responses,
+// the Media association must have been loaded
+func (se *SnapEntry) GetStoreSnapMedia() []responses.StoreSnapMedia {
+	sorted := make([]SnapMedia, len(se.Media))
+	copy(sorted, se.Media)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Type != sorted[j].Type {
+			return sorted[i].Type < sorted[j].Type
+		}
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	media := []responses.StoreSnapMedia{}
+	for _, m := range sorted {
+		media = append(media, m.ToStoreSnapMedia())
+	}
+
+	return media
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Snap name dispute statuses
+const (
+	SnapNameDisputeOpen     = "open"
+	SnapNameDisputeAccepted = "accepted"
+	SnapNameDisputeRejected = "rejected"
+)
+
+// ReservedSnapName is a name that can't be registered first-come-first-served. Without an account nobody can
+// register it, otherwise only that account can.
+type ReservedSnapName struct {
+	gorm.Model
+	Name      string `gorm:"unique"`
+	AccountID *uint
+	Account   *Account
+	Reason    string
+}
+
+// IsReservedFor returns true if the account is the one allowed to register the name
+func (r *ReservedSnapName) IsReservedFor(accountId uint) bool {
+	return r.AccountID != nil && *r.AccountID == accountId
+}
+
+// SnapNameDispute is a claim by an account to a name that is registered by another account or reserved
+type SnapNameDispute struct {
+	gorm.Model
+	Name       string `gorm:"index"`
+	AccountID  uint
+	Account    Account
+	Comment    string
+	Status     string `gorm:"default:open"`
+	ResolvedAt *time.Time
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// ValidationSetSnap is an entry of the snaps header of a validation-set assertion
+type ValidationSetSnap struct {
+	Name     string `json:"name"`
+	ID       string `json:"id,omitempty"`
+	Presence string `json:"presence,omitempty"`
+	Revision int    `json:"revision,omitempty"`
+}
+
+// ValidationSetDefinition is what an account wants in a validation-set, using the names of the assertion headers
+type ValidationSetDefinition struct {
+	AccountID string              `json:"account-id"`
+	Name      string              `json:"name"`
+	Snaps     []ValidationSetSnap `json:"snaps"`
+}
+
+// ValidationSet is one sequence of a named validation set, every change to a validation set is a new sequence
+type ValidationSet struct {
+	gorm.Model
+	AccountID string `gorm:"uniqueIndex:idx_validation_sets_account_id_name_sequence"`
+	Name      string `gorm:"uniqueIndex:idx_validation_sets_account_id_name_sequence"`
+	Sequence  int    `gorm:"uniqueIndex:idx_validation_sets_account_id_name_sequence"`
+	// Definition is the ValidationSetDefinition as json
+	Definition string
+}
+
+func (vs *ValidationSet) GetDefinition() (*ValidationSetDefinition, error) {
+	var definition ValidationSetDefinition
+	err := json.Unmarshal([]byte(vs.Definition), &definition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &definition, nil
+}
+
+// Headers returns the validation-set assertion headers for this sequence, leaving revision and timestamp to the signer
+func (vs *ValidationSet) Headers() (map[string]interface{}, error) {
+	definition, err := vs.GetDefinition()
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []interface{}
+	for _, s := range definition.Snaps {
+		snap := map[string]interface{}{
+			"name": s.Name,
+			"id":   s.ID,
+		}
+		if s.Presence != "" {
+			snap["presence"] = s.Presence
+		}
+		if s.Revision != 0 {
+			snap["revision"] = strconv.Itoa(s.Revision)
+		}
+		snaps = append(snaps, snap)
+	}
+
+	return map[string]interface{}{
+		"authority-id": vs.AccountID,
+		"series":       "16",
+		"account-id":   vs.AccountID,
+		"name":         vs.Name,
+		"sequence":     strconv.Itoa(vs.Sequence),
+		"snaps":        snaps,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package objectstore
 
 import (
+	bytes2 "bytes"
 	"context"
 	"errors"
 	"io"
@@ -87,6 +88,28 @@ func (obs *Impl) SaveFileToBucket(bucket string, filePath string) error {
 	return nil
 }
 
+// SaveBytesToBucket saves the bytes as the named object in the bucket, creating the bucket if needed
+func (obs *Impl) SaveBytesToBucket(bucket string, objectName string, bytes []byte, contentType string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exists, _ := obs.MinioClient.BucketExists(ctx, bucket)
+	if !exists {
+		err := obs.MinioClient.MakeBucket(ctx, bucket, minio.MakeBucketOptions{})
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	uploadInfo, err := obs.MinioClient.PutObject(ctx, bucket, objectName, bytes2.NewReader(bytes), int64(len(bytes)), minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Saved to bucket: %+v", uploadInfo)
+
+	return nil
+}
+
 func GetMinioClient() *minio.Client {
 	accessKey := viper.GetString(configkey.MinioAccessKey)
 	secretKey := viper.GetString(configkey.MinioSecretKey)
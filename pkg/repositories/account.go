@@ -1,6 +1,10 @@
 package repositories
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/auth"
 	"github.com/freetocompute/kebe/pkg/database"
 	"github.com/freetocompute/kebe/pkg/models"
 	"github.com/sirupsen/logrus"
@@ -11,8 +15,26 @@ import (
 type IAccountRepository interface {
 	GetAccountByEmail(email string, preload bool) (*models.Account, error)
 	GetAccountById(accountId string, preload bool) (*models.Account, error)
-	AddKey(name string, SHA3384 string, encodedPublicKey string, accountEmail string) (*models.Key, error)
+	GetAccount(id uint, preload bool) (*models.Account, error)
+	AddKey(name string, SHA3384 string, encodedPublicKey string, accountEmail string, since time.Time, until *time.Time) (*models.Key, error)
 	GetKeyBySHA3384(sha3384 string) (*models.Key, error)
+	RevokeKey(sha3384 string) (*models.Key, error)
+	ExpireKey(sha3384 string, until time.Time) (*models.Key, error)
+	GetSigningKeys(accountId string, when time.Time) ([]models.Key, error)
+	SetAccountValidation(accountId string, validation string) (*models.Account, error)
+	GetOrganizationRole(publisherId uint, memberId uint) (string, error)
+	GetOrganizationSnaps(memberId uint) (*[]models.SnapEntry, error)
+	GetSSHKeys(accountId uint) (*[]models.SSHKey, error)
+	GetSSHKeyByFingerprint(fingerprint string) (*models.SSHKey, error)
+	AddSSHKey(accountId uint, publicKey string, fingerprint string) (*models.SSHKey, error)
+	DeleteSSHKey(accountId uint, fingerprint string) (bool, error)
+	BackfillSSHKeyFingerprints() error
+	GetAccounts() (*[]models.Account, error)
+	GetConflictingAccounts(account *models.Account) (*[]models.Account, error)
+	SaveAccount(account *models.Account) error
+	SetAccountSuspended(account *models.Account, suspended bool) error
+	SetAccountAdmin(account *models.Account, admin bool) error
+	DeleteAccount(account *models.Account) error
 }
 
 type AccountRepository struct {
@@ -33,6 +55,16 @@ func (a *AccountRepository) GetAccountById(accountId string, preload bool) (*mod
 	return a.getAccountByWhereModel(whereModel, preload)
 }
 
+// GetAccount returns the account with the database id, nil if there is none
+func (a *AccountRepository) GetAccount(id uint, preload bool) (*models.Account, error) {
+	if id == 0 {
+		return nil, nil
+	}
+
+	whereModel := &models.Account{Model: gorm.Model{ID: id}}
+	return a.getAccountByWhereModel(whereModel, preload)
+}
+
 func (a *AccountRepository) GetAccountByEmail(email string, preload bool) (*models.Account, error) {
 	var userAccount models.Account
 	var db *gorm.DB
@@ -49,7 +81,60 @@ func (a *AccountRepository) GetAccountByEmail(email string, preload bool) (*mode
 	return nil, db.Error
 }
 
-func (a *AccountRepository) AddKey(name string, SHA3384 string, encodedPublicKey string, email string) (*models.Key, error) {
+// GetOrganizationRole returns the role the member has in the organization that publishes as the account with
+// publisherId, it's empty if the account isn't an organization or the member doesn't belong to it
+func (a *AccountRepository) GetOrganizationRole(publisherId uint, memberId uint) (string, error) {
+	var member models.OrganizationMember
+	db := a.db.Joins("JOIN organizations ON organizations.id = organization_members.organization_id AND organizations.deleted_at IS NULL").
+		Where("organizations.account_id = ? AND organization_members.account_id = ?", publisherId, memberId).Find(&member)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return "", db.Error
+	}
+
+	return member.Role, nil
+}
+
+// GetOrganizationSnaps returns the snaps published by the organizations the account is a member of
+func (a *AccountRepository) GetOrganizationSnaps(memberId uint) (*[]models.SnapEntry, error) {
+	var snapEntries []models.SnapEntry
+	db := a.db.Joins("JOIN organizations ON organizations.account_id = snap_entries.account_id AND organizations.deleted_at IS NULL").
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id AND organization_members.deleted_at IS NULL").
+		Where("organization_members.account_id = ?", memberId).Find(&snapEntries)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &snapEntries, nil
+}
+
+// SetAccountValidation returns nil if there is no account with the id
+func (a *AccountRepository) SetAccountValidation(accountId string, validation string) (*models.Account, error) {
+	if !models.IsValidAccountValidation(validation) {
+		return nil, fmt.Errorf("invalid validation level: %s", validation)
+	}
+
+	if accountId == "" {
+		return nil, nil
+	}
+
+	acct, err := a.GetAccountById(accountId, false)
+	if err == nil && acct != nil {
+		acct.Validation = validation
+		db := a.db.Save(acct)
+		if db.Error != nil {
+			logrus.Error(db.Error)
+			return nil, db.Error
+		}
+
+		return acct, nil
+	}
+
+	return nil, err
+}
+
+func (a *AccountRepository) AddKey(name string, SHA3384 string, encodedPublicKey string, email string, since time.Time, until *time.Time) (*models.Key, error) {
 	acct, err := a.GetAccountByEmail(email, false)
 	if err == nil && acct != nil {
 		accountKeyToAdd := models.Key{
@@ -57,15 +142,68 @@ func (a *AccountRepository) AddKey(name string, SHA3384 string, encodedPublicKey
 			SHA3384:          SHA3384,
 			AccountID:        acct.ID,
 			EncodedPublicKey: encodedPublicKey,
+			Since:            since,
+			Until:            until,
+		}
+
+		db := a.db.Save(&accountKeyToAdd)
+		if db.Error != nil {
+			return nil, db.Error
 		}
 
-		a.db.Save(&accountKeyToAdd)
 		return &accountKeyToAdd, nil
 	}
 
 	return nil, err
 }
 
+// RevokeKey sets the until of the key to now, a key that has already expired is left as is
+func (a *AccountRepository) RevokeKey(sha3384 string) (*models.Key, error) {
+	return a.ExpireKey(sha3384, time.Now().UTC())
+}
+
+// ExpireKey sets the until of the key, a key that expires earlier than that is left as is
+func (a *AccountRepository) ExpireKey(sha3384 string, until time.Time) (*models.Key, error) {
+	key, err := a.GetKeyBySHA3384(sha3384)
+	if err == nil && key != nil {
+		if key.Until != nil && !key.Until.After(until) {
+			return key, nil
+		}
+
+		// until can't be before since, a key that isn't valid yet expires from the moment it would be
+		if until.Before(key.Since) {
+			until = key.Since
+		}
+
+		key.Until = &until
+		db := a.db.Model(key).Update("until", key.Until)
+		if db.Error != nil {
+			return nil, db.Error
+		}
+
+		return key, nil
+	}
+
+	return nil, err
+}
+
+// GetSigningKeys returns the keys of the account that are valid at the given time, the most recent first
+func (a *AccountRepository) GetSigningKeys(accountId string, when time.Time) ([]models.Key, error) {
+	acct, err := a.GetAccountById(accountId, false)
+	if err != nil || acct == nil {
+		return nil, err
+	}
+
+	var keys []models.Key
+	db := a.db.Where(&models.Key{AccountID: acct.ID}).Where("since <= ? AND (until IS NULL OR until > ?)", when, when).
+		Order("since desc, id desc").Find(&keys)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return keys, nil
+}
+
 func (a *AccountRepository) getKeyByWhereModel(whereModel *models.Key, preload bool) (*models.Key, error) {
 	var accountKey models.Key
 	var db *gorm.DB
@@ -103,3 +241,199 @@ func (a *AccountRepository) getAccountByWhereModel(whereModel *models.Account, p
 	logrus.Warningf("Account not found: %+v", whereModel)
 	return nil, nil
 }
+
+func (a *AccountRepository) GetSSHKeys(accountId uint) (*[]models.SSHKey, error) {
+	var sshKeys []models.SSHKey
+	db := a.db.Where("account_id = ?", accountId).Order("id").Find(&sshKeys)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &sshKeys, nil
+}
+
+// GetSSHKeyByFingerprint returns the key with the fingerprint whichever account it belongs to, nil if there is none
+func (a *AccountRepository) GetSSHKeyByFingerprint(fingerprint string) (*models.SSHKey, error) {
+	var sshKey models.SSHKey
+	db := a.db.Where("fingerprint = ?", fingerprint).Preload("Account").Find(&sshKey)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &sshKey, nil
+	}
+
+	return nil, db.Error
+}
+
+func (a *AccountRepository) AddSSHKey(accountId uint, publicKey string, fingerprint string) (*models.SSHKey, error) {
+	sshKey := models.SSHKey{
+		PublicKeyString: publicKey,
+		Fingerprint:     fingerprint,
+		AccountID:       accountId,
+	}
+
+	db := a.db.Create(&sshKey)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &sshKey, nil
+}
+
+// BackfillSSHKeyFingerprints sets the fingerprint of keys inserted without one. A key that duplicates another key of
+// the same account is removed, one that duplicates another account's key or doesn't parse is left as it is.
+func (a *AccountRepository) BackfillSSHKeyFingerprints() error {
+	var sshKeys []models.SSHKey
+	db := a.db.Where("fingerprint IS NULL OR fingerprint = ''").Find(&sshKeys)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return db.Error
+	}
+
+	for _, sshKey := range sshKeys {
+		_, fingerprint, err := auth.ParseSSHPublicKey(sshKey.PublicKeyString)
+		if err != nil {
+			logrus.Warningf("Not backfilling the fingerprint of ssh key %d: %s", sshKey.ID, err)
+			continue
+		}
+
+		existing, err := a.GetSSHKeyByFingerprint(fingerprint)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+
+		if existing != nil && existing.AccountID == sshKey.AccountID {
+			logrus.Infof("Removing ssh key %d, it duplicates key %d", sshKey.ID, existing.ID)
+			db = a.db.Unscoped().Delete(&models.SSHKey{}, sshKey.ID)
+		} else if existing != nil {
+			logrus.Warningf("Not backfilling the fingerprint of ssh key %d, it's also key %d of account %d", sshKey.ID,
+				existing.ID, existing.AccountID)
+			continue
+		} else {
+			db = a.db.Model(&models.SSHKey{}).Where("id = ?", sshKey.ID).Update("fingerprint", fingerprint)
+		}
+
+		if db.Error != nil {
+			logrus.Error(db.Error)
+			return db.Error
+		}
+	}
+
+	return nil
+}
+
+// DeleteSSHKey returns false if the account has no key with the fingerprint. The row is removed so the key can be
+// added again.
+func (a *AccountRepository) DeleteSSHKey(accountId uint, fingerprint string) (bool, error) {
+	db := a.db.Unscoped().Where("account_id = ? AND fingerprint = ?", accountId, fingerprint).Delete(&models.SSHKey{})
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return false, db.Error
+	}
+
+	return db.RowsAffected > 0, nil
+}
+
+func (a *AccountRepository) GetAccounts() (*[]models.Account, error) {
+	var accounts []models.Account
+	db := a.db.Preload("SnapEntries").Order("account_id").Find(&accounts)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &accounts, nil
+}
+
+// GetConflictingAccounts returns the other accounts using the account id, username, display name or email of the
+// account. Deleted accounts are included, their values can't be reused.
+func (a *AccountRepository) GetConflictingAccounts(account *models.Account) (*[]models.Account, error) {
+	conditions := a.db
+	for column, value := range map[string]string{
+		"account_id": account.AccountId,
+		"username":   account.Username,
+		"email":      account.Email,
+	} {
+		if value != "" {
+			conditions = conditions.Or(column+" = ?", value)
+		}
+	}
+
+	var accounts []models.Account
+	db := a.db.Unscoped().Where("id <> ?", account.ID).Where(conditions).Find(&accounts)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &accounts, nil
+}
+
+func (a *AccountRepository) SaveAccount(account *models.Account) error {
+	db := a.db.Save(account)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+	}
+
+	return db.Error
+}
+
+// SetAccountSuspended suspends the account from now, or reactivates it
+func (a *AccountRepository) SetAccountSuspended(account *models.Account, suspended bool) error {
+	var suspendedAt *time.Time
+	if suspended {
+		now := time.Now()
+		suspendedAt = &now
+	}
+
+	db := a.db.Model(account).Update("suspended_at", suspendedAt)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return db.Error
+	}
+
+	account.SuspendedAt = suspendedAt
+	return nil
+}
+
+// SetAccountAdmin lets the account log in to admind with its local credential, or stops it from doing so
+func (a *AccountRepository) SetAccountAdmin(account *models.Account, admin bool) error {
+	db := a.db.Model(account).Update("admin", admin)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return db.Error
+	}
+
+	account.Admin = admin
+	return nil
+}
+
+// DeleteAccount removes the account along with its memberships, collaborations, ssh keys and discharges. The
+// account row is kept, soft deleted, as its account id may be in assertions that were already signed.
+func (a *AccountRepository) DeleteAccount(account *models.Account) error {
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		db := tx.Unscoped().Where("account_id = ?", account.ID).Delete(&models.OrganizationMember{})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		db = tx.Model(&models.SnapCollaborator{}).Where("account_id = ? AND until IS NULL", account.ID).Update("until", now)
+		if db.Error != nil {
+			return db.Error
+		}
+
+		db = tx.Unscoped().Where("account_id = ?", account.ID).Delete(&models.SSHKey{})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		db = tx.Model(&models.DischargeToken{}).Where("account_id = ? AND revoked_at IS NULL", account.ID).Update("revoked_at", now)
+		if db.Error != nil {
+			return db.Error
+		}
+
+		return tx.Delete(account).Error
+	})
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+)
+
+type IAdminActionsRepository interface {
+	AddAction(action *models.AdminAction) error
+	GetActions(subject string, limit int) (*[]models.AdminAction, error)
+}
+
+type AdminActionsRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminActionsRepository(db *gorm.DB) *AdminActionsRepository {
+	return &AdminActionsRepository{db: db}
+}
+
+func (aar *AdminActionsRepository) AddAction(action *models.AdminAction) error {
+	db := aar.db.Create(action)
+	return db.Error
+}
+
+// GetActions returns the most recent actions first, only those of the admin with the subject if one is given
+func (aar *AdminActionsRepository) GetActions(subject string, limit int) (*[]models.AdminAction, error) {
+	var actions []models.AdminAction
+	db := aar.db.Where(&models.AdminAction{Subject: subject}).Order("id desc").Limit(limit).Find(&actions)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return &actions, nil
+}
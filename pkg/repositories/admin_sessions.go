@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IAdminSessionsRepository interface {
+	AddSession(session *models.AdminSession) error
+	GetSession(tokenHash string) (*models.AdminSession, error)
+}
+
+type AdminSessionsRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminSessionsRepository(db *gorm.DB) *AdminSessionsRepository {
+	return &AdminSessionsRepository{db: db}
+}
+
+func (asr *AdminSessionsRepository) AddSession(session *models.AdminSession) error {
+	db := asr.db.Create(session)
+	return db.Error
+}
+
+// GetSession returns the session with the token hash along with its account, nil is returned if there is none
+func (asr *AdminSessionsRepository) GetSession(tokenHash string) (*models.AdminSession, error) {
+	if tokenHash == "" {
+		return nil, nil
+	}
+
+	var session models.AdminSession
+	db := asr.db.Where(&models.AdminSession{TokenHash: tokenHash}).Preload(clause.Associations).Find(&session)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &session, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+)
+
+type IAssertionsRepository interface {
+	GetLatestAssertion(assertionType string, primaryKey string) (*models.Assertion, error)
+	AddAssertion(assertion *models.Assertion) error
+}
+
+type AssertionsRepository struct {
+	db *gorm.DB
+}
+
+func NewAssertionsRepository(db *gorm.DB) *AssertionsRepository {
+	return &AssertionsRepository{db: db}
+}
+
+// GetLatestAssertion returns the highest revision of the assertion, nil is returned if there is none
+func (ar *AssertionsRepository) GetLatestAssertion(assertionType string, primaryKey string) (*models.Assertion, error) {
+	var assertion models.Assertion
+	db := ar.db.Where(&models.Assertion{Type: assertionType, PrimaryKey: primaryKey}).Order("revision desc").Limit(1).Find(&assertion)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &assertion, nil
+	}
+
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+func (ar *AssertionsRepository) AddAssertion(assertion *models.Assertion) error {
+	if assertion.Encoded == "" {
+		return errors.New("cannot add an assertion without its encoded form")
+	}
+
+	db := ar.db.Create(assertion)
+	return db.Error
+}
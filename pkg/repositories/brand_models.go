@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+)
+
+type IBrandModelsRepository interface {
+	GetBrandModel(brandId string, model string) (*models.BrandModel, error)
+	GetBrandModels(brandId string) ([]models.BrandModel, error)
+	SaveBrandModel(definition *models.ModelDefinition) (*models.BrandModel, error)
+}
+
+type BrandModelsRepository struct {
+	db *gorm.DB
+}
+
+func NewBrandModelsRepository(db *gorm.DB) *BrandModelsRepository {
+	return &BrandModelsRepository{db: db}
+}
+
+func (bmr *BrandModelsRepository) GetBrandModel(brandId string, model string) (*models.BrandModel, error) {
+	var brandModel models.BrandModel
+	db := bmr.db.Where(&models.BrandModel{BrandID: brandId, ModelName: model}).Find(&brandModel)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &brandModel, nil
+	}
+
+	return nil, db.Error
+}
+
+// GetBrandModels returns the models registered, optionally limited to a brand
+func (bmr *BrandModelsRepository) GetBrandModels(brandId string) ([]models.BrandModel, error) {
+	var brandModels []models.BrandModel
+	db := bmr.db.Where(&models.BrandModel{BrandID: brandId}).Order("brand_id, model").Find(&brandModels)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return brandModels, nil
+}
+
+// SaveBrandModel creates the model for the brand or replaces its definition if it already exists
+func (bmr *BrandModelsRepository) SaveBrandModel(definition *models.ModelDefinition) (*models.BrandModel, error) {
+	if definition.BrandID == "" || definition.Model == "" {
+		return nil, errors.New("a model definition needs a brand-id and model")
+	}
+
+	definitionBytes, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	brandModel, err := bmr.GetBrandModel(definition.BrandID, definition.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	if brandModel == nil {
+		brandModel = &models.BrandModel{
+			BrandID:   definition.BrandID,
+			ModelName: definition.Model,
+		}
+	}
+
+	brandModel.Definition = string(definitionBytes)
+	db := bmr.db.Save(brandModel)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return brandModel, nil
+}
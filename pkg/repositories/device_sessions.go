@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+)
+
+type IDeviceSessionsRepository interface {
+	AddNonce(nonce *models.Nonce) error
+	UseNonce(value string) (bool, error)
+	AddSession(session *models.DeviceSession) error
+	GetSession(id uint) (*models.DeviceSession, error)
+}
+
+type DeviceSessionsRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceSessionsRepository(db *gorm.DB) *DeviceSessionsRepository {
+	return &DeviceSessionsRepository{db: db}
+}
+
+func (dsr *DeviceSessionsRepository) AddNonce(nonce *models.Nonce) error {
+	db := dsr.db.Create(nonce)
+	return db.Error
+}
+
+// UseNonce marks the nonce as used, false is returned if it is unknown, expired or was already used
+func (dsr *DeviceSessionsRepository) UseNonce(value string) (bool, error) {
+	now := time.Now()
+	db := dsr.db.Model(&models.Nonce{}).
+		Where("value = ? AND used_at IS NULL AND expires_at > ?", value, now).
+		Update("used_at", now)
+	if db.Error != nil {
+		return false, db.Error
+	}
+
+	return db.RowsAffected == 1, nil
+}
+
+func (dsr *DeviceSessionsRepository) AddSession(session *models.DeviceSession) error {
+	db := dsr.db.Create(session)
+	return db.Error
+}
+
+func (dsr *DeviceSessionsRepository) GetSession(id uint) (*models.DeviceSession, error) {
+	var session models.DeviceSession
+	db := dsr.db.Where("id = ?", id).Preload("Serial").Find(&session)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &session, nil
+	}
+
+	return nil, db.Error
+}
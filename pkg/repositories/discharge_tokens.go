@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IDischargeTokensRepository interface {
+	AddToken(token *models.DischargeToken) error
+	GetToken(tokenId string) (*models.DischargeToken, error)
+	GetTokens(accountId string) (*[]models.DischargeToken, error)
+	RevokeToken(tokenId string) (*models.DischargeToken, error)
+	RevokeAccountTokens(accountId string) (int64, error)
+}
+
+type DischargeTokensRepository struct {
+	db *gorm.DB
+}
+
+func NewDischargeTokensRepository(db *gorm.DB) *DischargeTokensRepository {
+	return &DischargeTokensRepository{db: db}
+}
+
+func (dtr *DischargeTokensRepository) AddToken(token *models.DischargeToken) error {
+	db := dtr.db.Create(token)
+	return db.Error
+}
+
+func (dtr *DischargeTokensRepository) GetToken(tokenId string) (*models.DischargeToken, error) {
+	var token models.DischargeToken
+	db := dtr.db.Where(&models.DischargeToken{TokenID: tokenId}).Preload(clause.Associations).Find(&token)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &token, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+// GetTokens returns the tokens issued to the account, or to every account if no account id is given, the most
+// recent first
+func (dtr *DischargeTokensRepository) GetTokens(accountId string) (*[]models.DischargeToken, error) {
+	var tokens []models.DischargeToken
+	db := dtr.db.Preload(clause.Associations)
+	if accountId != "" {
+		db = db.Joins("JOIN accounts ON accounts.id = discharge_tokens.account_id").Where("accounts.account_id = ?", accountId)
+	}
+
+	db = db.Order("discharge_tokens.id desc").Find(&tokens)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return &tokens, nil
+}
+
+// RevokeToken revokes the token from now, a token that was already revoked is left as is
+func (dtr *DischargeTokensRepository) RevokeToken(tokenId string) (*models.DischargeToken, error) {
+	token, err := dtr.GetToken(tokenId)
+	if err == nil && token != nil {
+		if token.RevokedAt != nil {
+			return token, nil
+		}
+
+		now := time.Now()
+		token.RevokedAt = &now
+		db := dtr.db.Model(token).Update("revoked_at", token.RevokedAt)
+		if db.Error != nil {
+			return nil, db.Error
+		}
+
+		return token, nil
+	}
+
+	return nil, err
+}
+
+// RevokeAccountTokens revokes every token of the account that hasn't been revoked, the number revoked is returned
+func (dtr *DischargeTokensRepository) RevokeAccountTokens(accountId string) (int64, error) {
+	db := dtr.db.Model(&models.DischargeToken{}).
+		Where("revoked_at IS NULL AND account_id IN (?)", dtr.db.Model(&models.Account{}).Select("id").Where(&models.Account{AccountId: accountId})).
+		Update("revoked_at", time.Now())
+	if db.Error != nil {
+		return 0, db.Error
+	}
+
+	return db.RowsAffected, nil
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ILocalCredentialsRepository interface {
+	GetCredential(accountId uint) (*models.LocalCredential, error)
+	SetPasswordHash(accountId uint, passwordHash string) error
+}
+
+type LocalCredentialsRepository struct {
+	db *gorm.DB
+}
+
+func NewLocalCredentialsRepository(db *gorm.DB) *LocalCredentialsRepository {
+	return &LocalCredentialsRepository{db: db}
+}
+
+func (lcr *LocalCredentialsRepository) GetCredential(accountId uint) (*models.LocalCredential, error) {
+	var credential models.LocalCredential
+	db := lcr.db.Where("account_id = ?", accountId).Find(&credential)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &credential, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+// SetPasswordHash adds the account's credential or replaces its password hash
+func (lcr *LocalCredentialsRepository) SetPasswordHash(accountId uint, passwordHash string) error {
+	credential := models.LocalCredential{AccountID: accountId, PasswordHash: passwordHash}
+	db := lcr.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "account_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"password_hash", "updated_at"}),
+	}).Create(&credential)
+	return db.Error
+}
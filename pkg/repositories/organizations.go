@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type IOrganizationsRepository interface {
+	CreateOrganization(name string, account *models.Account) (*models.Organization, error)
+	GetOrganization(name string) (*models.Organization, error)
+	GetOrganizations() (*[]models.Organization, error)
+	GetOrganizationByAccount(accountId uint) (*models.Organization, error)
+	SetMember(organizationId uint, accountId uint, role string) (*models.OrganizationMember, error)
+	RemoveMember(organizationId uint, accountId uint) (bool, error)
+}
+
+type OrganizationsRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationsRepository(db *gorm.DB) *OrganizationsRepository {
+	return &OrganizationsRepository{db: db}
+}
+
+// CreateOrganization creates the account the organization publishes as along with the organization
+func (o *OrganizationsRepository) CreateOrganization(name string, account *models.Account) (*models.Organization, error) {
+	organization := models.Organization{Name: name}
+	err := o.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(account).Error
+		if err != nil {
+			return err
+		}
+
+		organization.AccountID = account.ID
+		return tx.Create(&organization).Error
+	})
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+
+	organization.Account = *account
+	return &organization, nil
+}
+
+// GetOrganization returns the organization with its account and members, nil if there is none with the name
+func (o *OrganizationsRepository) GetOrganization(name string) (*models.Organization, error) {
+	var organization models.Organization
+	db := o.db.Where("name = ?", name).Preload("Account").Preload("Members.Account").Find(&organization)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &organization, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+// GetOrganizationByAccount returns the organization that publishes as the account, nil if the account isn't an
+// organization's
+func (o *OrganizationsRepository) GetOrganizationByAccount(accountId uint) (*models.Organization, error) {
+	var organization models.Organization
+	db := o.db.Where("account_id = ?", accountId).Find(&organization)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &organization, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+func (o *OrganizationsRepository) GetOrganizations() (*[]models.Organization, error) {
+	var organizations []models.Organization
+	db := o.db.Preload("Account").Preload("Members.Account").Order("name").Find(&organizations)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &organizations, nil
+}
+
+// SetMember adds the account to the organization with the role, or changes its role if it's already a member
+func (o *OrganizationsRepository) SetMember(organizationId uint, accountId uint, role string) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	db := o.db.Where("organization_id = ? AND account_id = ?", organizationId, accountId).Find(&member)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	member.OrganizationID = organizationId
+	member.AccountID = accountId
+	member.Role = role
+	db = o.db.Save(&member)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &member, nil
+}
+
+// RemoveMember returns false if the account wasn't a member of the organization
+func (o *OrganizationsRepository) RemoveMember(organizationId uint, accountId uint) (bool, error) {
+	db := o.db.Unscoped().Where("organization_id = ? AND account_id = ?", organizationId, accountId).Delete(&models.OrganizationMember{})
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return false, db.Error
+	}
+
+	return db.RowsAffected > 0, nil
+}
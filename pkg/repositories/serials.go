@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+)
+
+type ISerialsRepository interface {
+	GetAuthRequest(id uint) (*models.AuthRequest, error)
+	UseAuthRequest(authRequest *models.AuthRequest, deviceKeySHA3384 string) error
+	GetSerial(brandId string, model string, serial string) (*models.Serial, error)
+	GetSerialByDeviceKey(brandId string, model string, deviceKeySHA3384 string) (*models.Serial, error)
+	GetSerials(brandId string, model string) ([]models.Serial, error)
+	AddSerial(serial *models.Serial) error
+	RevokeSerial(brandId string, model string, serial string) (*models.Serial, error)
+}
+
+type SerialsRepository struct {
+	db *gorm.DB
+}
+
+func NewSerialsRepository(db *gorm.DB) *SerialsRepository {
+	return &SerialsRepository{db: db}
+}
+
+func (sr *SerialsRepository) GetAuthRequest(id uint) (*models.AuthRequest, error) {
+	var authRequest models.AuthRequest
+	db := sr.db.Where("id = ?", id).Find(&authRequest)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &authRequest, nil
+	}
+
+	return nil, db.Error
+}
+
+// UseAuthRequest marks the request id as used by the given device key
+func (sr *SerialsRepository) UseAuthRequest(authRequest *models.AuthRequest, deviceKeySHA3384 string) error {
+	if authRequest.UsedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	authRequest.UsedAt = &now
+	authRequest.DeviceKeySHA3384 = deviceKeySHA3384
+
+	db := sr.db.Save(authRequest)
+	return db.Error
+}
+
+func (sr *SerialsRepository) GetSerial(brandId string, model string, serial string) (*models.Serial, error) {
+	return sr.getSerialByWhereModel(&models.Serial{BrandID: brandId, ModelName: model, SerialUUID: serial})
+}
+
+func (sr *SerialsRepository) GetSerialByDeviceKey(brandId string, model string, deviceKeySHA3384 string) (*models.Serial, error) {
+	return sr.getSerialByWhereModel(&models.Serial{BrandID: brandId, ModelName: model, DeviceKeySHA3384: deviceKeySHA3384})
+}
+
+// GetSerials returns the serials issued, optionally limited to a brand and model
+func (sr *SerialsRepository) GetSerials(brandId string, model string) ([]models.Serial, error) {
+	var serials []models.Serial
+	db := sr.db.Where(&models.Serial{BrandID: brandId, ModelName: model}).Order("id").Find(&serials)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return serials, nil
+}
+
+func (sr *SerialsRepository) AddSerial(serial *models.Serial) error {
+	if serial.Encoded == "" {
+		return errors.New("cannot add a serial without its encoded assertion")
+	}
+
+	db := sr.db.Create(serial)
+	return db.Error
+}
+
+// RevokeSerial marks the serial revoked, it returns nil when there is no such serial
+func (sr *SerialsRepository) RevokeSerial(brandId string, model string, serial string) (*models.Serial, error) {
+	// an empty field would be left out of the where model and match other serials
+	if brandId == "" || model == "" || serial == "" {
+		return nil, nil
+	}
+
+	existing, err := sr.GetSerial(brandId, model, serial)
+	if err == nil && existing != nil {
+		if existing.RevokedAt == nil {
+			now := time.Now()
+			existing.RevokedAt = &now
+			db := sr.db.Save(existing)
+			if db.Error != nil {
+				return nil, db.Error
+			}
+		}
+
+		return existing, nil
+	}
+
+	return nil, err
+}
+
+func (sr *SerialsRepository) getSerialByWhereModel(whereModel *models.Serial) (*models.Serial, error) {
+	var serial models.Serial
+	db := sr.db.Where(whereModel).Find(&serial)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &serial, nil
+	}
+
+	return nil, db.Error
+}
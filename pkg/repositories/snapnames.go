@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrDisputeNotOpen is returned when resolving a dispute that was resolved in the meantime
+var ErrDisputeNotOpen = errors.New("the dispute is no longer open")
+
+type ISnapNamesRepository interface {
+	GetReservedName(name string) (*models.ReservedSnapName, error)
+	GetReservedNames() (*[]models.ReservedSnapName, error)
+	ReserveName(name string, accountId *uint, reason string) (*models.ReservedSnapName, error)
+	UnreserveName(name string) (bool, error)
+
+	AddDispute(name string, accountId uint, comment string) (*models.SnapNameDispute, error)
+	GetDispute(id uint) (*models.SnapNameDispute, error)
+	GetDisputes(status string) (*[]models.SnapNameDispute, error)
+	GetOpenDispute(name string, accountId uint) (*models.SnapNameDispute, error)
+	ResolveDispute(dispute *models.SnapNameDispute, status string) error
+}
+
+type SnapNamesRepository struct {
+	db *gorm.DB
+}
+
+func NewSnapNamesRepository(db *gorm.DB) *SnapNamesRepository {
+	return &SnapNamesRepository{db: db}
+}
+
+// GetReservedName returns the reservation of the name with the account it is reserved for, nil if it isn't reserved
+func (sn *SnapNamesRepository) GetReservedName(name string) (*models.ReservedSnapName, error) {
+	var reservedName models.ReservedSnapName
+	db := sn.db.Where("name = ?", name).Preload("Account").Find(&reservedName)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &reservedName, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+func (sn *SnapNamesRepository) GetReservedNames() (*[]models.ReservedSnapName, error) {
+	var reservedNames []models.ReservedSnapName
+	db := sn.db.Preload("Account").Order("name").Find(&reservedNames)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &reservedNames, nil
+}
+
+// ReserveName reserves the name for the account, or blocks it when the account is nil. Reserving a name that is
+// already reserved replaces its reservation.
+func (sn *SnapNamesRepository) ReserveName(name string, accountId *uint, reason string) (*models.ReservedSnapName, error) {
+	reservedName, err := sn.GetReservedName(name)
+	if err != nil {
+		return nil, err
+	} else if reservedName == nil {
+		reservedName = &models.ReservedSnapName{Name: name}
+	}
+
+	reservedName.AccountID = accountId
+	reservedName.Account = nil
+	reservedName.Reason = reason
+
+	db := sn.db.Save(reservedName)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return reservedName, nil
+}
+
+// UnreserveName lets the name be registered first-come-first-served again, false if it wasn't reserved
+func (sn *SnapNamesRepository) UnreserveName(name string) (bool, error) {
+	db := sn.db.Unscoped().Where("name = ?", name).Delete(&models.ReservedSnapName{})
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return false, db.Error
+	}
+
+	return db.RowsAffected > 0, nil
+}
+
+func (sn *SnapNamesRepository) AddDispute(name string, accountId uint, comment string) (*models.SnapNameDispute, error) {
+	dispute := models.SnapNameDispute{
+		Name:      name,
+		AccountID: accountId,
+		Comment:   comment,
+		Status:    models.SnapNameDisputeOpen,
+	}
+
+	db := sn.db.Create(&dispute)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &dispute, nil
+}
+
+func (sn *SnapNamesRepository) GetDispute(id uint) (*models.SnapNameDispute, error) {
+	var dispute models.SnapNameDispute
+	db := sn.db.Where("id = ?", id).Preload("Account").Find(&dispute)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &dispute, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+// GetDisputes returns the disputes with the status, all of them if it is empty
+func (sn *SnapNamesRepository) GetDisputes(status string) (*[]models.SnapNameDispute, error) {
+	query := sn.db.Preload("Account").Order("created_at")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var disputes []models.SnapNameDispute
+	db := query.Find(&disputes)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &disputes, nil
+}
+
+// GetOpenDispute returns the dispute the account has open for the name, nil if there is none
+func (sn *SnapNamesRepository) GetOpenDispute(name string, accountId uint) (*models.SnapNameDispute, error) {
+	var dispute models.SnapNameDispute
+	db := sn.db.Where("name = ? AND account_id = ? AND status = ?", name, accountId, models.SnapNameDisputeOpen).Find(&dispute)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &dispute, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+// ResolveDispute closes the open dispute. Accepting it gives the name to the account that disputed it, the snap
+// registered with the name is transferred to the account or the name is reserved for it, and rejects the other open
+// disputes of the name, all in a single transaction.
+func (sn *SnapNamesRepository) ResolveDispute(dispute *models.SnapNameDispute, status string) error {
+	now := time.Now()
+	err := sn.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.SnapNameDispute{}).Where("id = ? AND status = ?", dispute.ID, models.SnapNameDisputeOpen).
+			Updates(map[string]interface{}{"status": status, "resolved_at": now})
+		if db.Error != nil {
+			return db.Error
+		} else if db.RowsAffected == 0 {
+			return ErrDisputeNotOpen
+		}
+
+		if status != models.SnapNameDisputeAccepted {
+			return nil
+		}
+
+		err := giveSnapName(tx, dispute)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.SnapNameDispute{}).Where("name = ? AND status = ?", dispute.Name, models.SnapNameDisputeOpen).
+			Updates(map[string]interface{}{"status": models.SnapNameDisputeRejected, "resolved_at": now}).Error
+	})
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	dispute.Status = status
+	dispute.ResolvedAt = &now
+	return nil
+}
+
+// giveSnapName makes the account that disputed the name the publisher of the snap registered with it, or lets the
+// account register the name
+func giveSnapName(tx *gorm.DB, dispute *models.SnapNameDispute) error {
+	snaps := NewSnapsRepository(tx)
+	snapEntry, err := snaps.GetSnap(dispute.Name, false)
+	if err != nil {
+		return err
+	} else if snapEntry == nil {
+		_, err = NewSnapNamesRepository(tx).ReserveName(dispute.Name, &dispute.AccountID,
+			"given to "+dispute.Account.AccountId+" by a name dispute")
+		return err
+	} else if snapEntry.AccountID != dispute.AccountID {
+		return snaps.TransferSnap(snapEntry.ID, dispute.AccountID)
+	}
+
+	return nil
+}
@@ -2,8 +2,11 @@ package repositories
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgconn"
 	"gorm.io/gorm/clause"
 
 	"github.com/freetocompute/kebe/pkg/snap"
@@ -16,18 +19,37 @@ import (
 	"gorm.io/gorm"
 )
 
+// uniqueViolation is the Postgres error code for a unique index violation
+const uniqueViolation = "23505"
+
+var (
+	// ErrSnapExists is returned when registering a name that a snap has already
+	ErrSnapExists = errors.New("snap already exists")
+	// ErrSnapHasRevisions is returned when unregistering a snap that revisions were uploaded for
+	ErrSnapHasRevisions = errors.New("the snap has revisions")
+	// ErrChannelNotFound and ErrRevisionNotFound are returned when releasing to a channel the snap doesn't have or
+	// a revision that isn't of the snap
+	ErrChannelNotFound  = errors.New("channel not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
 type ISnapsRepository interface {
 	GetSnap(name string, preloadAssociations bool) (*models.SnapEntry, error)
 	GetSnapById(id uint, preloadAssociations bool) (*models.SnapEntry, error)
 	GetSnapByStoreId(snapStoreId string, preloadAssociations bool) (*models.SnapEntry, error)
 	AddSnap(name string, accountId uint) (*models.SnapEntry, error)
+	TransferSnap(snapEntryId uint, accountId uint) error
+	UnregisterSnap(snapEntryId uint) error
 
 	GetRevisionBySHA(SHA3_384 string, encoded bool) (*models.SnapRevision, error)
 	GetUpload(upDownId string) (*models.SnapUpload, error)
 	UpdateRevision(revision *models.SnapRevision, revisionBytes *[]byte) (*models.SnapRevision, error)
 
+	SetUploadRevision(uploadId uint, revisionId uint) error
+
 	ReleaseSnap(channels []string, snapEntryId uint, revisionId uint) error
-	AddUpload(snapName string, upDownId string, size uint, channels []string) (*models.SnapUpload, error)
+	ReleaseRevisions(snapEntryId uint, channelRevisions map[string]uint) error
+	AddUpload(snapName string, upDownId string, size uint, channels []string, accountId uint) (*models.SnapUpload, error)
 
 	SetChannelRevision(trackName string, riskName string, revisionId uint, snapId uint) (*models.SnapTrack, error)
 
@@ -39,6 +61,17 @@ type ISnapsRepository interface {
 	GetSections() (*[]string, error)
 
 	GetSnaps() (*[]models.SnapEntry, error)
+
+	GetMetadata(snapEntryId uint) (*models.SnapMetadata, error)
+	SaveMetadata(metadata *models.SnapMetadata) error
+
+	GetMedia(snapEntryId uint) (*[]models.SnapMedia, error)
+	GetMediaByFilename(filename string) (*models.SnapMedia, error)
+	ReplaceMedia(snapEntryId uint, mediaTypes []string, media []models.SnapMedia) error
+
+	GetCollaborators(snapEntryId uint, includeRemoved bool) (*[]models.SnapCollaborator, error)
+	GetCollaborator(snapEntryId uint, accountId uint) (*models.SnapCollaborator, error)
+	SaveCollaborator(collaborator *models.SnapCollaborator) error
 }
 
 type SnapsRepository struct {
@@ -88,11 +121,23 @@ func (sp *SnapsRepository) GetRevisionByChannel(channel string, snapName string)
 }
 
 func (sp *SnapsRepository) GetSections() (*[]string, error) {
-	// TODO: add these to the database for real
 	sections := []string{
 		"general",
 	}
 
+	var categories []string
+	db := sp.db.Model(&models.SnapMetadata{}).Where("category IS NOT NULL AND category <> ''").Distinct("category").Order("category").Pluck("category", &categories)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	for _, category := range categories {
+		if category != "general" {
+			sections = append(sections, category)
+		}
+	}
+
 	return &sections, nil
 }
 
@@ -166,7 +211,7 @@ func (sp *SnapsRepository) SetChannelRevision(trackName string, riskName string,
 	return nil, errors.New("unknown error encountered")
 }
 
-func (sp *SnapsRepository) AddUpload(snapName string, upDownId string, fileSize uint, channels []string) (*models.SnapUpload, error) {
+func (sp *SnapsRepository) AddUpload(snapName string, upDownId string, fileSize uint, channels []string, accountId uint) (*models.SnapUpload, error) {
 	var snap models.SnapEntry
 	db := sp.db.Where(&models.SnapEntry{Name: snapName}).Find(&snap)
 	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
@@ -175,6 +220,7 @@ func (sp *SnapsRepository) AddUpload(snapName string, upDownId string, fileSize
 			UpDownID:    upDownId,
 			Filesize:    fileSize,
 			SnapEntryID: snap.ID,
+			AccountID:   accountId,
 		}
 
 		logrus.Infof("Uploading: %+v", snapUpload)
@@ -241,10 +287,12 @@ func (sp *SnapsRepository) GetUpload(upDownId string) (*models.SnapUpload, error
 func (sp *SnapsRepository) UpdateRevision(revision *models.SnapRevision, revisionBytes *[]byte) (*models.SnapRevision, error) {
 	db := sp.db.Save(revision)
 	if db.Error == nil {
-		err := sp.updateMeta(revisionBytes)
+		err := sp.updateMeta(revision, revisionBytes)
 		if err == nil {
 			return revision, nil
 		}
+
+		return nil, err
 	}
 	return nil, db.Error
 }
@@ -253,7 +301,7 @@ func (sp *SnapsRepository) GetSnaps() (*[]models.SnapEntry, error) {
 	var snaps []models.SnapEntry
 
 	// TODO: would need to implement private and filter here
-	db := sp.db.Find(&snaps)
+	db := sp.db.Preload("Account").Preload("Metadata").Preload("Revisions").Find(&snaps)
 	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
 		return &snaps, nil
 	}
@@ -315,18 +363,26 @@ func (sp *SnapsRepository) GetSnap(name string, preloadAssociations bool) (*mode
 	return nil, nil
 }
 
+// AddSnap registers the name for the account with the default track and risks. The name is unique among snaps that
+// aren't deleted, ErrSnapExists is returned if it is registered already, also when registered concurrently.
 func (sp *SnapsRepository) AddSnap(name string, accountId uint) (*models.SnapEntry, error) {
-	existingSnap, err := sp.GetSnap(name, false)
-	if err == nil && existingSnap == nil {
-		// when adding a snap, not finding one _is_ (!ok) what you want
-		var newSnapEntry models.SnapEntry
-		snapId := uuid.New()
-		newSnapEntry.SnapStoreID = snapId.String()
-		newSnapEntry.Name = name
-		newSnapEntry.AccountID = accountId
-		newSnapEntry.Type = "app"
-
-		sp.db.Save(&newSnapEntry)
+	newSnapEntry := models.SnapEntry{
+		Name:        name,
+		SnapStoreID: uuid.New().String(),
+		AccountID:   accountId,
+		Type:        "app",
+	}
+
+	err := sp.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&newSnapEntry).Error
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+				return fmt.Errorf("%w: %s", ErrSnapExists, name)
+			}
+
+			return err
+		}
 
 		// For now when we register a snap we are going to create the default tracks/risks
 		track := models.SnapTrack{
@@ -334,18 +390,74 @@ func (sp *SnapsRepository) AddSnap(name string, accountId uint) (*models.SnapEnt
 			SnapEntryID: newSnapEntry.ID,
 		}
 
-		sp.db.Save(&track)
+		err = tx.Create(&track).Error
+		if err != nil {
+			return err
+		}
+
+		return NewSnapsRepository(tx).addRisks(newSnapEntry.ID, track.ID)
+	})
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+
+	return &newSnapEntry, nil
+}
+
+// TransferSnap makes the account the publisher of the snap, if it was a collaborator on the snap it stops being one
+func (sp *SnapsRepository) TransferSnap(snapEntryId uint, accountId uint) error {
+	err := sp.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.SnapEntry{}).Where("id = ?", snapEntryId).Update("account_id", accountId)
+		if db.Error != nil {
+			return db.Error
+		}
+
+		return tx.Model(&models.SnapCollaborator{}).Where("snap_entry_id = ? AND account_id = ? AND until IS NULL", snapEntryId, accountId).
+			Update("until", time.Now()).Error
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+
+	return err
+}
+
+// UnregisterSnap removes a snap along with its channels, uploads, media, metadata and collaborators so the name can
+// be registered again. Snaps with revisions can't be unregistered, their snap-id is in assertions already signed.
+func (sp *SnapsRepository) UnregisterSnap(snapEntryId uint) error {
+	err := sp.db.Transaction(func(tx *gorm.DB) error {
+		// the empty revision the risks are created with doesn't count, nothing was uploaded
+		var revisions int64
+		db := tx.Model(&models.SnapRevision{}).Where("snap_entry_id = ? AND snap_filename <> ''", snapEntryId).Count(&revisions)
+		if db.Error != nil {
+			return db.Error
+		} else if revisions > 0 {
+			return ErrSnapHasRevisions
+		}
 
-		sp.addRisks(newSnapEntry.ID, track.ID)
+		for _, association := range []interface{}{&models.SnapBranch{}, &models.SnapRisk{}, &models.SnapTrack{}, &models.SnapRevision{},
+			&models.SnapUpload{}, &models.SnapMedia{}, &models.SnapMetadata{}, &models.SnapCollaborator{}} {
+			db = tx.Where("snap_entry_id = ?", snapEntryId).Delete(association)
+			if db.Error != nil {
+				return db.Error
+			}
+		}
 
-		return &newSnapEntry, nil
+		return tx.Delete(&models.SnapEntry{}, snapEntryId).Error
+	})
+	if err != nil {
+		logrus.Error(err)
 	}
 
-	return nil, errors.New("there was an error")
+	return err
 }
 
 func (sp *SnapsRepository) AddDefaultRisks(snapEntryId uint, trackId uint) {
-	sp.addRisks(snapEntryId, trackId)
+	err := sp.addRisks(snapEntryId, trackId)
+	if err != nil {
+		logrus.Error(err)
+	}
 }
 
 func (sp *SnapsRepository) ReleaseSnap(channels []string, snapEntryId uint, revisionId uint) error {
@@ -388,7 +500,80 @@ func (sp *SnapsRepository) ReleaseSnap(channels []string, snapEntryId uint, revi
 	return nil
 }
 
-func (sp *SnapsRepository) addRisks(snapEntryId uint, trackId uint) {
+// ReleaseRevisions sets the revision of every channel of the snap in a single transaction, nothing is released if
+// a channel doesn't exist or a revision isn't of the snap
+func (sp *SnapsRepository) ReleaseRevisions(snapEntryId uint, channelRevisions map[string]uint) error {
+	err := sp.db.Transaction(func(tx *gorm.DB) error {
+		for channel, revisionId := range channelRevisions {
+			trackName, riskName, err := splitChannel(channel)
+			if err != nil {
+				return err
+			}
+
+			var track models.SnapTrack
+			db := tx.Where(&models.SnapTrack{SnapEntryID: snapEntryId, Name: trackName}).Find(&track)
+			if db.Error != nil {
+				return db.Error
+			} else if db.RowsAffected == 0 {
+				return fmt.Errorf("%w: %s", ErrChannelNotFound, channel)
+			}
+
+			var risk models.SnapRisk
+			db = tx.Where(&models.SnapRisk{SnapEntryID: snapEntryId, Name: riskName, SnapTrackID: track.ID}).Find(&risk)
+			if db.Error != nil {
+				return db.Error
+			} else if db.RowsAffected == 0 {
+				return fmt.Errorf("%w: %s", ErrChannelNotFound, channel)
+			}
+
+			var revision models.SnapRevision
+			db = tx.Where("id = ? AND snap_entry_id = ?", revisionId, snapEntryId).Find(&revision)
+			if db.Error != nil {
+				return db.Error
+			} else if db.RowsAffected == 0 {
+				return fmt.Errorf("%w: %d", ErrRevisionNotFound, revisionId)
+			}
+
+			risk.RevisionID = revision.ID
+			db = tx.Save(&risk)
+			if db.Error != nil {
+				return db.Error
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+
+	return err
+}
+
+// splitChannel returns the track and risk of a channel, the track is latest when the channel is only a risk
+func splitChannel(channel string) (string, string, error) {
+	parts := strings.Split(channel, "/")
+	switch len(parts) {
+	case 1:
+		return "latest", parts[0], nil
+	case 2:
+		return parts[0], parts[1], nil
+	}
+
+	return "", "", errors.New("branches not supported yet")
+}
+
+// SetUploadRevision records the revision an upload was processed into
+func (sp *SnapsRepository) SetUploadRevision(uploadId uint, revisionId uint) error {
+	db := sp.db.Model(&models.SnapUpload{}).Where("id = ?", uploadId).Update("revision_id", revisionId)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+	}
+
+	return db.Error
+}
+
+func (sp *SnapsRepository) addRisks(snapEntryId uint, trackId uint) error {
 	// TODO: fix me
 	risks := []string{"stable", "candidate", "beta", "edge"}
 
@@ -400,7 +585,10 @@ func (sp *SnapsRepository) addRisks(snapEntryId uint, trackId uint) {
 		Size:         0,
 	}
 
-	sp.db.Save(&snapRevision)
+	db := sp.db.Save(&snapRevision)
+	if db.Error != nil {
+		return db.Error
+	}
 
 	for _, risk := range risks {
 		var snapRisk models.SnapRisk
@@ -410,11 +598,16 @@ func (sp *SnapsRepository) addRisks(snapEntryId uint, trackId uint) {
 
 		snapRisk.RevisionID = snapRevision.ID
 
-		sp.db.Save(&snapRisk)
+		db = sp.db.Save(&snapRisk)
+		if db.Error != nil {
+			return db.Error
+		}
 	}
+
+	return nil
 }
 
-func (sp *SnapsRepository) updateMeta(metaBytes *[]byte) error {
+func (sp *SnapsRepository) updateMeta(revision *models.SnapRevision, metaBytes *[]byte) error {
 	snapMeta, err2 := snap.GetSnapMetaFromBytes(*metaBytes, "/tmp")
 	if err2 == nil {
 		logrus.Tracef("snapMeta: %+v", snapMeta)
@@ -431,7 +624,23 @@ func (sp *SnapsRepository) updateMeta(metaBytes *[]byte) error {
 			snapEntry.Confinement = snapMeta.Confinement
 			snapEntry.Base = snapMeta.Base
 
-			sp.db.Save(&snapEntry)
+			db = sp.db.Save(&snapEntry)
+			if db.Error != nil {
+				logrus.Error(db.Error)
+				return db.Error
+			}
+
+			revision.Version = snapMeta.Version
+			revision.Title = snapMeta.Title
+			revision.Summary = snapMeta.Summary
+			revision.Description = snapMeta.Description
+			revision.License = snapMeta.License
+
+			db = sp.db.Save(revision)
+			if db.Error != nil {
+				logrus.Error(db.Error)
+				return db.Error
+			}
 		} else {
 			logrus.Errorf("No rows found for: %s", snapMeta.Name)
 		}
@@ -461,3 +670,114 @@ func (sp *SnapsRepository) getSnap(whereModel *models.SnapEntry, preloadAssociat
 
 	return nil, nil
 }
+
+func (sp *SnapsRepository) GetMedia(snapEntryId uint) (*[]models.SnapMedia, error) {
+	var media []models.SnapMedia
+	db := sp.db.Where(&models.SnapMedia{SnapEntryID: snapEntryId}).Order("type, position").Find(&media)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &media, nil
+}
+
+func (sp *SnapsRepository) GetMediaByFilename(filename string) (*models.SnapMedia, error) {
+	var media models.SnapMedia
+	db := sp.db.Where(&models.SnapMedia{Filename: filename}).First(&media)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &media, nil
+	}
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return nil, db.Error
+	}
+
+	logrus.Warningf("Could not find media %s", filename)
+	return nil, nil
+}
+
+// ReplaceMedia removes all media of the given types for the snap and replaces them with the media provided
+func (sp *SnapsRepository) ReplaceMedia(snapEntryId uint, mediaTypes []string, media []models.SnapMedia) error {
+	return sp.db.Transaction(func(tx *gorm.DB) error {
+		if len(mediaTypes) > 0 {
+			db := tx.Where("snap_entry_id = ? AND type IN ?", snapEntryId, mediaTypes).Delete(&models.SnapMedia{})
+			if db.Error != nil {
+				return db.Error
+			}
+		}
+
+		for i := range media {
+			media[i].ID = 0
+			media[i].SnapEntryID = snapEntryId
+			db := tx.Create(&media[i])
+			if db.Error != nil {
+				return db.Error
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetMetadata returns the edited metadata for the snap, or a new unsaved SnapMetadata if it has never been edited
+func (sp *SnapsRepository) GetMetadata(snapEntryId uint) (*models.SnapMetadata, error) {
+	var metadata models.SnapMetadata
+	db := sp.db.Where(&models.SnapMetadata{SnapEntryID: snapEntryId}).Find(&metadata)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &metadata, nil
+	}
+
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return &models.SnapMetadata{SnapEntryID: snapEntryId}, nil
+}
+
+func (sp *SnapsRepository) SaveMetadata(metadata *models.SnapMetadata) error {
+	db := sp.db.Save(metadata)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+	}
+
+	return db.Error
+}
+
+// GetCollaborators returns the collaborators of the snap in the order they were added with their accounts, removed
+// collaborators are only included when includeRemoved is set
+func (sp *SnapsRepository) GetCollaborators(snapEntryId uint, includeRemoved bool) (*[]models.SnapCollaborator, error) {
+	var collaborators []models.SnapCollaborator
+	db := sp.db.Where("snap_entry_id = ?", snapEntryId)
+	if !includeRemoved {
+		db = db.Where("until IS NULL")
+	}
+
+	db = db.Preload("Account").Order("since, id").Find(&collaborators)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+		return nil, db.Error
+	}
+
+	return &collaborators, nil
+}
+
+// GetCollaborator returns the account's collaboration on the snap if it hasn't been removed, nil if there is none
+func (sp *SnapsRepository) GetCollaborator(snapEntryId uint, accountId uint) (*models.SnapCollaborator, error) {
+	var collaborator models.SnapCollaborator
+	db := sp.db.Where("snap_entry_id = ? AND account_id = ? AND until IS NULL", snapEntryId, accountId).Find(&collaborator)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &collaborator, nil
+	}
+
+	return nil, db.Error
+}
+
+func (sp *SnapsRepository) SaveCollaborator(collaborator *models.SnapCollaborator) error {
+	db := sp.db.Save(collaborator)
+	if db.Error != nil {
+		logrus.Error(db.Error)
+	}
+
+	return db.Error
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+)
+
+type ITOTPDevicesRepository interface {
+	GetDevice(accountId uint) (*models.TOTPDevice, error)
+	EnrolDevice(accountId uint, secret string) (*models.TOTPDevice, error)
+	ConfirmDevice(device *models.TOTPDevice) error
+	UseStep(device *models.TOTPDevice, step int64) (bool, error)
+	DeleteDevice(accountId uint) error
+}
+
+type TOTPDevicesRepository struct {
+	db *gorm.DB
+}
+
+func NewTOTPDevicesRepository(db *gorm.DB) *TOTPDevicesRepository {
+	return &TOTPDevicesRepository{db: db}
+}
+
+func (tdr *TOTPDevicesRepository) GetDevice(accountId uint) (*models.TOTPDevice, error) {
+	var device models.TOTPDevice
+	db := tdr.db.Where("account_id = ?", accountId).Find(&device)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &device, nil
+	} else if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return nil, nil
+}
+
+// EnrolDevice replaces the account's device with an unconfirmed one using the secret
+func (tdr *TOTPDevicesRepository) EnrolDevice(accountId uint, secret string) (*models.TOTPDevice, error) {
+	device := models.TOTPDevice{AccountID: accountId, Secret: secret}
+	err := tdr.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Unscoped().Where("account_id = ?", accountId).Delete(&models.TOTPDevice{})
+		if db.Error != nil {
+			return db.Error
+		}
+
+		return tx.Create(&device).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+func (tdr *TOTPDevicesRepository) ConfirmDevice(device *models.TOTPDevice) error {
+	now := time.Now()
+	device.ConfirmedAt = &now
+	db := tdr.db.Model(device).Update("confirmed_at", device.ConfirmedAt)
+	return db.Error
+}
+
+// UseStep records a code of the time step was accepted, false is returned if a code of that step or a later one
+// was already used
+func (tdr *TOTPDevicesRepository) UseStep(device *models.TOTPDevice, step int64) (bool, error) {
+	db := tdr.db.Model(&models.TOTPDevice{}).Where("id = ? AND last_used_step < ?", device.ID, step).Update("last_used_step", step)
+	if db.Error != nil {
+		return false, db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		return false, nil
+	}
+
+	device.LastUsedStep = step
+	return true, nil
+}
+
+// DeleteDevice removes the account's device, it can enrol a new one afterwards
+func (tdr *TOTPDevicesRepository) DeleteDevice(accountId uint) error {
+	db := tdr.db.Unscoped().Where("account_id = ?", accountId).Delete(&models.TOTPDevice{})
+	return db.Error
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/models"
+	"gorm.io/gorm"
+)
+
+type IValidationSetsRepository interface {
+	AddValidationSet(definition *models.ValidationSetDefinition) (*models.ValidationSet, error)
+	GetValidationSet(accountId string, name string, sequence int) (*models.ValidationSet, error)
+	GetValidationSets(accountId string, name string) ([]models.ValidationSet, error)
+}
+
+type ValidationSetsRepository struct {
+	db *gorm.DB
+}
+
+func NewValidationSetsRepository(db *gorm.DB) *ValidationSetsRepository {
+	return &ValidationSetsRepository{db: db}
+}
+
+// AddValidationSet stores the definition as the next sequence of the named validation set
+func (vsr *ValidationSetsRepository) AddValidationSet(definition *models.ValidationSetDefinition) (*models.ValidationSet, error) {
+	if definition.AccountID == "" || definition.Name == "" {
+		return nil, errors.New("a validation set needs an account-id and name")
+	}
+
+	definitionBytes, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	validationSet := models.ValidationSet{
+		AccountID:  definition.AccountID,
+		Name:       definition.Name,
+		Definition: string(definitionBytes),
+	}
+
+	err = vsr.db.Transaction(func(tx *gorm.DB) error {
+		var latest models.ValidationSet
+		db := tx.Where(&models.ValidationSet{AccountID: definition.AccountID, Name: definition.Name}).Order("sequence desc").Limit(1).Find(&latest)
+		if db.Error != nil {
+			return db.Error
+		}
+
+		validationSet.Sequence = latest.Sequence + 1
+		return tx.Create(&validationSet).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &validationSet, nil
+}
+
+func (vsr *ValidationSetsRepository) GetValidationSet(accountId string, name string, sequence int) (*models.ValidationSet, error) {
+	var validationSet models.ValidationSet
+	db := vsr.db.Where(&models.ValidationSet{AccountID: accountId, Name: name, Sequence: sequence}).Find(&validationSet)
+	if _, ok := database.CheckDBForErrorOrNoRows(db); ok {
+		return &validationSet, nil
+	}
+
+	return nil, db.Error
+}
+
+// GetValidationSets returns the sequences of validation sets, newest first, optionally limited to an account and name
+func (vsr *ValidationSetsRepository) GetValidationSets(accountId string, name string) ([]models.ValidationSet, error) {
+	var validationSets []models.ValidationSet
+	db := vsr.db.Where(&models.ValidationSet{AccountID: accountId, Name: name}).Order("account_id, name, sequence desc").Find(&validationSets)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return validationSets, nil
+}
@@ -0,0 +1,222 @@
+package serialvault
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/assertions"
+	"github.com/freetocompute/kebe/pkg/crypto"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/asserts"
+)
+
+// RequestIDExpiry is how long a request id handed out by the store can be used in a serial request
+const RequestIDExpiry = time.Hour
+
+var (
+	ErrInvalidRequest = errors.New("invalid serial request")
+	ErrUnknownModel   = errors.New("unknown model")
+	ErrSerialRevoked  = errors.New("serial has been revoked")
+	ErrNoSigningKey   = errors.New("no key available to sign serials for model")
+)
+
+// Vault issues serial assertions to devices of registered models and keeps track of every serial issued
+type Vault struct {
+	serials    repositories.ISerialsRepository
+	accounts   repositories.IAccountRepository
+	assertions repositories.IAssertionsRepository
+	signingDB  *crypto.SigningDB
+}
+
+func NewVault(serials repositories.ISerialsRepository, accounts repositories.IAccountRepository, assertions repositories.IAssertionsRepository, signingDB *crypto.SigningDB) *Vault {
+	return &Vault{
+		serials:    serials,
+		accounts:   accounts,
+		assertions: assertions,
+		signingDB:  signingDB,
+	}
+}
+
+// IssueSerial checks the serial request and returns a serial assertion for the device. A device asking again
+// with the same key gets the serial it was already issued.
+func (v *Vault) IssueSerial(serialRequest *asserts.SerialRequest) (*asserts.Serial, error) {
+	deviceKey := serialRequest.DeviceKey()
+	if err := asserts.SignatureCheck(serialRequest, deviceKey); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err.Error())
+	}
+
+	model, err := v.findModel(serialRequest.BrandID(), serialRequest.Model())
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.checkRequestID(serialRequest.RequestID(), deviceKey.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := v.serials.GetSerialByDeviceKey(model.BrandID(), model.Model(), deviceKey.ID())
+	if err == nil && existing != nil {
+		if existing.IsRevoked() {
+			return nil, fmt.Errorf("%w: %s", ErrSerialRevoked, existing.SerialUUID)
+		}
+
+		logrus.Infof("Device key %s already has serial %s, re-issuing", deviceKey.ID(), existing.SerialUUID)
+		return decodeSerial(existing.Encoded)
+	} else if err != nil {
+		return nil, err
+	}
+
+	serial := serialRequest.Serial()
+	if serial == "" {
+		serial = uuid.New().String()
+	} else {
+		taken, err2 := v.serials.GetSerial(model.BrandID(), model.Model(), serial)
+		if err2 != nil {
+			return nil, err2
+		}
+
+		if taken != nil {
+			return nil, fmt.Errorf("%w: serial %s is already in use", ErrInvalidRequest, serial)
+		}
+	}
+
+	authorityId, keyId, err := v.findSigningKey(model)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedDeviceKey, err := asserts.EncodePublicKey(deviceKey)
+	if err != nil {
+		return nil, err
+	}
+
+	serialHeaders := map[string]interface{}{
+		"authority-id":        authorityId,
+		"brand-id":            model.BrandID(),
+		"model":               model.Model(),
+		"serial":              serial,
+		"device-key":          string(encodedDeviceKey),
+		"device-key-sha3-384": deviceKey.ID(),
+		"timestamp":           time.Now().UTC().Format(time.RFC3339),
+	}
+
+	assertion, err := v.signingDB.Sign(asserts.SerialType, serialHeaders, nil, keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	serialAssertion, ok := assertion.(*asserts.Serial)
+	if !ok {
+		return nil, errors.New("unable to assert type on serial assertion")
+	}
+
+	err = v.serials.AddSerial(&models.Serial{
+		BrandID:          model.BrandID(),
+		ModelName:        model.Model(),
+		SerialUUID:       serial,
+		DeviceKeySHA3384: deviceKey.ID(),
+		EncodedDeviceKey: string(encodedDeviceKey),
+		Encoded:          string(asserts.Encode(serialAssertion)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return serialAssertion, nil
+}
+
+// findModel returns the latest signed model assertion registered for the brand
+func (v *Vault) findModel(brandId string, model string) (*asserts.Model, error) {
+	stored, err := v.assertions.GetLatestAssertion(asserts.ModelType.Name, assertions.JoinKey([]string{"16", brandId, model}))
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil {
+		return nil, fmt.Errorf("%w: %s/%s", ErrUnknownModel, brandId, model)
+	}
+
+	assertion, err := asserts.Decode([]byte(stored.Encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	modelAssertion, ok := assertion.(*asserts.Model)
+	if !ok {
+		return nil, errors.New("unable to assert type on model assertion")
+	}
+
+	return modelAssertion, nil
+}
+
+// checkRequestID makes sure the request id was handed out by the store, has not expired and has not been used
+// by a different device
+func (v *Vault) checkRequestID(requestId string, deviceKeySHA3384 string) error {
+	id, err := strconv.ParseUint(requestId, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed request-id", ErrInvalidRequest)
+	}
+
+	authRequest, err := v.serials.GetAuthRequest(uint(id))
+	if err != nil {
+		return err
+	}
+
+	if authRequest == nil {
+		return fmt.Errorf("%w: unknown request-id", ErrInvalidRequest)
+	}
+
+	if authRequest.UsedAt != nil {
+		if authRequest.DeviceKeySHA3384 != deviceKeySHA3384 {
+			return fmt.Errorf("%w: request-id has already been used", ErrInvalidRequest)
+		}
+
+		return nil
+	}
+
+	if time.Since(authRequest.CreatedAt) > RequestIDExpiry {
+		return fmt.Errorf("%w: request-id has expired", ErrInvalidRequest)
+	}
+
+	return v.serials.UseAuthRequest(authRequest, deviceKeySHA3384)
+}
+
+// findSigningKey returns the first authority allowed to sign serials for the model that the signing backend
+// holds a key for, the brand is preferred over any other serial-authority and its most recent key is used
+func (v *Vault) findSigningKey(model *asserts.Model) (string, string, error) {
+	authorities := append([]string{model.BrandID()}, model.SerialAuthority()...)
+	for _, authorityId := range authorities {
+		keys, err := v.accounts.GetSigningKeys(authorityId, time.Now().UTC())
+		if err != nil {
+			return "", "", err
+		}
+
+		for _, key := range keys {
+			if _, err2 := v.signingDB.PublicKey(key.SHA3384); err2 == nil {
+				return authorityId, key.SHA3384, nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s/%s", ErrNoSigningKey, model.BrandID(), model.Model())
+}
+
+func decodeSerial(encoded string) (*asserts.Serial, error) {
+	assertion, err := asserts.Decode([]byte(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	serialAssertion, ok := assertion.(*asserts.Serial)
+	if !ok {
+		return nil, errors.New("unable to assert type on serial assertion")
+	}
+
+	return serialAssertion, nil
+}
@@ -2,25 +2,30 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"strings"
 	"sync"
 
+	"github.com/freetocompute/kebe/pkg/assertions"
 	"github.com/freetocompute/kebe/pkg/crypto"
 	"github.com/snapcore/snapd/asserts"
-	"github.com/snapcore/snapd/asserts/assertstest"
 
 	"github.com/freetocompute/kebe/config"
 	"github.com/freetocompute/kebe/config/configkey"
 	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/devicesession"
 	"github.com/freetocompute/kebe/pkg/middleware"
+	"github.com/freetocompute/kebe/pkg/models"
 	"github.com/freetocompute/kebe/pkg/objectstore"
 	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/freetocompute/kebe/pkg/serialvault"
 	"github.com/freetocompute/kebe/pkg/store"
 	"github.com/gin-gonic/gin"
 	"github.com/minio/minio-go/v7"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"gorm.io/gorm"
 )
 
 type Server struct {
@@ -53,33 +58,34 @@ func (s *Server) Run() {
 
 	db, _ := database.CreateDatabase()
 
-	assertsDatabase := GetDatabaseWithRootKey()
-
-	obs := objectstore.NewObjectStore()
-	bytes, _ := obs.GetFileFromBucket("root", "private-key.pem")
-	rootPrivateKey, err := crypto.ParseRSAPrivateKeyFromPEM(*bytes)
-	if err != nil {
-		logrus.Error(err)
-		panic(err)
-	}
+	assertsDatabase := GetAssertsDatabase(db)
 
+	accounts := repositories.NewAccountRepository(db)
+	signingBackend := crypto.MustGetBackend()
 	rootAuthorityId := config.MustGetString(configkey.RootAuthority)
-	signingDB := assertstest.NewSigningDB(rootAuthorityId, asserts.RSAPrivateKey(rootPrivateKey))
-
-	bytes, _ = obs.GetFileFromBucket("generic", "private-key.pem")
-	genericPrivateKey, err := crypto.ParseRSAPrivateKeyFromPEM(*bytes)
+	trustedKeyId, err := findTrustedKey(assertsDatabase, accounts, signingBackend, rootAuthorityId)
 	if err != nil {
 		logrus.Error(err)
 		panic(err)
 	}
 
-	err = signingDB.ImportKey(asserts.RSAPrivateKey(genericPrivateKey))
-	if err != nil {
-		panic(err)
+	// new assertions are signed with the active root key, which is looked up as they're signed so rotated keys
+	// are picked up without a restart
+	signingDB := crypto.NewAuthoritySigningDB(signingBackend, accounts, rootAuthorityId, trustedKeyId)
+
+	assertions := repositories.NewAssertionsRepository(db)
+	serials := repositories.NewSerialsRepository(db)
+	serialVault := serialvault.NewVault(serials, accounts, assertions, signingDB)
+	// the device key has no default, a key shared by every deployment would let anyone mint device sessions
+	deviceKey := viper.GetString(configkey.MacaroonDeviceKey)
+	if deviceKey == "" {
+		panic(fmt.Errorf("%s is not set, it must be set to a random secret the store signs device sessions with, e.g. the output of `openssl rand -hex 32`", configkey.MacaroonDeviceKey))
 	}
 
-	handler := store.NewHandler(repositories.NewAccountRepository(db), repositories.NewSnapsRepository(db))
-	store := store.New(handler, assertsDatabase, rootPrivateKey, genericPrivateKey, signingDB)
+	deviceSessions := devicesession.NewManager(repositories.NewDeviceSessionsRepository(db), serials, assertions, assertsDatabase,
+		deviceKey, viper.GetString(configkey.StoreAPIURL))
+	handler := store.NewHandler(accounts, repositories.NewSnapsRepository(db), assertions, serialVault, deviceSessions, repositories.NewValidationSetsRepository(db))
+	store := store.New(handler, assertsDatabase, signingDB)
 	if store == nil {
 		panic("store was not created, cannot continue")
 	}
@@ -100,22 +106,31 @@ func (s *Server) Run() {
 		}
 	}
 
+	err = objectstore.GetMinioClient().MakeBucket(context.Background(), models.MediaBucket, minio.MakeBucketOptions{})
+	if err != nil {
+		if _, ok := err.(minio.ErrorResponse); !ok {
+			panic(err)
+		}
+	}
+
 	_ = r.Run()
 }
 
 var databaseCreationMutex sync.Mutex
 
-func GetDatabaseWithRootKey() *asserts.Database {
+func GetAssertsDatabase(db *gorm.DB) *asserts.Database {
 	minioClient := objectstore.GetMinioClient()
 
 	databaseCreationMutex.Lock()
 	defer databaseCreationMutex.Unlock()
 
-	return GetDatabaseWithRootKeyS3(minioClient)
+	return GetAssertsDatabaseS3(minioClient, db)
 }
 
-func GetDatabaseWithRootKeyS3(minioClient *minio.Client) *asserts.Database {
-	databaseCfg, err := getDatabaseConfig(minioClient)
+// GetAssertsDatabaseS3 returns an assertion database that trusts the store's assertions, it holds no private
+// keys, signing goes through the signing backend
+func GetAssertsDatabaseS3(minioClient *minio.Client, gormDB *gorm.DB) *asserts.Database {
+	databaseCfg, err := getDatabaseConfig(minioClient, gormDB)
 	if err != nil {
 		panic(err)
 	}
@@ -125,42 +140,43 @@ func GetDatabaseWithRootKeyS3(minioClient *minio.Client) *asserts.Database {
 		panic(err)
 	}
 
-	buckets := []string{"root", "generic"}
-	ctx, cancel := context.WithCancel(context.Background())
-
-	defer cancel()
+	return db
+}
 
-	for _, bucket := range buckets {
-		objectCh := minioClient.ListObjects(ctx, bucket, minio.ListObjectsOptions{
-			Recursive: true,
-		})
-		for object := range objectCh {
-			if strings.Contains(object.Key, "pem") {
-				objectPtr, err2 := minioClient.GetObject(ctx, bucket, object.Key, minio.GetObjectOptions{})
-				if err2 != nil {
-					panic(err2)
-				}
-				bytes, _ := ioutil.ReadAll(objectPtr)
+// findTrustedKey returns the key of the account the signing backend holds that devices trust, it is the one
+// the account-keys of the store's other keys are signed with
+func findTrustedKey(assertsDatabase *asserts.Database, accounts repositories.IAccountRepository, backend crypto.Backend, accountId string) (string, error) {
+	account, err := accounts.GetAccountById(accountId, true)
+	if err != nil {
+		return "", err
+	}
 
-				rsaPK, err2 := crypto.ParseRSAPrivateKeyFromPEM(bytes)
-				if err2 != nil {
-					panic(err)
-				}
+	if account == nil {
+		return "", fmt.Errorf("account %s not found, has the store been initialized?", accountId)
+	}
 
-				assertPK := asserts.RSAPrivateKey(rsaPK)
+	for _, key := range account.Keys {
+		_, err = assertsDatabase.FindTrusted(asserts.AccountKeyType, map[string]string{
+			"account-id":          accountId,
+			"public-key-sha3-384": key.SHA3384,
+		})
+		if err != nil {
+			continue
+		}
 
-				err2 = db.ImportKey(assertPK)
-				if err2 != nil {
-					panic(err)
-				}
-			}
+		if _, err = backend.Signer(key.SHA3384); err == nil {
+			return key.SHA3384, nil
 		}
+
+		logrus.Warnf("Trusted key %s of %s is not available from the signing backend: %s", key.SHA3384, accountId, err)
 	}
 
-	return db
+	return "", fmt.Errorf("the signing backend holds no trusted key for %s", accountId)
 }
 
-func getDatabaseConfig(minioClient *minio.Client) (*asserts.DatabaseConfig, error) {
+// getDatabaseConfig returns the configuration for the assertion database, the trusted assertions come from the
+// root and generic buckets while everything else added to the database is kept in Postgres
+func getDatabaseConfig(minioClient *minio.Client, gormDB *gorm.DB) (*asserts.DatabaseConfig, error) {
 	var trusted []asserts.Assertion
 	var otherPredefined []asserts.Assertion
 	buckets := []string{"root", "generic"}
@@ -204,10 +220,16 @@ func getDatabaseConfig(minioClient *minio.Client) (*asserts.DatabaseConfig, erro
 		}
 	}
 
+	backstore := assertions.NewPostgresBackstore(gormDB)
+	err := backstore.Backfill()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := asserts.DatabaseConfig{
 		Trusted:         trusted,
 		OtherPredefined: otherPredefined,
-		Backstore:       asserts.NewMemoryBackstore(),
+		Backstore:       backstore,
 		KeypairManager:  asserts.NewMemoryKeypairManager(),
 		Checkers:        nil,
 	}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -14,6 +15,7 @@ import (
 type SnapMeta struct {
 	Name          string   `yaml:"name"`
 	Version       string   `yaml:"version"`
+	Title         string   `yaml:"title"`
 	Summary       string   `yaml:"summary"`
 	Description   string   `yaml:"description"`
 	Type          string   `yaml:"type"`
@@ -21,6 +23,13 @@ type SnapMeta struct {
 	Confinement   string   `yaml:"confinement"`
 	Grade         string   `yaml:"grade"`
 	Base          string   `yaml:"base"`
+	License       string   `yaml:"license"`
+}
+
+// SnapIcon is an icon found in the meta/gui directory of a snap
+type SnapIcon struct {
+	Filename string
+	Bytes    []byte
 }
 
 // GetSnapMetaFromFile will return SnapMeta from a byte array representing a snap file
@@ -35,38 +44,97 @@ func GetSnapMetaFromFile(snapFilePath string, workingDirectory string) (*SnapMet
 }
 
 func GetSnapMetaFromBytes(bytes []byte, workingDirectory string) (*SnapMeta, error) {
-	tmpFilePath := path.Join(workingDirectory, uuid.New().String()+".snap")
-	err := ioutil.WriteFile(tmpFilePath, bytes, 0755)
+	rootDir, cleanup, err := unsquashFromBytes(bytes, workingDirectory, "meta/snap.yaml")
 	if err == nil {
-		defer func(name string) {
-			errIn := os.Remove(name)
-			if errIn != nil {
-				logrus.Error(errIn)
-			}
-		}(tmpFilePath)
-		err = os.Chdir(workingDirectory)
+		defer cleanup()
+		bytes, err = ioutil.ReadFile(path.Join(rootDir, "meta", "snap.yaml"))
 		if err == nil {
-			cmd := exec.Command("unsquashfs", tmpFilePath, "-e", "meta/snap.yaml")
-			defer func() {
-				errIn := os.RemoveAll(path.Join(workingDirectory, "squashfs-root"))
-				if errIn != nil {
-					logrus.Error(err)
-				}
-			}()
-			cmd.Stderr = os.Stderr
-			err = cmd.Run()
+			var snapMeta SnapMeta
+			err = yaml.Unmarshal(bytes, &snapMeta)
 			if err == nil {
-				bytes, err = ioutil.ReadFile(path.Join(workingDirectory, "squashfs-root", "meta", "snap.yaml"))
-				if err == nil {
-					var snapMeta SnapMeta
-					err = yaml.Unmarshal(bytes, &snapMeta)
-					if err == nil {
-						return &snapMeta, nil
-					}
-				}
+				return &snapMeta, nil
 			}
 		}
 	}
 
 	return nil, err
 }
+
+// GetSnapIconFromBytes returns the icon from meta/gui of a snap, if it has one. A snap
+// without an icon is not an error, nil is returned instead.
+func GetSnapIconFromBytes(bytes []byte, workingDirectory string) (*SnapIcon, error) {
+	rootDir, cleanup, err := unsquashFromBytes(bytes, workingDirectory, "meta/gui")
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	guiDir := path.Join(rootDir, "meta", "gui")
+	entries, err := ioutil.ReadDir(guiDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := path.Ext(name)
+		if entry.IsDir() || strings.TrimSuffix(name, ext) != "icon" {
+			continue
+		}
+
+		switch strings.ToLower(ext) {
+		case ".png", ".svg", ".jpg", ".jpeg":
+			iconBytes, err := ioutil.ReadFile(path.Join(guiDir, name))
+			if err != nil {
+				return nil, err
+			}
+
+			return &SnapIcon{Filename: name, Bytes: iconBytes}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// unsquashFromBytes writes the snap to the working directory and extracts the given paths from it,
+// the returned cleanup function removes everything that was written
+func unsquashFromBytes(bytes []byte, workingDirectory string, extractPaths ...string) (string, func(), error) {
+	id := uuid.New().String()
+	tmpFilePath := path.Join(workingDirectory, id+".snap")
+	rootDir := path.Join(workingDirectory, id+"-squashfs-root")
+
+	err := ioutil.WriteFile(tmpFilePath, bytes, 0755)
+	if err != nil {
+		return "", nil, err
+	}
+
+	cleanup := func() {
+		errIn := os.Remove(tmpFilePath)
+		if errIn != nil {
+			logrus.Error(errIn)
+		}
+
+		errIn = os.RemoveAll(rootDir)
+		if errIn != nil {
+			logrus.Error(errIn)
+		}
+	}
+
+	args := []string{"-d", rootDir, tmpFilePath}
+	for _, p := range extractPaths {
+		args = append(args, "-e", p)
+	}
+
+	cmd := exec.Command("unsquashfs", args...)
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return rootDir, cleanup, nil
+}
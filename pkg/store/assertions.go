@@ -2,6 +2,7 @@ package store
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -12,7 +13,7 @@ func (s *Store) getSnapRevisionAssertion(c *gin.Context) {
 	sha3384digest := c.Param("sha3384digest")
 	logrus.Tracef("Requested snap-revision: %s", sha3384digest)
 
-	assertion, err := s.handler.GetSnapRevisionAssertion(sha3384digest, s.rootStoreKey, s.assertsDatabase)
+	assertion, err := s.handler.GetSnapRevisionAssertion(sha3384digest, s.signingDB)
 	if err == nil && assertion != nil {
 		encodedAssertion := asserts.Encode(assertion)
 		logrus.Trace("Sending snap-revision assertion: ")
@@ -40,7 +41,7 @@ func (s *Store) getSnapDeclarationAssertion(c *gin.Context) {
 	snapId := c.Param("snap-id")
 	logrus.Tracef("Requested snap-declaration: %s", snapId)
 
-	assertion, err := s.handler.GetSnapDeclarationAssertion(snapId, s.rootStoreKey, s.assertsDatabase)
+	assertion, err := s.handler.GetSnapDeclarationAssertion(snapId, s.signingDB)
 	if err == nil && assertion != nil {
 		encodedAssertion := asserts.Encode(assertion)
 		logrus.Trace("Sending snap-declaraction assertion: ")
@@ -64,11 +65,36 @@ func (s *Store) getSnapDeclarationAssertion(c *gin.Context) {
 	c.AbortWithStatus(http.StatusBadRequest)
 }
 
+func (s *Store) getSnapDeveloperAssertion(c *gin.Context) {
+	snapId := c.Param("snap-id")
+	publisherId := c.Param("publisher-id")
+	logrus.Tracef("Requested snap-developer: %s/%s", snapId, publisherId)
+
+	assertion, err := s.handler.GetSnapDeveloperAssertion(snapId, publisherId, s.signingDB)
+	if err == nil && assertion != nil {
+		c.Writer.Header().Set("Content-Type", asserts.MediaType)
+
+		_, err = c.Writer.Write(asserts.Encode(assertion))
+		if err == nil {
+			c.Writer.WriteHeader(200)
+			return
+		}
+
+		logrus.Error(err)
+	} else if err != nil {
+		logrus.Error(err)
+	} else {
+		logrus.Error("unknown error encountered in getSnapDeveloperAssertion")
+	}
+
+	c.AbortWithStatus(http.StatusBadRequest)
+}
+
 func (s *Store) getAccountAssertion(c *gin.Context) {
 	id := c.Param("id")
 	logrus.Tracef("Requested account: %s", id)
 
-	accountAssertion, err := s.handler.GetAccountAssertion(id, s.rootStoreKey, s.signingDB)
+	accountAssertion, err := s.handler.GetAccountAssertion(id, s.signingDB)
 	if err == nil && accountAssertion != nil {
 		assertionBytes := asserts.Encode(accountAssertion)
 		c.Writer.Header().Set("Content-Type", asserts.MediaType)
@@ -91,11 +117,56 @@ func (s *Store) getAccountAssertion(c *gin.Context) {
 	c.AbortWithStatus(http.StatusInternalServerError)
 }
 
+func (s *Store) getModelAssertion(c *gin.Context) {
+	brandId := c.Param("brand")
+	model := c.Param("model")
+	logrus.Tracef("Requested model: %s/%s", brandId, model)
+
+	modelAssertion, err := s.handler.GetModelAssertion(brandId, model)
+	if err == nil && modelAssertion != nil {
+		c.Data(http.StatusOK, asserts.MediaType, asserts.Encode(modelAssertion))
+		return
+	} else if err == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"code": "not-found", "message": "model not found"})
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+func (s *Store) getValidationSetAssertion(c *gin.Context) {
+	accountId := c.Param("account-id")
+	name := c.Param("name")
+	sequence := 0
+	if c.Query("sequence") != "" {
+		var err error
+		sequence, err = strconv.Atoi(c.Query("sequence"))
+		if err != nil || sequence < 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"code": "invalid-request", "message": "sequence must be a number greater than zero"})
+			return
+		}
+	}
+	logrus.Tracef("Requested validation-set: %s/%s sequence %d", accountId, name, sequence)
+
+	validationSet, err := s.handler.GetValidationSetAssertion(accountId, name, sequence)
+	if err == nil && validationSet != nil {
+		c.Data(http.StatusOK, asserts.MediaType, asserts.Encode(validationSet))
+		return
+	} else if err == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"code": "not-found", "message": "validation set not found"})
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
 func (s *Store) getAccountKey(c *gin.Context) {
 	key := c.Param("key")
 	logrus.Tracef("Requested account-key: %s", key)
 
-	accountKeyAssertion, err := s.handler.GetAccountKeyAssertion(key, s.rootStoreKey, s.signingDB)
+	accountKeyAssertion, err := s.handler.GetAccountKeyAssertion(key, s.signingDB)
 	if err == nil && accountKeyAssertion != nil {
 		logrus.Tracef("Found account-key assertion: %+v", accountKeyAssertion)
 
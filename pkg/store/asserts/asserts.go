@@ -1,25 +1,32 @@
 package asserts
 
 import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/assertions"
 	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/repositories"
+	"github.com/sirupsen/logrus"
 	"github.com/snapcore/snapd/asserts"
-	"time"
+	"github.com/snapcore/snapd/asserts/assertstest"
 )
 
-func MakeSnapDeclarationAssertion(authorityId, publisherId string, snapEntry *models.SnapEntry, storePrivateKey asserts.PrivateKey, db *asserts.Database) (*asserts.SnapDeclaration, error) {
+func MakeSnapDeclarationAssertion(authorityId, publisherId string, snapEntry *models.SnapEntry, keyID string, db assertstest.SignerDB, repo repositories.IAssertionsRepository) (*asserts.SnapDeclaration, error) {
 	headers := map[string]interface{}{
 		"authority-id": authorityId,
 		"series":       "16",
 		"snap-id":      snapEntry.SnapStoreID,
 		"publisher-id": publisherId,
 		"snap-name":    snapEntry.Name,
-		"timestamp":    time.Now().Format(time.RFC3339),
-		"revision":     "1",
 	}
 
-	a, err := db.Sign(asserts.SnapDeclarationType, headers, nil, storePrivateKey.PublicKey().ID())
+	a, err := SignAndPersist(asserts.SnapDeclarationType, headers, nil, keyID, db, repo)
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +38,7 @@ func MakeSnapDeclarationAssertion(authorityId, publisherId string, snapEntry *mo
 	return nil, errors.New("unable to cast assertion")
 }
 
-func MakeSnapRevisionAssertion(authorityId, digest, snapID string, size uint64, revision int, developerID, keyID string, db *asserts.Database) (*asserts.SnapRevision, error) {
+func MakeSnapRevisionAssertion(authorityId, digest, snapID string, size uint64, revision int, developerID, keyID string, db assertstest.SignerDB, repo repositories.IAssertionsRepository) (*asserts.SnapRevision, error) {
 	headers := map[string]interface{}{
 		"authority-id":  authorityId,
 		"snap-sha3-384": digest,
@@ -39,9 +46,8 @@ func MakeSnapRevisionAssertion(authorityId, digest, snapID string, size uint64,
 		"snap-size":     fmt.Sprintf("%d", size),
 		"snap-revision": fmt.Sprintf("%d", revision),
 		"developer-id":  developerID,
-		"timestamp":     time.Now().Format(time.RFC3339),
 	}
-	a, err := db.Sign(asserts.SnapRevisionType, headers, nil, keyID)
+	a, err := SignAndPersist(asserts.SnapRevisionType, headers, nil, keyID, db, repo)
 	if err != nil {
 		return nil, err
 	}
@@ -53,3 +59,209 @@ func MakeSnapRevisionAssertion(authorityId, digest, snapID string, size uint64,
 
 	return nil, errors.New("unable to cast assertion")
 }
+
+// MakeSnapDeveloperAssertion lists the collaborators of the snap and the periods they could upload revisions of it,
+// the publisher is implicitly a developer and is not listed. Periods shorter than a second can't be told apart from
+// a revocation and are left out.
+func MakeSnapDeveloperAssertion(authorityId, publisherId string, snapEntry *models.SnapEntry, collaborators []models.SnapCollaborator, keyID string, db assertstest.SignerDB, repo repositories.IAssertionsRepository) (*asserts.SnapDeveloper, error) {
+	headers := map[string]interface{}{
+		"authority-id": authorityId,
+		"snap-id":      snapEntry.SnapStoreID,
+		"publisher-id": publisherId,
+	}
+
+	var developers []interface{}
+	for _, collaborator := range collaborators {
+		since := collaborator.Since.UTC().Truncate(time.Second)
+		developer := map[string]interface{}{
+			"developer-id": collaborator.Account.AccountId,
+			"since":        since.Format(time.RFC3339),
+		}
+
+		if collaborator.Until != nil {
+			until := collaborator.Until.UTC().Truncate(time.Second)
+			if !until.After(since) {
+				continue
+			}
+
+			developer["until"] = until.Format(time.RFC3339)
+		}
+
+		developers = append(developers, developer)
+	}
+
+	if len(developers) > 0 {
+		headers["developers"] = developers
+	}
+
+	a, err := SignAndPersist(asserts.SnapDeveloperType, headers, nil, keyID, db, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if aaa, ok := a.(*asserts.SnapDeveloper); ok {
+		return aaa, nil
+	}
+
+	return nil, errors.New("unable to cast assertion")
+}
+
+// MakeAccountKeyAssertion signs the account-key of a key the account has registered. A revoked key gets a new
+// revision of its account-key with until set, snapd stops trusting assertions signed with it after that.
+func MakeAccountKeyAssertion(authorityId, accountId string, key *models.Key, keyID string, db assertstest.SignerDB, repo repositories.IAssertionsRepository) (*asserts.AccountKey, error) {
+	encodedPublicKey, err := base64.StdEncoding.DecodeString(key.EncodedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := asserts.DecodePublicKey(encodedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]interface{}{
+		"authority-id":        authorityId,
+		"account-id":          accountId,
+		"since":               key.Since.UTC().Format(time.RFC3339),
+		"public-key-sha3-384": publicKey.ID(),
+		"name":                key.Name,
+	}
+
+	if key.Until != nil {
+		headers["until"] = key.Until.UTC().Format(time.RFC3339)
+	}
+
+	body, err := asserts.EncodePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := SignAndPersist(asserts.AccountKeyType, headers, body, keyID, db, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if aaa, ok := a.(*asserts.AccountKey); ok {
+		return aaa, nil
+	}
+
+	return nil, errors.New("unable to cast assertion")
+}
+
+// SignAndPersist returns the latest stored revision of the assertion if its content matches the headers and
+// body given and it was signed with keyID, otherwise it signs a new revision and stores it. That way
+// assertions signed with a key that has been rotated out are signed again with the current key when next
+// requested. The timestamp and revision headers are managed here and should not be set by callers unless they
+// are part of the content.
+func SignAndPersist(assertType *asserts.AssertionType, headers map[string]interface{}, body []byte, keyID string, db assertstest.SignerDB, repo repositories.IAssertionsRepository) (asserts.Assertion, error) {
+	primaryKey, err := PrimaryKey(assertType, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	contentDigest, err := digestContent(headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := repo.GetLatestAssertion(assertType.Name, primaryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nextRevision := 1
+	if latest != nil {
+		if latest.ContentDigest == contentDigest {
+			stored, err2 := asserts.Decode([]byte(latest.Encoded))
+			if err2 != nil {
+				return nil, err2
+			}
+
+			if keyID == "" || stored.SignKeyID() == keyID {
+				return stored, nil
+			}
+
+			logrus.Infof("%s %s was signed with %s, signing it again with %s", assertType.Name, primaryKey, stored.SignKeyID(), keyID)
+		}
+
+		nextRevision = latest.Revision + 1
+	}
+
+	toSign := make(map[string]interface{}, len(headers)+2)
+	for k, v := range headers {
+		toSign[k] = v
+	}
+
+	toSign["revision"] = strconv.Itoa(nextRevision)
+	if _, ok := toSign["timestamp"]; !ok && hasTimestamp(assertType) {
+		toSign["timestamp"] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	a, err := db.Sign(assertType, toSign, body, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the record has the columns the assertion database searches so it sees what the store signs
+	record, err := assertions.NewRecord(a)
+	if err != nil {
+		return nil, err
+	}
+
+	record.ContentDigest = contentDigest
+	err = repo.AddAssertion(record)
+	if err != nil {
+		// another instance may have stored the same revision first, serve that one if it has the same content
+		logrus.Warnf("unable to store %s %s revision %d: %s", assertType.Name, primaryKey, nextRevision, err)
+		raced, err2 := repo.GetLatestAssertion(assertType.Name, primaryKey)
+		if err2 == nil && raced != nil && raced.ContentDigest == contentDigest {
+			return asserts.Decode([]byte(raced.Encoded))
+		}
+
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// PrimaryKey returns the primary key values of the assertion joined with "/"
+func PrimaryKey(assertType *asserts.AssertionType, headers map[string]interface{}) (string, error) {
+	var values []string
+	for _, name := range assertType.PrimaryKey {
+		value, ok := headers[name].(string)
+		if !ok || value == "" {
+			return "", fmt.Errorf("%s assertion is missing primary key header %q", assertType.Name, name)
+		}
+
+		values = append(values, value)
+	}
+
+	// the assertion database looks assertions up by the same key
+	return assertions.JoinKey(values), nil
+}
+
+func digestContent(headers map[string]interface{}, body []byte) (string, error) {
+	content := make(map[string]interface{}, len(headers))
+	for k, v := range headers {
+		if k == "revision" || k == "timestamp" {
+			continue
+		}
+		content[k] = v
+	}
+
+	// map keys are sorted when marshalled so this is stable
+	contentBytes, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+
+	h := crypto.SHA3_384.New()
+	h.Write(contentBytes)
+	h.Write(body)
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func hasTimestamp(assertType *asserts.AssertionType) bool {
+	// account-key uses since/until rather than a timestamp
+	return assertType != asserts.AccountKeyType
+}
@@ -0,0 +1,209 @@
+package asserts
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/freetocompute/kebe/pkg/crypto"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/snapcore/snapd/asserts"
+)
+
+// fakeAssertions keeps the assertions in memory, the latest revision last
+type fakeAssertions struct {
+	assertions []models.Assertion
+}
+
+func (f *fakeAssertions) GetLatestAssertion(assertionType string, primaryKey string) (*models.Assertion, error) {
+	for i := len(f.assertions) - 1; i >= 0; i-- {
+		if f.assertions[i].Type == assertionType && f.assertions[i].PrimaryKey == primaryKey {
+			return &f.assertions[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeAssertions) AddAssertion(assertion *models.Assertion) error {
+	f.assertions = append(f.assertions, *assertion)
+	return nil
+}
+
+func newTestSigningDB(t *testing.T) (*crypto.SigningDB, string, string) {
+	backend, err := crypto.NewKeyfileBackend(t.TempDir(), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldPublicKey, err := backend.GenerateKey("old", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPublicKey, err := backend.GenerateKey("new", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKeyId := asserts.RSAPublicKey(oldPublicKey).ID()
+	return crypto.NewSigningDB(backend, "test-store", oldKeyId), oldKeyId, asserts.RSAPublicKey(newPublicKey).ID()
+}
+
+func accountHeaders(displayName string) map[string]interface{} {
+	return map[string]interface{}{
+		"authority-id": "test-store",
+		"account-id":   "test-account",
+		"display-name": displayName,
+		"username":     "test",
+		"validation":   "unproven",
+	}
+}
+
+func TestSignAndPersist(t *testing.T) {
+	db, oldKeyId, newKeyId := newTestSigningDB(t)
+
+	tests := []struct {
+		name string
+		// stored is signed and persisted with storedKeyId first, unless it's nil
+		stored      map[string]interface{}
+		storedKeyId string
+		headers     map[string]interface{}
+		keyId       string
+		revision    int
+		signKeyId   string
+		added       int
+	}{
+		{name: "first revision", headers: accountHeaders("Test"), keyId: oldKeyId, revision: 1, signKeyId: oldKeyId, added: 1},
+		{name: "default key", headers: accountHeaders("Test"), keyId: "", revision: 1, signKeyId: oldKeyId, added: 1},
+		{name: "unchanged", stored: accountHeaders("Test"), storedKeyId: oldKeyId, headers: accountHeaders("Test"), keyId: oldKeyId, revision: 1, signKeyId: oldKeyId},
+		{name: "unchanged with any key", stored: accountHeaders("Test"), storedKeyId: oldKeyId, headers: accountHeaders("Test"), keyId: "", revision: 1, signKeyId: oldKeyId},
+		{name: "key rotated", stored: accountHeaders("Test"), storedKeyId: oldKeyId, headers: accountHeaders("Test"), keyId: newKeyId, revision: 2, signKeyId: newKeyId, added: 1},
+		{name: "content changed", stored: accountHeaders("Test"), storedKeyId: oldKeyId, headers: accountHeaders("Renamed"), keyId: oldKeyId, revision: 2, signKeyId: oldKeyId, added: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &fakeAssertions{}
+			if test.stored != nil {
+				_, err := SignAndPersist(asserts.AccountType, test.stored, nil, test.storedKeyId, db, repo)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stored := len(repo.assertions)
+			a, err := SignAndPersist(asserts.AccountType, test.headers, nil, test.keyId, db, repo)
+			if err != nil {
+				t.Fatalf("SignAndPersist failed: %s", err)
+			}
+
+			if a.Revision() != test.revision {
+				t.Errorf("expected revision %d, got %d", test.revision, a.Revision())
+			}
+
+			if a.SignKeyID() != test.signKeyId {
+				t.Errorf("expected the assertion to be signed with %s, got %s", test.signKeyId, a.SignKeyID())
+			}
+
+			if added := len(repo.assertions) - stored; added != test.added {
+				t.Errorf("expected %d assertions to be stored, got %d", test.added, added)
+			}
+
+			if test.added > 0 && repo.assertions[len(repo.assertions)-1].Encoded != string(asserts.Encode(a)) {
+				t.Error("the stored assertion is not the one returned")
+			}
+		})
+	}
+}
+
+func TestSignAndPersistErrors(t *testing.T) {
+	db, oldKeyId, _ := newTestSigningDB(t)
+
+	contentDigest, err := digestContent(accountHeaders("Test"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		stored  []models.Assertion
+		headers map[string]interface{}
+		keyId   string
+	}{
+		{name: "unknown key", headers: accountHeaders("Test"), keyId: "unknown"},
+		{name: "missing primary key", headers: map[string]interface{}{"authority-id": "test-store", "display-name": "Test"}, keyId: oldKeyId},
+		{
+			name:    "malformed stored assertion",
+			stored:  []models.Assertion{{Type: asserts.AccountType.Name, PrimaryKey: "test-account", Revision: 1, ContentDigest: contentDigest, Encoded: "not an assertion"}},
+			headers: accountHeaders("Test"),
+			keyId:   oldKeyId,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &fakeAssertions{assertions: test.stored}
+			_, err := SignAndPersist(asserts.AccountType, test.headers, nil, test.keyId, db, repo)
+			if err == nil {
+				t.Fatal("expected SignAndPersist to fail")
+			}
+
+			if len(repo.assertions) != len(test.stored) {
+				t.Errorf("expected nothing to be stored, got %d assertions", len(repo.assertions))
+			}
+		})
+	}
+}
+
+func TestMakeAccountKeyAssertionRevoked(t *testing.T) {
+	db, oldKeyId, newKeyId := newTestSigningDB(t)
+	repo := &fakeAssertions{}
+
+	publicKey, err := db.PublicKey(newKeyId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encodedPublicKey, err := asserts.EncodePublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	since := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	key := &models.Key{Name: "default", EncodedPublicKey: base64.StdEncoding.EncodeToString(encodedPublicKey), Since: since}
+
+	accountKey, err := MakeAccountKeyAssertion("test-store", "test-account", key, oldKeyId, db, repo)
+	if err != nil {
+		t.Fatalf("MakeAccountKeyAssertion failed: %s", err)
+	}
+
+	if !accountKey.Until().IsZero() || accountKey.Revision() != 1 {
+		t.Fatalf("expected revision 1 without until, got revision %d until %s", accountKey.Revision(), accountKey.Until())
+	}
+
+	until := time.Now().UTC().Truncate(time.Second)
+	key.Until = &until
+	revoked, err := MakeAccountKeyAssertion("test-store", "test-account", key, oldKeyId, db, repo)
+	if err != nil {
+		t.Fatalf("MakeAccountKeyAssertion failed: %s", err)
+	}
+
+	if revoked.Revision() != 2 || !revoked.Until().Equal(until) {
+		t.Errorf("expected revision 2 until %s, got revision %d until %s", until, revoked.Revision(), revoked.Until())
+	}
+
+	if revoked.PublicKeyID() != newKeyId || revoked.SignKeyID() != oldKeyId {
+		t.Errorf("expected the key %s signed with %s, got %s signed with %s", newKeyId, oldKeyId, revoked.PublicKeyID(), revoked.SignKeyID())
+	}
+
+	signingKey, err := db.PublicKey(oldKeyId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = asserts.SignatureCheck(revoked, signingKey)
+	if err != nil {
+		t.Errorf("the revoked account-key doesn't verify: %s", err)
+	}
+}
@@ -11,19 +11,26 @@ func (s *Store) SetupEndpoints(r *gin.Engine) {
 
 	r.GET("/api/v1/snaps/assertions/account/:id", s.getAccountAssertion)
 	r.GET("/api/v1/snaps/assertions/account-key/:key", s.getAccountKey)
+	r.GET("/api/v1/snaps/assertions/model/16/:brand/:model", s.getModelAssertion)
 	r.GET("/api/v1/snaps/assertions/snap-declaration/16/:snap-id", s.getSnapDeclarationAssertion)
+	r.GET("/api/v1/snaps/assertions/snap-developer/:snap-id/:publisher-id", s.getSnapDeveloperAssertion)
 	r.GET("/api/v1/snaps/assertions/snap-revision/:sha3384digest", s.getSnapRevisionAssertion)
+	r.GET("/api/v1/snaps/assertions/validation-set/16/:account-id/:name", s.getValidationSetAssertion)
 	r.GET("/api/v1/snaps/names", s.getSnapNames)
 	r.GET("/api/v1/snaps/sections", s.getSnapSections)
 
 	r.GET("/v2/assertions/account/:id", s.getAccountAssertion)
 	r.GET("/v2/assertions/account-key/:key", s.getAccountKey)
+	r.GET("/v2/assertions/model/16/:brand/:model", s.getModelAssertion)
 	r.GET("/v2/assertions/snap-declaration/16/:snap-id", s.getSnapDeclarationAssertion)
+	r.GET("/v2/assertions/snap-developer/:snap-id/:publisher-id", s.getSnapDeveloperAssertion)
 	r.GET("/v2/assertions/snap-revision/:sha3384digest", s.getSnapRevisionAssertion)
+	r.GET("/v2/assertions/validation-set/16/:account-id/:name", s.getValidationSetAssertion)
 	r.GET("/v2/snaps/find", s.findSnap)
-	r.POST("/v2/snaps/refresh", s.snapRefresh)
+	r.POST("/v2/snaps/refresh", s.identifyDevice, s.snapRefresh)
 
-	r.GET("/download/snaps/:filename", s.snapDownload)
+	r.GET("/download/snaps/:filename", s.identifyDevice, s.snapDownload)
+	r.GET("/media/:filename", s.getMedia)
 
 	r.POST("/unscanned-upload/", s.unscannedUpload)
 }
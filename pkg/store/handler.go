@@ -1,26 +1,25 @@
 package store
 
 import (
-	"crypto/rsa"
-	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path"
 	"strconv"
+	"time"
 
+	"github.com/freetocompute/kebe/pkg/assertions"
+	"github.com/freetocompute/kebe/pkg/crypto"
 	"github.com/freetocompute/kebe/pkg/database"
+	"github.com/freetocompute/kebe/pkg/devicesession"
 	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/serialvault"
 
 	"github.com/google/uuid"
 
-	"github.com/snapcore/snapd/asserts/assertstest"
-
 	asserts2 "github.com/freetocompute/kebe/pkg/store/asserts"
 
-	"github.com/freetocompute/kebe/config"
-	"github.com/freetocompute/kebe/config/configkey"
-
 	"github.com/snapcore/snapd/asserts"
 
 	"github.com/freetocompute/kebe/pkg/objectstore"
@@ -39,28 +38,42 @@ type IStoreHandler interface {
 	GetSections() (*responses.SectionResults, error)
 	GetSnapNames() (*responses.CatalogResults, error)
 	FindSnap(name string) (*responses.SearchV2Results, error)
-	SnapRefresh(actions *[]*requests.SnapActionJSON) (*responses.SnapActionResultList, error)
+	SnapRefresh(actionRequest *requests.SnapActionRequest) (*responses.SnapActionResultList, error)
 	SnapDownload(snapFilename string) (*[]byte, error)
-	GetSnapRevisionAssertion(SHA3384Encoded string, rootStoreKey *rsa.PrivateKey, assertsDB *asserts.Database) (*asserts.SnapRevision, error)
-	GetSnapDeclarationAssertion(snapId string, rootStoreKey *rsa.PrivateKey, assertsDB *asserts.Database) (*asserts.SnapDeclaration, error)
-	GetAccountKeyAssertion(keySHA3384 string, rootStoreKey *rsa.PrivateKey, signingDB *assertstest.SigningDB) (*asserts.AccountKey, error)
-	GetAccountAssertion(accountId string, rootStoreKey *rsa.PrivateKey, signingDB *assertstest.SigningDB) (*asserts.Account, error)
+	GetSnapRevisionAssertion(SHA3384Encoded string, signingDB *crypto.SigningDB) (*asserts.SnapRevision, error)
+	GetSnapDeclarationAssertion(snapId string, signingDB *crypto.SigningDB) (*asserts.SnapDeclaration, error)
+	GetSnapDeveloperAssertion(snapId string, publisherId string, signingDB *crypto.SigningDB) (*asserts.SnapDeveloper, error)
+	GetAccountKeyAssertion(keySHA3384 string, signingDB *crypto.SigningDB) (*asserts.AccountKey, error)
+	GetAccountAssertion(accountId string, signingDB *crypto.SigningDB) (*asserts.Account, error)
+	GetModelAssertion(brandId string, model string) (*asserts.Model, error)
+	GetValidationSetAssertion(accountId string, name string, sequence int) (*asserts.ValidationSet, error)
 	UnscannedUpload(snapFile io.Reader) (string, error)
 	AuthRequest() *responses.AuthRequestIDResp
-	AuthDevice(serialRequest *asserts.SerialRequest, genericPrivateKey asserts.PrivateKey, signingDB *assertstest.SigningDB) (*asserts.Serial, error)
-	AuthNonce() *responses.Nonce
-	AuthSession() *responses.Session
+	AuthDevice(serialRequest *asserts.SerialRequest) (*asserts.Serial, error)
+	AuthNonce() (*responses.Nonce, error)
+	AuthSession(sessionRequest *requests.Session) (*responses.Session, error)
+	IdentifyDevice(authorization string) (*models.Serial, error)
+	GetMedia(filename string) (*models.SnapMedia, *[]byte, error)
 }
 
 type Handler struct {
-	accounts repositories.IAccountRepository
-	snaps    repositories.ISnapsRepository
+	accounts    repositories.IAccountRepository
+	snaps       repositories.ISnapsRepository
+	assertions  repositories.IAssertionsRepository
+	serialVault *serialvault.Vault
+	sessions    *devicesession.Manager
+
+	validationSets repositories.IValidationSetsRepository
 }
 
-func NewHandler(accts repositories.IAccountRepository, snaps repositories.ISnapsRepository) *Handler {
+func NewHandler(accts repositories.IAccountRepository, snaps repositories.ISnapsRepository, assertions repositories.IAssertionsRepository, serialVault *serialvault.Vault, sessions *devicesession.Manager, validationSets repositories.IValidationSetsRepository) *Handler {
 	return &Handler{
 		accts,
 		snaps,
+		assertions,
+		serialVault,
+		sessions,
+		validationSets,
 	}
 }
 
@@ -72,42 +85,8 @@ func (h *Handler) AuthRequest() *responses.AuthRequestIDResp {
 	return resp
 }
 
-func (h *Handler) AuthDevice(serialRequest *asserts.SerialRequest, genericPrivateKey asserts.PrivateKey, signingDB *assertstest.SigningDB) (*asserts.Serial, error) {
-	// TODO: this private key needs to be handled differently
-
-	// TODO: store session information in the database
-	serial := uuid.New().String()
-	encodedKeyBytes, err := asserts.EncodePublicKey(serialRequest.DeviceKey())
-	if err != nil {
-		panic(err)
-	}
-
-	serialHeaders := map[string]interface{}{
-		"brand-id":            serialRequest.BrandID(),
-		"model":               serialRequest.Model(),
-		"authority-id":        serialRequest.BrandID(),
-		"serial":              serial,
-		"device-key":          string(encodedKeyBytes),
-		"device-key-sha3-384": serialRequest.DeviceKey().ID(),
-		// TODO: fix this static timestamp
-		"timestamp": "2021-03-06T15:04:00Z",
-	}
-
-	// TODO: look up actual account, get key, etc.
-	assertion, err := signingDB.Sign(asserts.SerialType, serialHeaders, nil, genericPrivateKey.PublicKey().ID())
-
-	if err == nil && assertion != nil {
-		if serialAssertion, ok := assertion.(*asserts.Serial); ok {
-			return serialAssertion, nil
-		} else {
-			return nil, errors.New("unable to assert type on serial assertion")
-		}
-	} else if err != nil {
-		logrus.Error(err)
-		return nil, err
-	}
-
-	return nil, errors.New("unknown error encountered trying to get serial assertion")
+func (h *Handler) AuthDevice(serialRequest *asserts.SerialRequest) (*asserts.Serial, error) {
+	return h.serialVault.IssueSerial(serialRequest)
 }
 
 func (h *Handler) UnscannedUpload(snapFile io.Reader) (string, error) {
@@ -126,45 +105,41 @@ func (h *Handler) UnscannedUpload(snapFile io.Reader) (string, error) {
 
 }
 
-func (h *Handler) AuthSession() *responses.Session {
-	session := &responses.Session{Macaroon: "12345-678-9101112"}
-	return session
+func (h *Handler) AuthSession(sessionRequest *requests.Session) (*responses.Session, error) {
+	mac, err := h.sessions.CreateSession(sessionRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &responses.Session{Macaroon: mac}, nil
+}
+
+func (h *Handler) AuthNonce() (*responses.Nonce, error) {
+	nonce, err := h.sessions.IssueNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	return &responses.Nonce{Nonce: nonce}, nil
 }
 
-func (h *Handler) AuthNonce() *responses.Nonce {
-	nonce := responses.Nonce{Nonce: uuid.New().String()}
-	return &nonce
+func (h *Handler) IdentifyDevice(authorization string) (*models.Serial, error) {
+	return h.sessions.Authenticate(authorization)
 }
 
-func (h *Handler) GetAccountKeyAssertion(keySHA3384 string, rootStoreKey *rsa.PrivateKey, signingDB *assertstest.SigningDB) (*asserts.AccountKey, error) {
+func (h *Handler) GetAccountKeyAssertion(keySHA3384 string, signingDB *crypto.SigningDB) (*asserts.AccountKey, error) {
 	accountKey, err := h.accounts.GetKeyBySHA3384(keySHA3384)
 	if err == nil && accountKey != nil {
 		logrus.Tracef("Found account-key: %+v", accountKey)
 
-		bytes, err2 := base64.StdEncoding.DecodeString(accountKey.EncodedPublicKey)
+		// account-keys are signed with the trusted key, so devices can verify the store's newer keys
+		trustedAccKey, err2 := asserts2.MakeAccountKeyAssertion(signingDB.AuthorityID, accountKey.Account.AccountId, accountKey, signingDB.KeyID, signingDB, h.assertions)
 		if err2 != nil {
-			panic(err2)
-		}
-
-		pbk, err2 := asserts.DecodePublicKey([]byte(bytes))
-		if err2 != nil {
-			panic(err2)
+			logrus.Error(err2)
+			return nil, err2
 		}
 
-		trustedAcct := getTrustedAccount(accountKey.Account.AccountId, signingDB, accountKey.Account.DisplayName)
-
-		// TODO: what do do about these dates?
-		trustedAcctKeyHeaders := map[string]interface{}{
-			"since":               "2015-11-20T15:04:00Z",
-			"until":               "2500-11-20T15:04:00Z",
-			"public-key-sha3-384": accountKey.SHA3384,
-			"name":                accountKey.Name,
-		}
-		//
-		trustedAccKey := assertstest.NewAccountKey(signingDB, trustedAcct, trustedAcctKeyHeaders, pbk, "")
-		if trustedAccKey != nil {
-			return trustedAccKey, nil
-		}
+		return trustedAccKey, nil
 	} else if err != nil {
 		logrus.Error(err)
 		return nil, err
@@ -173,13 +148,16 @@ func (h *Handler) GetAccountKeyAssertion(keySHA3384 string, rootStoreKey *rsa.Pr
 	return nil, errors.New("account key could not be found or there was an error")
 }
 
-func (h *Handler) GetAccountAssertion(accountId string, rootStoreKey *rsa.PrivateKey, signingDB *assertstest.SigningDB) (*asserts.Account, error) {
+func (h *Handler) GetAccountAssertion(accountId string, signingDB *crypto.SigningDB) (*asserts.Account, error) {
 	account, err := h.accounts.GetAccountById(accountId, false)
 	if err == nil && account != nil {
-		//
-		pk := asserts.RSAPrivateKey(rootStoreKey)
-		acct := createAccountAssertion(signingDB, pk.PublicKey().ID(), account.AccountId, account.Username)
-		return acct, nil
+		keyId, err2 := signingDB.ActiveKeyID()
+		if err2 != nil {
+			logrus.Error(err2)
+			return nil, err2
+		}
+
+		return createAccountAssertion(signingDB, keyId, account, h.assertions)
 	} else if err != nil {
 		return nil, err
 	}
@@ -188,18 +166,41 @@ func (h *Handler) GetAccountAssertion(accountId string, rootStoreKey *rsa.Privat
 	return nil, errors.New("account not found")
 }
 
-func (h *Handler) GetSnapDeclarationAssertion(snapStoreId string, rootStoreKey *rsa.PrivateKey, assertsDB *asserts.Database) (*asserts.SnapDeclaration, error) {
+// GetModelAssertion returns the latest signed revision of the model, nil is returned if the brand has not
+// signed one
+func (h *Handler) GetModelAssertion(brandId string, model string) (*asserts.Model, error) {
+	stored, err := h.assertions.GetLatestAssertion(asserts.ModelType.Name, assertions.JoinKey([]string{"16", brandId, model}))
+	if err != nil || stored == nil {
+		return nil, err
+	}
+
+	assertion, err := asserts.Decode([]byte(stored.Encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	if modelAssertion, ok := assertion.(*asserts.Model); ok {
+		return modelAssertion, nil
+	}
+
+	return nil, errors.New("unable to assert type on model assertion")
+}
+
+func (h *Handler) GetSnapDeclarationAssertion(snapStoreId string, signingDB *crypto.SigningDB) (*asserts.SnapDeclaration, error) {
 	logrus.Tracef("Requested snap-declaration: %s", snapStoreId)
 
 	snapEntry, err := h.snaps.GetSnapByStoreId(snapStoreId, true)
 	if err == nil && snapEntry != nil {
-		// TODO: do this sooner, like during construction to fail then if not MUST
-		rootAuthorityId := config.MustGetString(configkey.RootAuthority)
+		keyId, err2 := signingDB.ActiveKeyID()
+		if err2 != nil {
+			logrus.Error(err2)
+			return nil, err2
+		}
 
-		aaa, err2 := asserts2.MakeSnapDeclarationAssertion(rootAuthorityId, snapEntry.Account.AccountId, snapEntry, asserts.RSAPrivateKey(rootStoreKey), assertsDB)
+		aaa, err2 := asserts2.MakeSnapDeclarationAssertion(signingDB.AuthorityID, snapEntry.Account.AccountId, snapEntry, keyId, signingDB, h.assertions)
 		if err2 == nil && aaa != nil {
 			return aaa, nil
-		} else if err2 == nil {
+		} else if err2 != nil {
 			logrus.Error(err2)
 			return nil, err2
 		}
@@ -213,20 +214,27 @@ func (h *Handler) GetSnapDeclarationAssertion(snapStoreId string, rootStoreKey *
 	return nil, errUnknown
 }
 
-func (h *Handler) GetSnapRevisionAssertion(SHA3384Encoded string, rootStoreKey *rsa.PrivateKey, assertsDB *asserts.Database) (*asserts.SnapRevision, error) {
+func (h *Handler) GetSnapRevisionAssertion(SHA3384Encoded string, signingDB *crypto.SigningDB) (*asserts.SnapRevision, error) {
 	revision, err := h.snaps.GetRevisionBySHA(SHA3384Encoded, true)
 	if err == nil && revision != nil {
 		snapEntry, err2 := h.snaps.GetSnapById(revision.SnapEntryID, true)
 		logrus.Tracef("Got snap entry: %+v", snapEntry)
 
 		if err2 == nil && snapEntry != nil {
+			keyId, err3 := signingDB.ActiveKeyID()
+			if err3 != nil {
+				logrus.Error(err3)
+				return nil, err3
+			}
 
-			// TODO: we should get this somewhere sooner, like construction so we can MUST fail at the beginning of time
-			storeAuthorityId := config.MustGetString(configkey.RootAuthority)
+			developerId, err3 := h.getRevisionDeveloperId(revision, snapEntry)
+			if err3 != nil {
+				logrus.Error(err3)
+				return nil, err3
+			}
 
-			// TODO: we can do better here
-			assertion, err3 := asserts2.MakeSnapRevisionAssertion(storeAuthorityId, SHA3384Encoded, snapEntry.SnapStoreID, uint64(revision.Size), int(revision.ID), snapEntry.Account.AccountId,
-				asserts.RSAPrivateKey(rootStoreKey).PublicKey().ID(), assertsDB)
+			assertion, err3 := asserts2.MakeSnapRevisionAssertion(signingDB.AuthorityID, SHA3384Encoded, snapEntry.SnapStoreID, uint64(revision.Size), int(revision.ID), developerId,
+				keyId, signingDB, h.assertions)
 			if err3 == nil && assertion != nil {
 				return assertion, nil
 			} else if err3 != nil {
@@ -245,6 +253,67 @@ func (h *Handler) GetSnapRevisionAssertion(SHA3384Encoded string, rootStoreKey *
 	return nil, errors.New("unknown error encountered while trying to get snap revision assertion")
 }
 
+// getRevisionDeveloperId returns the account id of the collaborator that uploaded the revision, revisions uploaded by
+// the publisher, members of the organization publishing the snap or before uploaders were recorded are the
+// publisher's
+func (h *Handler) getRevisionDeveloperId(revision *models.SnapRevision, snapEntry *models.SnapEntry) (string, error) {
+	if revision.UploaderID == 0 || revision.UploaderID == snapEntry.AccountID {
+		return snapEntry.Account.AccountId, nil
+	}
+
+	collaborators, err := h.snaps.GetCollaborators(snapEntry.ID, true)
+	if err != nil {
+		return "", err
+	}
+
+	for _, collaborator := range *collaborators {
+		if collaborator.AccountID == revision.UploaderID {
+			return collaborator.Account.AccountId, nil
+		}
+	}
+
+	logrus.Tracef("Revision %d of %s was not uploaded by a collaborator, %s is the developer", revision.ID, snapEntry.Name, snapEntry.Account.AccountId)
+	return snapEntry.Account.AccountId, nil
+}
+
+func (h *Handler) GetSnapDeveloperAssertion(snapStoreId string, publisherId string, signingDB *crypto.SigningDB) (*asserts.SnapDeveloper, error) {
+	logrus.Tracef("Requested snap-developer: %s/%s", snapStoreId, publisherId)
+
+	snapEntry, err := h.snaps.GetSnapByStoreId(snapStoreId, true)
+	if err == nil && snapEntry != nil {
+		if snapEntry.Account.AccountId != publisherId {
+			return nil, fmt.Errorf("%s is not the publisher of %s", publisherId, snapStoreId)
+		}
+
+		collaborators, err2 := h.snaps.GetCollaborators(snapEntry.ID, true)
+		if err2 != nil {
+			logrus.Error(err2)
+			return nil, err2
+		}
+
+		keyId, err2 := signingDB.ActiveKeyID()
+		if err2 != nil {
+			logrus.Error(err2)
+			return nil, err2
+		}
+
+		aaa, err2 := asserts2.MakeSnapDeveloperAssertion(signingDB.AuthorityID, publisherId, snapEntry, *collaborators, keyId, signingDB, h.assertions)
+		if err2 == nil && aaa != nil {
+			return aaa, nil
+		} else if err2 != nil {
+			logrus.Error(err2)
+			return nil, err2
+		}
+	} else if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+
+	errUnknown := errors.New("unknown error in GetSnapDeveloperAssertion")
+	logrus.Error(errUnknown)
+	return nil, errUnknown
+}
+
 func (h *Handler) SnapDownload(snapFilename string) (*[]byte, error) {
 	// TODO: make this part of construction
 	obs := objectstore.NewObjectStore()
@@ -261,58 +330,132 @@ func (h *Handler) SnapDownload(snapFilename string) (*[]byte, error) {
 	return nil, errors.New("unknown error encountered while trying to get snap for download")
 }
 
-func (h *Handler) SnapRefresh(actions *[]*requests.SnapActionJSON) (*responses.SnapActionResultList, error) {
-	var actionResults []*responses.SnapActionResult
-	for _, action := range *actions {
-		snapEntry, err := h.snaps.GetSnap(action.Name, true)
-		if err == nil && snapEntry != nil {
-			// TODO: support other actions "refresh", etc.
-			if action.Action == "download" {
-				logrus.Infof("We know about this snap %s, its id is %s we we'll try to handle it.", snapEntry.Name, snapEntry.SnapStoreID)
-
-				snapRevision, err2 := h.snaps.GetRevisionByChannel(action.Channel, action.Name)
-				if err2 == nil && snapRevision != nil {
-					storeSnap, err3 := snapEntry.ToStoreSnap(snapRevision)
-					if err3 == nil && storeSnap != nil {
-						actionResult := responses.SnapActionResult{
-							Result:      "download",
-							InstanceKey: "download-1",
-							SnapID:      snapEntry.SnapStoreID,
-							Name:        snapEntry.Name,
-							Snap:        storeSnap,
-						}
-
-						actionResults = append(actionResults, &actionResult)
-					}
-					logrus.Errorf("unable to process action %s for snap %s: %s", action.Action, action.Name, err3)
-				}
-			} else if action.Action == "install" {
-				logrus.Infof("We know about this snap %s, its id is %s we we'll try to handle it.", snapEntry.Name, snapEntry.SnapStoreID)
-				snapRevision, err2 := h.snaps.GetRevisionByChannel(action.Channel, action.Name)
-				if err2 == nil && snapRevision != nil {
-					storeSnap, err3 := snapEntry.ToStoreSnap(snapRevision)
-					if err3 == nil && storeSnap != nil {
-						// TODO: this shouldn't be a fixed architecture
-						storeSnap.Architectures = []string{"amd64"}
-						storeSnap.Confinement = snapEntry.Confinement
-
-						actionResult := responses.SnapActionResult{
-							Result:      "install",
-							InstanceKey: "install-1",
-							SnapID:      snapEntry.SnapStoreID,
-							Name:        snapEntry.Name,
-							Snap:        storeSnap,
-						}
-
-						actionResults = append(actionResults, &actionResult)
-					}
-				}
+func (h *Handler) GetMedia(filename string) (*models.SnapMedia, *[]byte, error) {
+	snapMedia, err := h.snaps.GetMediaByFilename(filename)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	} else if snapMedia == nil {
+		return nil, nil, nil
+	}
+
+	// TODO: make this part of construction
+	obs := objectstore.NewObjectStore()
+	bytes, err := obs.GetFileFromBucket(models.MediaBucket, snapMedia.Filename)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	return snapMedia, bytes, nil
+}
+
+func (h *Handler) SnapRefresh(actionRequest *requests.SnapActionRequest) (*responses.SnapActionResultList, error) {
+	contexts := make(map[string]*requests.CurrentSnapV2JSON, len(actionRequest.Context))
+	for _, current := range actionRequest.Context {
+		contexts[current.InstanceKey] = current
+	}
+
+	// a malformed validation set key fails the whole request rather than only its action
+	for _, action := range actionRequest.Actions {
+		for _, key := range validationSetKeys(action, contexts[action.InstanceKey]) {
+			if _, err := validationSetSequence(key); err != nil {
+				return nil, err
 			}
-		} else if err != nil {
-			logrus.Error(err)
+		}
+	}
+
+	var actionResults []*responses.SnapActionResult
+	for _, action := range actionRequest.Actions {
+		if action.Action == "fetch-assertions" {
+			actionResults = append(actionResults, h.fetchAssertions(action))
+			continue
+		}
+
+		current := contexts[action.InstanceKey]
+
+		var snapEntry *models.SnapEntry
+		var err error
+		if action.SnapID != "" {
+			snapEntry, err = h.snaps.GetSnapByStoreId(action.SnapID, true)
 		} else {
+			snapEntry, err = h.snaps.GetSnap(action.Name, true)
+		}
+
+		if err != nil {
+			logrus.Error(err)
+			continue
+		} else if snapEntry == nil {
 			logrus.Errorf("cannot process action %s for %s, snap unknown", action.Action, action.Name)
+			continue
+		}
+
+		if action.Action != "download" && action.Action != "install" && action.Action != "refresh" {
+			logrus.Warnf("unsupported action %s for snap %s", action.Action, snapEntry.Name)
+			continue
 		}
+
+		logrus.Infof("We know about this snap %s, its id is %s we we'll try to handle it.", snapEntry.Name, snapEntry.SnapStoreID)
+
+		channel := action.Channel
+		if channel == "" && current != nil {
+			channel = current.TrackingChannel
+		}
+		if channel == "" {
+			channel = "latest/stable"
+		}
+
+		snapRevision, err := h.snaps.GetRevisionByChannel(channel, snapEntry.Name)
+		if err != nil || snapRevision == nil {
+			logrus.Errorf("unable to process action %s for snap %s: %s", action.Action, snapEntry.Name, err)
+			continue
+		}
+
+		if !ignoreValidation(action, current) {
+			var actionError *responses.SnapActionResultError
+			snapRevision, actionError, err = h.applyValidationSets(snapEntry, snapRevision, validationSetKeys(action, current))
+			if err != nil {
+				logrus.Error(err)
+				continue
+			}
+
+			if actionError != nil {
+				actionResults = append(actionResults, &responses.SnapActionResult{
+					Result:      "error",
+					InstanceKey: action.InstanceKey,
+					SnapID:      snapEntry.SnapStoreID,
+					Name:        snapEntry.Name,
+					Error:       *actionError,
+				})
+				continue
+			}
+		}
+
+		// nothing to refresh to
+		if action.Action == "refresh" && current != nil && current.Revision == int(snapRevision.ID) {
+			continue
+		}
+
+		storeSnap, err := snapEntry.ToStoreSnap(snapRevision)
+		if err != nil || storeSnap == nil {
+			logrus.Errorf("unable to process action %s for snap %s: %s", action.Action, snapEntry.Name, err)
+			continue
+		}
+
+		if action.Action != "download" {
+			// TODO: this shouldn't be a fixed architecture
+			storeSnap.Architectures = []string{"amd64"}
+			storeSnap.Confinement = snapEntry.Confinement
+		}
+
+		actionResults = append(actionResults, &responses.SnapActionResult{
+			Result:           action.Action,
+			InstanceKey:      action.InstanceKey,
+			SnapID:           snapEntry.SnapStoreID,
+			Name:             snapEntry.Name,
+			Snap:             storeSnap,
+			EffectiveChannel: channel,
+		})
 	}
 
 	actionResultList := responses.SnapActionResultList{
@@ -329,7 +472,11 @@ func (h *Handler) FindSnap(name string) (*responses.SearchV2Results, error) {
 	}
 
 	snapEntry, err := h.snaps.GetSnap(name, true)
-	if err == nil && snapEntry != nil {
+	if err == nil && snapEntry != nil && snapEntry.Account.IsSuspended() {
+		// the snaps of a suspended account aren't found
+		searchResult.Results = []responses.StoreSearchResult{}
+		return &searchResult, nil
+	} else if err == nil && snapEntry != nil {
 		results := func() []responses.StoreSearchResult {
 			var results []responses.StoreSearchResult
 
@@ -357,7 +504,8 @@ func (h *Handler) FindSnap(name string) (*responses.SearchV2Results, error) {
 						Revision:  1,
 						SnapID:    snapEntry.SnapStoreID,
 						Type:      snapType,
-						Publisher: snap.StoreAccount{ID: snapEntry.Account.AccountId, Username: snapEntry.Account.Username, DisplayName: snapEntry.Account.DisplayName},
+						Publisher: snapEntry.Account.ToStoreAccount(),
+						Media:     snapEntry.GetStoreSnapMedia(),
 					},
 				},
 				Snap: responses.StoreSnap{
@@ -368,7 +516,8 @@ func (h *Handler) FindSnap(name string) (*responses.SearchV2Results, error) {
 					Revision:  1,
 					SnapID:    snapEntry.SnapStoreID,
 					Type:      snapType,
-					Publisher: snap.StoreAccount{ID: snapEntry.Account.AccountId, Username: snapEntry.Account.Username, DisplayName: snapEntry.Account.DisplayName},
+					Publisher: snapEntry.Account.ToStoreAccount(),
+					Media:     snapEntry.GetStoreSnapMedia(),
 				},
 				Name:   snapEntry.Name,
 				SnapID: snapEntry.SnapStoreID,
@@ -377,6 +526,12 @@ func (h *Handler) FindSnap(name string) (*responses.SearchV2Results, error) {
 			return results
 		}()
 
+		latestRevision := snapEntry.LatestRevision()
+		for i := range results {
+			snapEntry.ApplyMetadata(&results[i].Snap, latestRevision)
+			snapEntry.ApplyMetadata(&results[i].Revision.StoreSnap, latestRevision)
+		}
+
 		searchResult.Results = results
 		return &searchResult, nil
 	} else if err != nil {
@@ -397,18 +552,31 @@ func (h *Handler) GetSnapNames() (*responses.CatalogResults, error) {
 		}
 
 		for _, sn := range *snaps {
+			if sn.Account.IsSuspended() {
+				continue
+			}
+
+			latestRevision := sn.LatestRevision()
+			metadata := sn.GetMetadataValues(latestRevision)
+
+			version := "none provided"
+			if latestRevision != nil && latestRevision.Version != "" {
+				version = latestRevision.Version
+			}
+
 			catalogItems.Payload.Items = append(catalogItems.Payload.Items, responses.CatalogItem{
-				Name: sn.Name,
-				// TODO: implement version
-				Version: "none provided",
-				// TODO: implement summary
-				Summary: "none provided",
+				Name:    sn.Name,
+				Version: version,
+				Summary: metadata[models.MetadataFieldSummary],
 				// TODO: implement aliases
 				Aliases: nil,
 				// TODO: implement apps
-				Apps: nil,
-				// TODO: implement title
-				Title: "none provided",
+				Apps:                nil,
+				Title:               metadata[models.MetadataFieldTitle],
+				Publisher:           sn.Account.DisplayName,
+				DeveloperID:         sn.Account.AccountId,
+				DeveloperName:       sn.Account.Username,
+				DeveloperValidation: sn.Account.GetValidation(),
 			})
 		}
 
@@ -427,43 +595,44 @@ func (h *Handler) GetSections() (*responses.SectionResults, error) {
 	if err == nil && sections != nil {
 		results := responses.SectionResults{
 			Payload: responses.Payload{
-				Sections: []responses.Section{
-					{Name: "general"},
-				},
+				Sections: []responses.Section{},
 			},
 		}
 
+		for _, section := range *sections {
+			results.Payload.Sections = append(results.Payload.Sections, responses.Section{Name: section})
+		}
+
 		return &results, nil
 	}
 
 	return nil, errors.New("unknown error")
 }
 
-func createAccountAssertion(signingDB *assertstest.SigningDB, keyId string, accountId string, storeAccountUsername string) *asserts.Account {
+func createAccountAssertion(signingDB *crypto.SigningDB, keyId string, account *models.Account, assertionsRepo repositories.IAssertionsRepository) (*asserts.Account, error) {
 	trustedAcctHeaders := map[string]interface{}{
-		"validation": "certified",
-		"timestamp":  "2015-11-20T15:04:00Z",
-		"account-id": accountId,
+		"authority-id": signingDB.AuthorityID,
+		"validation":   account.GetValidation(),
+		"timestamp":    account.UpdatedAt.UTC().Format(time.RFC3339),
+		"account-id":   account.AccountId,
+		"username":     account.Username,
+		"display-name": account.DisplayName,
 	}
 
-	trustedAcct := assertstest.NewAccount(signingDB, storeAccountUsername, trustedAcctHeaders, keyId)
-	return trustedAcct
-}
-
-func getTrustedAccount(accountID string, signingDB *assertstest.SigningDB, displayName string) *asserts.Account {
-	trustedAcctHeaders := map[string]interface{}{
-		"validation": "verified",
-		"timestamp":  "2015-11-20T15:04:00Z",
+	if account.DisplayName == "" {
+		trustedAcctHeaders["display-name"] = account.Username
 	}
 
-	if displayName != "" {
-		trustedAcctHeaders["display-name"] = displayName
+	assertion, err := asserts2.SignAndPersist(asserts.AccountType, trustedAcctHeaders, nil, keyId, signingDB, assertionsRepo)
+	if err != nil {
+		return nil, err
 	}
 
-	trustedAcctHeaders["account-id"] = accountID
-	trustedAcct := assertstest.NewAccount(signingDB, accountID, trustedAcctHeaders, "")
+	if trustedAcct, ok := assertion.(*asserts.Account); ok {
+		return trustedAcct, nil
+	}
 
-	return trustedAcct
+	return nil, errors.New("unable to cast assertion")
 }
 
 func saveFileToTemp(snapFile io.Reader) (string, string, error) {
@@ -22,6 +22,9 @@ type CurrentSnapV2JSON struct {
 	RefreshedDate    *time.Time `json:"refreshed-date,omitempty"`
 	IgnoreValidation bool       `json:"ignore-validation,omitempty"`
 	CohortKey        string     `json:"cohort-key,omitempty"`
+	// ValidationSets are the enforced validation sets that constrain the snap, each is the
+	// series, account-id, name and optionally sequence of the set
+	ValidationSets [][]string `json:"validation-sets,omitempty"`
 }
 
 type SnapActionJSON struct {
@@ -34,6 +37,8 @@ type SnapActionJSON struct {
 	Revision         int    `json:"revision,omitempty"`
 	CohortKey        string `json:"cohort-key,omitempty"`
 	IgnoreValidation *bool  `json:"ignore-validation,omitempty"`
+	// ValidationSets are the enforced validation sets that constrain the snap, like in CurrentSnapV2JSON
+	ValidationSets [][]string `json:"validation-sets,omitempty"`
 
 	// NOTE the store needs an epoch (even if null) for the "install" and "download"
 	// actions, to know the client handles epochs at all.  "refresh" actions should
@@ -49,8 +54,14 @@ type SnapActionJSON struct {
 
 type AssertAtJSON struct {
 	Type        string   `json:"type"`
-	PrimaryKey  []string `json:"primary-key"`
+	PrimaryKey  []string `json:"primary-key,omitempty"`
 	IfNewerThan *int     `json:"if-newer-than,omitempty"`
+
+	// For sequence forming assertions, like validation-set
+	SequenceKey                []string `json:"sequence-key,omitempty"`
+	Sequence                   int      `json:"sequence,omitempty"`
+	IfSequenceEqualOrNewerThan *int     `json:"if-sequence-equal-or-newer-than,omitempty"`
+	IfSequenceNewerThan        *int     `json:"if-sequence-newer-than,omitempty"`
 }
 
 type SnapPush struct {
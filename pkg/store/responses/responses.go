@@ -74,12 +74,16 @@ type CatalogResults struct {
 }
 
 type CatalogItem struct {
-	Name    string   `json:"package_name"`
-	Version string   `json:"version"`
-	Summary string   `json:"summary"`
-	Aliases []Alias  `json:"aliases"`
-	Apps    []string `json:"apps"`
-	Title   string   `json:"title"`
+	Name                string   `json:"package_name"`
+	Version             string   `json:"version"`
+	Summary             string   `json:"summary"`
+	Aliases             []Alias  `json:"aliases"`
+	Apps                []string `json:"apps"`
+	Title               string   `json:"title"`
+	Publisher           string   `json:"publisher"`
+	DeveloperID         string   `json:"developer_id"`
+	DeveloperName       string   `json:"developer_name"`
+	DeveloperValidation string   `json:"developer_validation"`
 }
 
 type SnapRelease struct {
@@ -91,8 +95,10 @@ type ErrorListEntry struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	// for assertions
-	Type       string   `json:"type"`
-	PrimaryKey []string `json:"primary-key"`
+	Type string `json:"type"`
+	// either primary-key or sequence-key is set
+	PrimaryKey  []string `json:"primary-key,omitempty"`
+	SequenceKey []string `json:"sequence-key,omitempty"`
 }
 
 type SnapActionResultList struct {
@@ -1,37 +1,36 @@
 package store
 
 import (
-	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"io"
 	"mime/multipart"
 	"net/http"
 
+	"github.com/freetocompute/kebe/pkg/crypto"
+	"github.com/freetocompute/kebe/pkg/devicesession"
+	"github.com/freetocompute/kebe/pkg/serialvault"
 	"github.com/freetocompute/kebe/pkg/store/responses"
 
 	"github.com/freetocompute/kebe/pkg/store/requests"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/snapcore/snapd/asserts"
-	"github.com/snapcore/snapd/asserts/assertstest"
 )
 
 type Store struct {
-	assertsDatabase   *asserts.Database
-	rootStoreKey      *rsa.PrivateKey
-	genericPrivateKey *rsa.PrivateKey
-	signingDB         *assertstest.SigningDB
-	handler           IStoreHandler
+	assertsDatabase *asserts.Database
+	// signingDB signs as the store with its active key
+	signingDB *crypto.SigningDB
+	handler   IStoreHandler
 }
 
-func New(handler IStoreHandler, assertsDB *asserts.Database, rootStoreKey *rsa.PrivateKey, genericPrivateKey *rsa.PrivateKey, signingDB *assertstest.SigningDB) *Store {
+func New(handler IStoreHandler, assertsDB *asserts.Database, signingDB *crypto.SigningDB) *Store {
 	return &Store{
 		// db:                db,
-		assertsDatabase:   assertsDB,
-		rootStoreKey:      rootStoreKey,
-		signingDB:         signingDB,
-		genericPrivateKey: genericPrivateKey,
-		handler:           handler,
+		assertsDatabase: assertsDB,
+		signingDB:       signingDB,
+		handler:         handler,
 	}
 }
 
@@ -53,6 +52,32 @@ func (s *Store) snapDownload(c *gin.Context) {
 	c.AbortWithStatus(http.StatusInternalServerError)
 }
 
+func (s *Store) getMedia(c *gin.Context) {
+	filename := c.Param("filename")
+
+	snapMedia, bytes, err := s.handler.GetMedia(filename)
+	if err == nil && snapMedia == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	} else if err != nil || bytes == nil {
+		logrus.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	// media is content addressed, so it never changes once it has a name
+	etag := "\"" + snapMedia.SHA3_384 + "\""
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", snapMedia.CreatedAt.UTC().Format(http.TimeFormat))
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, snapMedia.ContentType, *bytes)
+}
+
 func (s *Store) snapRefresh(c *gin.Context) {
 	request := c.Request
 	writer := c.Writer
@@ -67,10 +92,13 @@ func (s *Store) snapRefresh(c *gin.Context) {
 
 	writer.Header().Set("Content-Type", "application/json")
 
-	snapActionResultList, err := s.handler.SnapRefresh(&actionRequest.Actions)
+	snapActionResultList, err := s.handler.SnapRefresh(&actionRequest)
 	if err == nil && snapActionResultList != nil {
 		c.JSON(http.StatusOK, &snapActionResultList)
 		return
+	} else if errors.Is(err, ErrInvalidValidationSetKey) {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
 	} else if err != nil {
 		logrus.Error(err)
 	} else {
@@ -190,7 +218,7 @@ func (s *Store) authDevicePOST(c *gin.Context) {
 		if got.Type() == asserts.SerialRequestType {
 			serialRequest := got.(*asserts.SerialRequest)
 
-			serialAssertion, err2 := s.handler.AuthDevice(serialRequest, asserts.RSAPrivateKey(s.genericPrivateKey), s.signingDB)
+			serialAssertion, err2 := s.handler.AuthDevice(serialRequest)
 			if err2 == nil && serialAssertion != nil {
 				encodedSerialAssertion := asserts.Encode(serialAssertion)
 				logrus.Trace("Sending serial assertion: ")
@@ -203,6 +231,10 @@ func (s *Store) authDevicePOST(c *gin.Context) {
 				}
 
 				logrus.Error(err3)
+			} else if err2 != nil {
+				logrus.Error(err2)
+				abortWithSerialVaultError(c, err2)
+				return
 			}
 		} else {
 			logrus.Warningf("Assertion type included but not exepected: %s", got.Type().Name)
@@ -212,14 +244,82 @@ func (s *Store) authDevicePOST(c *gin.Context) {
 	c.AbortWithStatus(http.StatusInternalServerError)
 }
 
+// abortWithSerialVaultError aborts with a status matching the reason the serial vault refused the request
+func abortWithSerialVaultError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, serialvault.ErrUnknownModel):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"code": "unknown-model", "message": err.Error()})
+	case errors.Is(err, serialvault.ErrSerialRevoked):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": "serial-revoked", "message": err.Error()})
+	case errors.Is(err, serialvault.ErrInvalidRequest):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"code": "invalid-request", "message": err.Error()})
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
 func (s *Store) authNonce(c *gin.Context) {
-	// TODO: do we need to store this?
-	nonce := s.handler.AuthNonce()
-	c.JSON(http.StatusOK, &nonce)
+	nonce, err := s.handler.AuthNonce()
+	if err == nil && nonce != nil {
+		c.JSON(http.StatusOK, &nonce)
+		return
+	}
+
+	logrus.Error(err)
+	c.AbortWithStatus(http.StatusInternalServerError)
 }
 
 func (s *Store) authSession(c *gin.Context) {
-	// TODO: implement actual sessions?
-	session := s.handler.AuthSession()
-	c.JSON(http.StatusOK, session)
+	var sessionRequest requests.Session
+	err := json.NewDecoder(c.Request.Body).Decode(&sessionRequest)
+	if err != nil {
+		logrus.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"code": "invalid-request", "message": err.Error()})
+		return
+	}
+
+	session, err := s.handler.AuthSession(&sessionRequest)
+	if err == nil && session != nil {
+		c.JSON(http.StatusOK, session)
+		return
+	}
+
+	logrus.Error(err)
+	if errors.Is(err, devicesession.ErrInvalidSessionRequest) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"code": "invalid-request", "message": err.Error()})
+		return
+	}
+
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// identifyDevice sets the serial of the device making the request if it sent a device macaroon, snapd is asked
+// to get a new session when the one it sent is no longer valid
+func (s *Store) identifyDevice(c *gin.Context) {
+	authorization := c.GetHeader("X-Device-Authorization")
+	if authorization == "" {
+		authorization = c.GetHeader("Snap-Device-Authorization")
+	}
+
+	if authorization == "" {
+		c.Next()
+		return
+	}
+
+	serial, err := s.handler.IdentifyDevice(authorization)
+	if err == nil && serial != nil {
+		logrus.Tracef("Request from device %s/%s/%s", serial.BrandID, serial.ModelName, serial.SerialUUID)
+		c.Set("device", serial)
+		c.Next()
+		return
+	}
+
+	logrus.Error(err)
+	if errors.Is(err, devicesession.ErrInvalidSession) {
+		c.Header("WWW-Authenticate", "Macaroon refresh_device_session=1")
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusInternalServerError)
 }
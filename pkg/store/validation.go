@@ -0,0 +1,225 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/freetocompute/kebe/config/configkey"
+	"github.com/freetocompute/kebe/pkg/assertions"
+	"github.com/freetocompute/kebe/pkg/models"
+	"github.com/freetocompute/kebe/pkg/store/requests"
+	"github.com/freetocompute/kebe/pkg/store/responses"
+	"github.com/sirupsen/logrus"
+	"github.com/snapcore/snapd/asserts"
+	"github.com/spf13/viper"
+)
+
+// ErrInvalidValidationSetKey is returned for a snap action request with a malformed validation set key
+var ErrInvalidValidationSetKey = errors.New("invalid validation set key")
+
+// GetValidationSetAssertion returns the signed validation set at the given sequence, or the latest signed
+// sequence if sequence is zero. nil is returned if there is no such signed validation set.
+func (h *Handler) GetValidationSetAssertion(accountId string, name string, sequence int) (*asserts.ValidationSet, error) {
+	sequences := []int{sequence}
+	if sequence <= 0 {
+		validationSets, err := h.validationSets.GetValidationSets(accountId, name)
+		if err != nil {
+			return nil, err
+		}
+
+		sequences = nil
+		for _, validationSet := range validationSets {
+			sequences = append(sequences, validationSet.Sequence)
+		}
+	}
+
+	for _, seq := range sequences {
+		stored, err := h.assertions.GetLatestAssertion(asserts.ValidationSetType.Name, validationSetPrimaryKey(accountId, name, seq))
+		if err != nil {
+			return nil, err
+		}
+
+		if stored == nil {
+			continue
+		}
+
+		assertion, err := asserts.Decode([]byte(stored.Encoded))
+		if err != nil {
+			return nil, err
+		}
+
+		if validationSet, ok := assertion.(*asserts.ValidationSet); ok {
+			return validationSet, nil
+		}
+
+		return nil, fmt.Errorf("unable to assert type on validation-set %s/%s/%d", accountId, name, seq)
+	}
+
+	return nil, nil
+}
+
+// ignoreValidation returns true if the device asked for validation sets to be ignored for the snap, the action
+// takes precedence over what the context says
+func ignoreValidation(action *requests.SnapActionJSON, current *requests.CurrentSnapV2JSON) bool {
+	if action.IgnoreValidation != nil {
+		return *action.IgnoreValidation
+	}
+
+	return current != nil && current.IgnoreValidation
+}
+
+func validationSetKeys(action *requests.SnapActionJSON, current *requests.CurrentSnapV2JSON) [][]string {
+	var keys [][]string
+	keys = append(keys, action.ValidationSets...)
+	if current != nil {
+		keys = append(keys, current.ValidationSets...)
+	}
+
+	return keys
+}
+
+// validationSetSequence checks the validation set key is series/account-id/name with an optional sequence and
+// returns the sequence, zero if there is none
+func validationSetSequence(key []string) (int, error) {
+	if len(key) < 3 || len(key) > 4 {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidValidationSetKey, key)
+	}
+
+	if len(key) == 3 {
+		return 0, nil
+	}
+
+	sequence, err := strconv.Atoi(key[3])
+	if err != nil || sequence < 1 {
+		return 0, fmt.Errorf("%w: %v has an invalid sequence", ErrInvalidValidationSetKey, key)
+	}
+
+	return sequence, nil
+}
+
+// applyValidationSets returns the revision the enforced validation sets require for the snap, or the revision
+// given if they don't pin one. An action error is returned if the sets don't allow the snap at all.
+func (h *Handler) applyValidationSets(snapEntry *models.SnapEntry, snapRevision *models.SnapRevision, keys [][]string) (*models.SnapRevision, *responses.SnapActionResultError, error) {
+	required := 0
+	for _, key := range keys {
+		sequence, err := validationSetSequence(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		validationSet, err := h.GetValidationSetAssertion(key[1], key[2], sequence)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if validationSet == nil {
+			logrus.Warnf("Unknown validation set %s, ignoring it", strings.Join(key, "/"))
+			continue
+		}
+
+		for _, vsSnap := range validationSet.Snaps() {
+			if vsSnap.SnapID != snapEntry.SnapStoreID && vsSnap.Name != snapEntry.Name {
+				continue
+			}
+
+			if vsSnap.Presence == asserts.PresenceInvalid {
+				return nil, &responses.SnapActionResultError{
+					Code:    "invalid-for-validation-set",
+					Message: fmt.Sprintf("snap %s is not allowed by validation set %s/%s", snapEntry.Name, validationSet.AccountID(), validationSet.Name()),
+				}, nil
+			}
+
+			if vsSnap.Revision == 0 {
+				continue
+			}
+
+			if required != 0 && required != vsSnap.Revision {
+				return nil, &responses.SnapActionResultError{
+					Code:    "revision-conflict",
+					Message: fmt.Sprintf("validation sets require different revisions of snap %s: %d and %d", snapEntry.Name, required, vsSnap.Revision),
+				}, nil
+			}
+
+			required = vsSnap.Revision
+		}
+	}
+
+	if required == 0 || required == int(snapRevision.ID) {
+		return snapRevision, nil, nil
+	}
+
+	requiredRevision, err := h.snaps.GetRevision(uint(required))
+	if err != nil || requiredRevision == nil || requiredRevision.SnapEntryID != snapEntry.ID {
+		return nil, &responses.SnapActionResultError{
+			Code:    "revision-not-found",
+			Message: fmt.Sprintf("revision %d of snap %s required by a validation set is not available", required, snapEntry.Name),
+		}, nil
+	}
+
+	return requiredRevision, nil, nil
+}
+
+// fetchAssertions answers a fetch-assertions action with the urls of the assertions that are newer than what
+// the device has
+func (h *Handler) fetchAssertions(action *requests.SnapActionJSON) *responses.SnapActionResult {
+	result := &responses.SnapActionResult{
+		Result:              "fetch-assertions",
+		Key:                 action.Key,
+		AssertionStreamURLs: []string{},
+	}
+
+	assertionsURL := viper.GetString(configkey.StoreAPIURL) + "/v2/assertions/"
+	for _, at := range action.Assertions {
+		if len(at.SequenceKey) > 0 {
+			if at.Type != asserts.ValidationSetType.Name || len(at.SequenceKey) != 3 {
+				result.ErrorList = append(result.ErrorList, responses.ErrorListEntry{Code: "not-found", Message: "unsupported sequence forming assertion", Type: at.Type, SequenceKey: at.SequenceKey})
+				continue
+			}
+
+			validationSet, err := h.GetValidationSetAssertion(at.SequenceKey[1], at.SequenceKey[2], at.Sequence)
+			if err != nil {
+				logrus.Error(err)
+			}
+
+			if validationSet == nil {
+				result.ErrorList = append(result.ErrorList, responses.ErrorListEntry{Code: "not-found", Message: "validation set not found", Type: at.Type, SequenceKey: at.SequenceKey})
+				continue
+			}
+
+			if (at.IfSequenceEqualOrNewerThan != nil && validationSet.Sequence() < *at.IfSequenceEqualOrNewerThan) ||
+				(at.IfSequenceNewerThan != nil && validationSet.Sequence() <= *at.IfSequenceNewerThan) {
+				continue
+			}
+
+			query := url.Values{}
+			query.Set("sequence", strconv.Itoa(validationSet.Sequence()))
+			result.AssertionStreamURLs = append(result.AssertionStreamURLs, assertionsURL+at.Type+"/"+strings.Join(at.SequenceKey, "/")+"?"+query.Encode())
+			continue
+		}
+
+		stored, err := h.assertions.GetLatestAssertion(at.Type, assertions.JoinKey(at.PrimaryKey))
+		if err != nil {
+			logrus.Error(err)
+		}
+
+		if stored == nil {
+			result.ErrorList = append(result.ErrorList, responses.ErrorListEntry{Code: "not-found", Message: "assertion not found", Type: at.Type, PrimaryKey: at.PrimaryKey})
+			continue
+		}
+
+		if at.IfNewerThan != nil && stored.Revision <= *at.IfNewerThan {
+			continue
+		}
+
+		result.AssertionStreamURLs = append(result.AssertionStreamURLs, assertionsURL+at.Type+"/"+strings.Join(at.PrimaryKey, "/"))
+	}
+
+	return result
+}
+
+func validationSetPrimaryKey(accountId string, name string, sequence int) string {
+	return assertions.JoinKey([]string{"16", accountId, name, strconv.Itoa(sequence)})
+}